@@ -0,0 +1,102 @@
+package y_middleware
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// AccessLogFormat selects the line format written by AccessLog.
+type AccessLogFormat int
+
+const (
+	// CommonLogFormat is the NCSA Common Log Format.
+	CommonLogFormat AccessLogFormat = iota
+	// CombinedLogFormat is the Common Log Format followed by the referer and user agent.
+	CombinedLogFormat
+)
+
+// accessLogTimeFormat is the timestamp layout used by the NCSA log formats.
+const accessLogTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// AccessLog is a middleware handler that writes an access log line per request in the NCSA
+// Common or Combined Log Format, for use with existing log analysis tools. Requests served by
+// Retry get their attempt number appended as an extra "attempt=N" field. Headers and query
+// parameters marked by Redactor are masked.
+type AccessLog struct {
+	format AccessLogFormat
+	mu     sync.Mutex
+	w      io.Writer
+	clock  Clock
+}
+
+// NewAccessLog returns a new AccessLog instance writing lines in format to w.
+func NewAccessLog(w io.Writer, format AccessLogFormat) *AccessLog {
+	return &AccessLog{
+		format: format,
+		w:      w,
+	}
+}
+
+// WithClock makes the AccessLog read the time from c instead of the system clock.
+func (a *AccessLog) WithClock(c Clock) *AccessLog {
+	a.clock = c
+	return a
+}
+
+func (a *AccessLog) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	start := clockOrDefault(a.clock).Now()
+
+	res := wrapResponseWriter(rw)
+	next(res, r)
+
+	r = RedactRequest(r)
+	uri := r.RequestURI
+	if uri == "" {
+		uri = r.URL.RequestURI()
+	}
+	requestLine := r.Method + " " + uri + " " + r.Proto
+
+	status := res.Status()
+	if status == 0 {
+		status = http.StatusOK
+	}
+	size := "-"
+	if res.Size() > 0 {
+		size = strconv.Itoa(res.Size())
+	}
+
+	line := fmt.Sprintf("%s - %s [%s] %q %d %s",
+		clientIP(r),
+		accessLogUser(r),
+		start.Format(accessLogTimeFormat),
+		requestLine,
+		status,
+		size,
+	)
+	if a.format == CombinedLogFormat {
+		line += fmt.Sprintf(" %q %q", r.Referer(), r.UserAgent())
+	}
+	if attempt := Attempt(r.Context()); attempt > 0 {
+		line += " attempt=" + strconv.Itoa(attempt)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	io.WriteString(a.w, line+"\n")
+}
+
+// accessLogUser returns the authenticated user name of the request, or "-" if there is none.
+func accessLogUser(r *http.Request) string {
+	if r.URL.User != nil {
+		if name := r.URL.User.Username(); name != "" {
+			return name
+		}
+	}
+	if name, _, ok := r.BasicAuth(); ok && name != "" {
+		return name
+	}
+	return "-"
+}
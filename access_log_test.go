@@ -0,0 +1,61 @@
+package y_middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAccessLogCommonFormat(t *testing.T) {
+	var buf bytes.Buffer
+	a := NewAccessLog(&buf, CommonLogFormat).WithClock(newFakeClock())
+	k := New(a)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusCreated)
+		io.WriteString(rw, "created")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/items?x=1", nil)
+	req.SetBasicAuth("alice", "secret")
+	k.ServeHTTP(httptest.NewRecorder(), req)
+
+	want := `192.0.2.1 - alice [01/Jan/2024:00:00:00 +0000] "POST /items?x=1 HTTP/1.1" 201 7` + "\n"
+	if buf.String() != want {
+		t.Errorf("logged %q, want %q", buf.String(), want)
+	}
+}
+
+func TestAccessLogCombinedFormat(t *testing.T) {
+	var buf bytes.Buffer
+	a := NewAccessLog(&buf, CombinedLogFormat).WithClock(newFakeClock())
+	k := New(a)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Referer", "https://example.com/")
+	req.Header.Set("User-Agent", "test/1.0")
+	k.ServeHTTP(httptest.NewRecorder(), req)
+
+	want := `192.0.2.1 - - [01/Jan/2024:00:00:00 +0000] "GET / HTTP/1.1" 200 - "https://example.com/" "test/1.0"` + "\n"
+	if buf.String() != want {
+		t.Errorf("logged %q, want %q", buf.String(), want)
+	}
+}
+
+func TestAccessLogRedactsQuery(t *testing.T) {
+	var buf bytes.Buffer
+	k := New(NewRedactor(nil, []string{"token"}), NewAccessLog(&buf, CommonLogFormat).WithClock(newFakeClock()))
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {})
+
+	k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items?token=s3cret&page=2", nil))
+
+	if strings.Contains(buf.String(), "s3cret") {
+		t.Errorf("logged %q, want the token masked", buf.String())
+	}
+	if !strings.Contains(buf.String(), "page=2") {
+		t.Errorf("logged %q, want other parameters kept", buf.String())
+	}
+}
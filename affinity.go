@@ -0,0 +1,53 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+)
+
+type affinityKey struct{}
+
+// Affinity is a middleware handler that pins clients to a backend instance with a sticky
+// cookie, for deployments where a set of replicas sits behind a load balancer without session
+// affinity of its own. Clients without the cookie are pinned to this Instance. The instance a
+// request is pinned to is stored in the request context, where handlers read it with
+// AffinityFrom, e.g. to forward requests pinned elsewhere.
+type Affinity struct {
+	// Instance identifies this backend, e.g. the pod name.
+	Instance string
+	// Cookie is used as a template for the sticky cookie. Its Value is overwritten.
+	Cookie http.Cookie
+}
+
+// NewAffinity returns a new Affinity instance pinning new clients to instance.
+func NewAffinity(instance string) *Affinity {
+	return &Affinity{
+		Instance: instance,
+		Cookie: http.Cookie{
+			Name:     "affinity",
+			Path:     "/",
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		},
+	}
+}
+
+func (a *Affinity) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	key := ""
+	if cookie, err := r.Cookie(a.Cookie.Name); err == nil {
+		key = cookie.Value
+	}
+	if key == "" {
+		key = a.Instance
+		cookie := a.Cookie
+		cookie.Value = key
+		http.SetCookie(rw, &cookie)
+	}
+	next(rw, r.WithContext(context.WithValue(r.Context(), affinityKey{}, key)))
+}
+
+// AffinityFrom returns the instance Affinity pinned the request to, or an empty string.
+func AffinityFrom(ctx context.Context) string {
+	key, _ := ctx.Value(affinityKey{}).(string)
+	return key
+}
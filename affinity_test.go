@@ -0,0 +1,46 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func serveAffinity(a *Affinity, cookie *http.Cookie) (string, []*http.Cookie) {
+	var pinned string
+	k := New(a)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		pinned = AffinityFrom(r.Context())
+	})
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, r)
+	return pinned, rec.Result().Cookies()
+}
+
+func TestAffinityPinsNewClients(t *testing.T) {
+	pinned, cookies := serveAffinity(NewAffinity("pod-a"), nil)
+	if pinned != "pod-a" {
+		t.Errorf("AffinityFrom = %q, want pod-a", pinned)
+	}
+	if len(cookies) != 1 || cookies[0].Name != "affinity" || cookies[0].Value != "pod-a" || !cookies[0].HttpOnly {
+		t.Errorf("cookies = %v, want an HttpOnly affinity=pod-a cookie", cookies)
+	}
+}
+
+func TestAffinityKeepsExistingPin(t *testing.T) {
+	pinned, cookies := serveAffinity(NewAffinity("pod-a"), &http.Cookie{Name: "affinity", Value: "pod-b"})
+	if pinned != "pod-b" {
+		t.Errorf("AffinityFrom = %q, want the cookie's pod-b", pinned)
+	}
+	if len(cookies) != 0 {
+		t.Errorf("cookies = %v, want no new cookie", cookies)
+	}
+
+	if pinned, _ := serveAffinity(NewAffinity("pod-a"), &http.Cookie{Name: "affinity", Value: ""}); pinned != "pod-a" {
+		t.Errorf("empty cookie: AffinityFrom = %q, want pod-a", pinned)
+	}
+}
@@ -0,0 +1,50 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+)
+
+type principalKey struct{}
+
+// Authenticator authenticates a request. It returns the authenticated principal and true if the
+// request carries valid credentials for its scheme, false if it does not, and an error if the
+// credentials could not be checked at all, e.g. because a backing service is down.
+type Authenticator func(r *http.Request) (principal any, ok bool, err error)
+
+// AuthChain is a middleware handler that tries each of its Authenticators in order until one
+// succeeds, so one endpoint can accept several authentication schemes such as API keys, JWT
+// and basic auth. The principal of the first successful Authenticator is stored in the request
+// context, where handlers read it with PrincipalFrom. If none succeeds the client gets a 401,
+// and if one fails with an error the chain stops there and the client gets a 500; either way
+// the rest of the chain is skipped. Errors are recorded with AddError.
+type AuthChain struct {
+	Authenticators []Authenticator
+}
+
+// NewAuthChain returns a new AuthChain instance trying authenticators in order.
+func NewAuthChain(authenticators ...Authenticator) *AuthChain {
+	return &AuthChain{Authenticators: authenticators}
+}
+
+func (a *AuthChain) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	for _, authenticate := range a.Authenticators {
+		principal, ok, err := authenticate(r)
+		if err != nil {
+			AddError(r.Context(), err)
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		if ok {
+			next(rw, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+			return
+		}
+	}
+	http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+// PrincipalFrom returns the principal authenticated by AuthChain, and whether there is one.
+func PrincipalFrom(ctx context.Context) (any, bool) {
+	principal := ctx.Value(principalKey{})
+	return principal, principal != nil
+}
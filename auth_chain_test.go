@@ -0,0 +1,110 @@
+package y_middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// apiKeyAuth authenticates requests carrying key in the X-API-Key header.
+func apiKeyAuth(key string, principal any) Authenticator {
+	return func(r *http.Request) (any, bool, error) {
+		return principal, r.Header.Get("X-API-Key") == key, nil
+	}
+}
+
+func serveAuthChain(a *AuthChain, req *http.Request) (*httptest.ResponseRecorder, any) {
+	var principal any = "<not served>"
+	k := New(a)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		principal, _ = PrincipalFrom(r.Context())
+	})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, req)
+	return rec, principal
+}
+
+func TestAuthChainFirstMatchWins(t *testing.T) {
+	tried := 0
+	counting := func(r *http.Request) (any, bool, error) {
+		tried++
+		return "unreachable", true, nil
+	}
+	a := NewAuthChain(apiKeyAuth("key-1", "service-1"), apiKeyAuth("key-2", "service-2"), counting)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-API-Key", "key-2")
+	rec, principal := serveAuthChain(a, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if principal != "service-2" {
+		t.Errorf("principal = %v, want service-2", principal)
+	}
+	if tried != 0 {
+		t.Error("authenticators after the first match were tried")
+	}
+}
+
+func TestAuthChainFallsThroughToLaterScheme(t *testing.T) {
+	clock := newFakeClock()
+	j := NewJWT(NewJWTKeyVerifier(JWTKey(jwtSecret))).WithClock(clock)
+	a := NewAuthChain(apiKeyAuth("key", "service"), j.Authenticate)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+signJWT(t, "HS256", "", jwtSecret, Claims{"sub": "alice"}))
+
+	var claims Claims
+	k := New(a)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		claims, _ = ClaimsFrom(r.Context())
+	})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if claims["sub"] != "alice" {
+		t.Errorf("ClaimsFrom sub = %v, want alice", claims["sub"])
+	}
+}
+
+func TestAuthChainAllFail(t *testing.T) {
+	a := NewAuthChain(apiKeyAuth("key-1", "service-1"), apiKeyAuth("key-2", "service-2"))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-API-Key", "wrong")
+
+	rec, principal := serveAuthChain(a, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if principal != "<not served>" {
+		t.Error("handler was served")
+	}
+
+	if rec, _ := serveAuthChain(NewAuthChain(), httptest.NewRequest(http.MethodGet, "/", nil)); rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d without authenticators, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestAuthChainError(t *testing.T) {
+	errBackend := errors.New("auth backend down")
+	failing := func(r *http.Request) (any, bool, error) {
+		return nil, false, errBackend
+	}
+	a := NewAuthChain(failing, apiKeyAuth("key", "service"))
+
+	req := withRequestErrors(httptest.NewRequest(http.MethodGet, "/", nil))
+	req.Header.Set("X-API-Key", "key")
+	rec, principal := serveAuthChain(a, req)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if principal != "<not served>" {
+		t.Error("a later authenticator was tried after an error")
+	}
+	if errs := Errors(req.Context()); len(errs) != 1 || !errors.Is(errs[0], errBackend) {
+		t.Errorf("recorded errors = %v, want [%v]", errs, errBackend)
+	}
+}
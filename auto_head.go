@@ -0,0 +1,94 @@
+package y_middleware
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// AutoHead is a middleware handler that answers HEAD requests with handlers that only implement
+// GET. The rest of the chain sees HEAD requests as GET requests, and the body they write is
+// discarded while the status and headers are sent as usual. Unless the handler set
+// Content-Length itself or flushed the response, it is set to the length of the discarded body,
+// as the GET response would have had.
+type AutoHead struct{}
+
+// NewAutoHead returns a new AutoHead instance.
+func NewAutoHead() *AutoHead {
+	return &AutoHead{}
+}
+
+func (a *AutoHead) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.Method != http.MethodHead {
+		next(rw, r)
+		return
+	}
+
+	get := r.WithContext(r.Context())
+	get.Method = http.MethodGet
+	hw := &headResponseWriter{ResponseWriter: wrapResponseWriter(rw)}
+	next(hw, get)
+	hw.sendHeader()
+}
+
+// headResponseWriter sends the status and headers of a response but drops its body. It holds
+// the status back until the handler returns, so Content-Length can be set from the body size.
+type headResponseWriter struct {
+	ResponseWriter
+	status int
+	size   int
+}
+
+func (w *headResponseWriter) WriteHeader(code int) {
+	if w.status == 0 && !w.ResponseWriter.Written() {
+		w.status = code
+	}
+}
+
+func (w *headResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.size += len(p)
+	return len(p), nil
+}
+
+func (w *headResponseWriter) Status() int {
+	if w.status != 0 && !w.ResponseWriter.Written() {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *headResponseWriter) Written() bool {
+	return w.status != 0 || w.ResponseWriter.Written()
+}
+
+// Flush sends the headers as they are, since the body size is not final yet.
+func (w *headResponseWriter) Flush() {
+	w.writeHeader()
+	w.ResponseWriter.Flush()
+}
+
+// sendHeader sends the status and headers once the handler has returned, setting
+// Content-Length from the discarded body.
+func (w *headResponseWriter) sendHeader() {
+	if w.ResponseWriter.Written() {
+		return
+	}
+	h := w.Header()
+	if h.Get("Content-Length") == "" && h.Get("Transfer-Encoding") == "" && bodyAllowedForStatus(w.Status()) {
+		h.Set("Content-Length", strconv.Itoa(w.size))
+	}
+	w.writeHeader()
+}
+
+func (w *headResponseWriter) writeHeader() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+func (w *headResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,95 @@
+package y_middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func serveHead(h http.HandlerFunc) *httptest.ResponseRecorder {
+	k := New(NewAutoHead())
+	k.UseHandlerFunc(h)
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/", nil))
+	return rec
+}
+
+func TestAutoHeadDiscardsBody(t *testing.T) {
+	method := ""
+	rec := serveHead(func(rw http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		rw.Header().Set("Content-Type", "text/plain")
+		rw.WriteHeader(http.StatusAccepted)
+		io.WriteString(rw, "hello ")
+		io.WriteString(rw, "world")
+	})
+
+	if method != http.MethodGet {
+		t.Errorf("handler saw method %s, want GET", method)
+	}
+	if rec.Code != http.StatusAccepted {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("body = %q, want it discarded", rec.Body)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/plain" {
+		t.Errorf("Content-Type = %q, want text/plain", got)
+	}
+	if got := rec.Header().Get("Content-Length"); got != "11" {
+		t.Errorf("Content-Length = %q, want 11", got)
+	}
+}
+
+func TestAutoHeadKeepsHandlerContentLength(t *testing.T) {
+	rec := serveHead(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Length", "1024")
+	})
+	if got := rec.Header().Get("Content-Length"); got != "1024" {
+		t.Errorf("Content-Length = %q, want 1024", got)
+	}
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestAutoHeadNoContentLengthWithoutBody(t *testing.T) {
+	rec := serveHead(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusNoContent)
+	})
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if got := rec.Header().Get("Content-Length"); got != "" {
+		t.Errorf("Content-Length = %q for a 204, want none", got)
+	}
+}
+
+func TestAutoHeadFlushSendsHeaders(t *testing.T) {
+	rec := serveHead(func(rw http.ResponseWriter, r *http.Request) {
+		io.WriteString(rw, "partial")
+		rw.(http.Flusher).Flush()
+		if !rw.(ResponseWriter).Written() {
+			t.Error("Written() = false after Flush")
+		}
+	})
+	if !rec.Flushed {
+		t.Error("response was not flushed")
+	}
+	if got := rec.Header().Get("Content-Length"); got != "" {
+		t.Errorf("Content-Length = %q for a flushed response, want none", got)
+	}
+}
+
+func TestAutoHeadPassesOtherMethods(t *testing.T) {
+	k := New(NewAutoHead())
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		io.WriteString(rw, "body")
+	})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Body.String() != "body" {
+		t.Errorf("body = %q, want %q", rec.Body, "body")
+	}
+}
@@ -0,0 +1,73 @@
+package y_middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// AutoOptions is a middleware handler that answers OPTIONS requests with a 204 and an Allow
+// header listing the methods registered for the path, so route handlers don't have to. Paths are
+// route templates as understood by RouteLabel. OPTIONS requests for paths without registered
+// methods pass through to next.
+type AutoOptions struct {
+	// Methods, if set, is consulted instead of the registered paths.
+	Methods func(r *http.Request) []string
+
+	routes  *RouteLabel
+	methods map[string][]string
+}
+
+// NewAutoOptions returns a new AutoOptions instance with no paths registered.
+func NewAutoOptions() *AutoOptions {
+	return &AutoOptions{
+		routes:  NewRouteLabel(),
+		methods: make(map[string][]string),
+	}
+}
+
+// Register adds methods to the set allowed for template. It must not be called while requests
+// are being served.
+func (a *AutoOptions) Register(template string, methods ...string) {
+	if _, ok := a.methods[template]; !ok {
+		a.routes.Add(template)
+	}
+	a.methods[template] = append(a.methods[template], methods...)
+}
+
+func (a *AutoOptions) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.Method != http.MethodOptions {
+		next(rw, r)
+		return
+	}
+
+	methods := a.allowed(r)
+	if len(methods) == 0 {
+		next(rw, r)
+		return
+	}
+
+	allow := []string{}
+	seen := map[string]bool{}
+	for _, m := range append(methods, http.MethodOptions) {
+		if m = strings.ToUpper(m); !seen[m] {
+			seen[m] = true
+			allow = append(allow, m)
+		}
+	}
+	rw.Header().Set("Allow", strings.Join(allow, ", "))
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (a *AutoOptions) allowed(r *http.Request) []string {
+	if a.Methods != nil {
+		return a.Methods(r)
+	}
+	if a.routes == nil {
+		return nil
+	}
+	template, ok := a.routes.Match(r.URL.Path)
+	if !ok {
+		return nil
+	}
+	return a.methods[template]
+}
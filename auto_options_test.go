@@ -0,0 +1,54 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func serveOptions(a *AutoOptions, method, path string) *httptest.ResponseRecorder {
+	k := New(a)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusTeapot)
+	})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec
+}
+
+func TestAutoOptions(t *testing.T) {
+	a := NewAutoOptions()
+	a.Register("/users/:id", "get", http.MethodPut)
+	a.Register("/users/:id", http.MethodDelete, http.MethodGet)
+
+	rec := serveOptions(a, http.MethodOptions, "/users/42")
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if got, want := rec.Header().Get("Allow"), "GET, PUT, DELETE, OPTIONS"; got != want {
+		t.Errorf("Allow = %q, want %q", got, want)
+	}
+
+	if rec := serveOptions(a, http.MethodOptions, "/orders"); rec.Code != http.StatusTeapot {
+		t.Errorf("unregistered path: status = %d, want it passed on", rec.Code)
+	}
+	if rec := serveOptions(a, http.MethodGet, "/users/42"); rec.Code != http.StatusTeapot {
+		t.Errorf("GET: status = %d, want it passed on", rec.Code)
+	}
+}
+
+func TestAutoOptionsMethodsFunc(t *testing.T) {
+	a := &AutoOptions{Methods: func(r *http.Request) []string {
+		if r.URL.Path == "/ping" {
+			return []string{http.MethodGet, http.MethodOptions}
+		}
+		return nil
+	}}
+	rec := serveOptions(a, http.MethodOptions, "/ping")
+	if got := rec.Header().Get("Allow"); got != "GET, OPTIONS" {
+		t.Errorf("Allow = %q, want %q", got, "GET, OPTIONS")
+	}
+	if rec := serveOptions(a, http.MethodOptions, "/other"); rec.Code != http.StatusTeapot {
+		t.Errorf("no methods: status = %d, want it passed on", rec.Code)
+	}
+}
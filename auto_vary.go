@@ -0,0 +1,73 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+type varyKey struct{}
+
+type varySet struct {
+	mu      sync.Mutex
+	headers []string
+}
+
+// AutoVary is a middleware handler that sets the Vary response header from the request headers
+// downstream handlers reported consulting with MarkVary, so caches key content-negotiated
+// responses correctly. Headers already listed in Vary are kept.
+type AutoVary struct{}
+
+// NewAutoVary returns a new AutoVary instance.
+func NewAutoVary() *AutoVary {
+	return &AutoVary{}
+}
+
+func (a *AutoVary) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	set := &varySet{}
+	res := wrapResponseWriter(rw)
+	res.Before(func(res ResponseWriter) {
+		set.apply(res.Header())
+	})
+	next(res, r.WithContext(context.WithValue(r.Context(), varyKey{}, set)))
+
+	if !res.Written() {
+		set.apply(res.Header())
+	}
+}
+
+// MarkVary records that the response depends on the request header name. It has no effect
+// unless AutoVary runs earlier in the stack.
+func MarkVary(ctx context.Context, name string) {
+	set, ok := ctx.Value(varyKey{}).(*varySet)
+	if !ok {
+		return
+	}
+	set.mu.Lock()
+	set.headers = append(set.headers, http.CanonicalHeaderKey(name))
+	set.mu.Unlock()
+}
+
+// apply merges the recorded headers into the Vary header of h.
+func (s *varySet) apply(h http.Header) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, name := range s.headers {
+		addVary(h, name)
+	}
+}
+
+// addVary adds name to the Vary header of h unless it is already listed.
+func addVary(h http.Header, name string) {
+	for _, v := range h.Values("Vary") {
+		for _, existing := range strings.Split(v, ",") {
+			existing = strings.TrimSpace(existing)
+			if existing == "*" || strings.EqualFold(existing, name) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", name)
+}
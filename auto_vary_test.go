@@ -0,0 +1,44 @@
+package y_middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAutoVary(t *testing.T) {
+	for _, write := range []bool{true, false} {
+		k := New(NewAutoVary())
+		k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.Header().Set("Vary", "Accept-Encoding")
+			MarkVary(r.Context(), "accept-language")
+			MarkVary(r.Context(), "Accept-Encoding")
+			MarkVary(r.Context(), "Accept-Language")
+			if write {
+				io.WriteString(rw, "body")
+			}
+		})
+		rec := httptest.NewRecorder()
+		k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		got := rec.Header().Values("Vary")
+		if len(got) != 2 || got[0] != "Accept-Encoding" || got[1] != "Accept-Language" {
+			t.Errorf("handler writing %v: Vary = %q, want [Accept-Encoding Accept-Language]", write, got)
+		}
+	}
+}
+
+func TestAddVaryWildcard(t *testing.T) {
+	h := http.Header{"Vary": {"*"}}
+	addVary(h, "Accept")
+	if got := h.Values("Vary"); len(got) != 1 {
+		t.Errorf("Vary = %q, want only *", got)
+	}
+}
+
+func TestMarkVaryWithoutAutoVary(t *testing.T) {
+	// Must not panic.
+	MarkVary(context.Background(), "Accept")
+}
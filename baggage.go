@@ -0,0 +1,78 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultBaggagePrefix is the header prefix Baggage collects by default.
+	DefaultBaggagePrefix = "X-Baggage-"
+	// DefaultBaggageMaxEntries is the number of baggage headers kept by default.
+	DefaultBaggageMaxEntries = 16
+	// DefaultBaggageMaxSize is the largest baggage header value kept by default, in bytes.
+	DefaultBaggageMaxSize = 256
+)
+
+type baggageKey struct{}
+
+// Baggage is a middleware handler that collects the request headers starting with Prefix into
+// the request context, so handlers can propagate them to the services they call with InjectBaggage.
+// At most MaxEntries headers are kept, in name order, and values longer than MaxSize are dropped.
+type Baggage struct {
+	Prefix     string
+	MaxEntries int
+	MaxSize    int
+}
+
+// NewBaggage returns a new Baggage instance collecting X-Baggage-* headers.
+func NewBaggage() *Baggage {
+	return &Baggage{
+		Prefix:     DefaultBaggagePrefix,
+		MaxEntries: DefaultBaggageMaxEntries,
+		MaxSize:    DefaultBaggageMaxSize,
+	}
+}
+
+func (b *Baggage) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	prefix := http.CanonicalHeaderKey(b.Prefix)
+
+	var names []string
+	for name := range r.Header {
+		if strings.HasPrefix(name, prefix) && len(name) > len(prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	baggage := make(map[string]string)
+	for _, name := range names {
+		if len(baggage) >= b.MaxEntries {
+			break
+		}
+		if value := r.Header.Get(name); len(value) <= b.MaxSize {
+			baggage[name] = value
+		}
+	}
+	next(rw, r.WithContext(context.WithValue(r.Context(), baggageKey{}, baggage)))
+}
+
+// BaggageFrom returns a copy of the baggage headers collected by Baggage, keyed by header name.
+func BaggageFrom(ctx context.Context) map[string]string {
+	baggage, _ := ctx.Value(baggageKey{}).(map[string]string)
+	copied := make(map[string]string, len(baggage))
+	for k, v := range baggage {
+		copied[k] = v
+	}
+	return copied
+}
+
+// InjectBaggage sets the baggage headers collected by Baggage on an outbound request.
+func InjectBaggage(ctx context.Context, out *http.Request) {
+	baggage, _ := ctx.Value(baggageKey{}).(map[string]string)
+	for k, v := range baggage {
+		out.Header.Set(k, v)
+	}
+}
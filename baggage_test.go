@@ -0,0 +1,58 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBaggageCollectsAndInjects(t *testing.T) {
+	b := NewBaggage()
+	b.MaxEntries = 2
+	b.MaxSize = 8
+
+	var baggage map[string]string
+	out := httptest.NewRequest(http.MethodGet, "http://backend/", nil)
+	k := New(b)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		baggage = BaggageFrom(r.Context())
+		InjectBaggage(r.Context(), out)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Baggage-Tenant", "acme")
+	req.Header.Set("X-Baggage-Big", strings.Repeat("x", 9))
+	req.Header.Set("X-Baggage-Region", "eu")
+	req.Header.Set("X-Baggage-User", "alice")
+	req.Header.Set("X-Other", "ignored")
+	k.ServeHTTP(httptest.NewRecorder(), req)
+
+	want := map[string]string{"X-Baggage-Region": "eu", "X-Baggage-Tenant": "acme"}
+	if len(baggage) != len(want) {
+		t.Fatalf("baggage = %v, want %v", baggage, want)
+	}
+	for name, value := range want {
+		if baggage[name] != value {
+			t.Errorf("baggage[%s] = %q, want %q", name, baggage[name], value)
+		}
+		if got := out.Header.Get(name); got != value {
+			t.Errorf("injected %s = %q, want %q", name, got, value)
+		}
+	}
+	if out.Header.Get("X-Other") != "" || out.Header.Get("X-Baggage-User") != "" {
+		t.Errorf("injected headers %v, want only the collected baggage", out.Header)
+	}
+}
+
+func TestBaggageFromReturnsCopy(t *testing.T) {
+	ctx := context.WithValue(context.Background(), baggageKey{}, map[string]string{"X-Baggage-A": "1"})
+	BaggageFrom(ctx)["X-Baggage-A"] = "changed"
+	if got := BaggageFrom(ctx)["X-Baggage-A"]; got != "1" {
+		t.Errorf("baggage = %q after changing a copy, want 1", got)
+	}
+	if got := BaggageFrom(context.Background()); got == nil || len(got) != 0 {
+		t.Errorf("BaggageFrom without Baggage = %v, want an empty map", got)
+	}
+}
@@ -0,0 +1,76 @@
+package y_middleware
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+const (
+	// DefaultBodyGuardMaxDrain is the most unread request body BodyGuard drains by default.
+	DefaultBodyGuardMaxDrain = 256 << 10
+	// DefaultBodyGuardLogThreshold is the unread remainder BodyGuard logs by default.
+	DefaultBodyGuardLogThreshold = 4 << 10
+)
+
+// BodyGuard is a middleware handler that cleans up after handlers that leave the request body
+// unread. Once the rest of the chain returns, up to MaxDrain bytes of unread body are drained
+// so the connection can be reused, and the body is closed. When Logger is set, remainders of at
+// least LogThreshold bytes are logged to help find the handlers at fault. Hijacked and upgraded
+// connections are left alone.
+type BodyGuard struct {
+	MaxDrain     int64
+	LogThreshold int64
+	Logger       ALogger
+}
+
+// NewBodyGuard returns a new BodyGuard instance with the default limits and no logging.
+func NewBodyGuard() *BodyGuard {
+	return &BodyGuard{
+		MaxDrain:     DefaultBodyGuardMaxDrain,
+		LogThreshold: DefaultBodyGuardLogThreshold,
+	}
+}
+
+func (b *BodyGuard) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	body := r.Body
+	if body == nil || body == http.NoBody {
+		next(rw, r)
+		return
+	}
+
+	gw := &bodyGuardWriter{ResponseWriter: wrapResponseWriter(rw)}
+	next(gw, r)
+	if gw.hijacked || gw.Status() == http.StatusSwitchingProtocols {
+		return
+	}
+
+	n, _ := io.CopyN(io.Discard, body, b.MaxDrain+1)
+	body.Close()
+	if b.Logger != nil && n > 0 && n >= b.LogThreshold {
+		if n > b.MaxDrain {
+			b.Logger.Printf("%s %s: handler left more than %d bytes of the request body unread", r.Method, r.URL.Path, b.MaxDrain)
+		} else {
+			b.Logger.Printf("%s %s: handler left %d bytes of the request body unread", r.Method, r.URL.Path, n)
+		}
+	}
+}
+
+// bodyGuardWriter records whether the connection was hijacked.
+type bodyGuardWriter struct {
+	ResponseWriter
+	hijacked bool
+}
+
+func (w *bodyGuardWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buf, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, buf, err
+}
+
+func (w *bodyGuardWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,87 @@
+package y_middleware
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// trackedBody is a request body that records how much was read from it and whether it was closed.
+type trackedBody struct {
+	io.Reader
+	read   int64
+	closed bool
+}
+
+func (b *trackedBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	b.read += int64(n)
+	return n, err
+}
+
+func (b *trackedBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func serveBodyGuard(g *BodyGuard, size int, h http.HandlerFunc) *trackedBody {
+	body := &trackedBody{Reader: strings.NewReader(strings.Repeat("x", size))}
+	k := New(g)
+	k.UseHandlerFunc(h)
+	r := httptest.NewRequest(http.MethodPost, "/upload", nil)
+	r.Body = body
+	k.ServeHTTP(httptest.NewRecorder(), r)
+	return body
+}
+
+func TestBodyGuardDrainsUnreadBody(t *testing.T) {
+	var logged bytes.Buffer
+	g := NewBodyGuard()
+	g.Logger = log.New(&logged, "", 0)
+
+	body := serveBodyGuard(g, 10<<10, func(http.ResponseWriter, *http.Request) {})
+	if body.read != 10<<10 || !body.closed {
+		t.Errorf("read %d bytes, closed = %v, want the body drained and closed", body.read, body.closed)
+	}
+	if want := "POST /upload: handler left 10240 bytes of the request body unread\n"; logged.String() != want {
+		t.Errorf("log = %q, want %q", logged.String(), want)
+	}
+
+	logged.Reset()
+	g.MaxDrain = 1 << 10
+	g.LogThreshold = 512
+	body = serveBodyGuard(g, 10<<10, func(http.ResponseWriter, *http.Request) {})
+	if body.read != 1<<10+1 || !body.closed {
+		t.Errorf("read %d bytes, closed = %v, want the drain capped and the body closed", body.read, body.closed)
+	}
+	if want := "POST /upload: handler left more than 1024 bytes of the request body unread\n"; logged.String() != want {
+		t.Errorf("log = %q, want %q", logged.String(), want)
+	}
+}
+
+func TestBodyGuardQuiet(t *testing.T) {
+	var logged bytes.Buffer
+	g := NewBodyGuard()
+	g.Logger = log.New(&logged, "", 0)
+
+	serveBodyGuard(g, 1<<10, func(http.ResponseWriter, *http.Request) {})
+	serveBodyGuard(g, 10<<10, func(rw http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+	})
+	if logged.Len() != 0 {
+		t.Errorf("log = %q, want nothing for small or fully read bodies", logged.String())
+	}
+}
+
+func TestBodyGuardSkipsUpgrades(t *testing.T) {
+	body := serveBodyGuard(NewBodyGuard(), 10, func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusSwitchingProtocols)
+	})
+	if body.read != 0 || body.closed {
+		t.Errorf("read %d bytes, closed = %v, want an upgraded request left alone", body.read, body.closed)
+	}
+}
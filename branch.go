@@ -0,0 +1,37 @@
+package y_middleware
+
+import "net/http"
+
+// Branch returns a Handler that dispatches each request into ifTrue or ifFalse depending on
+// cond, building decision trees of middleware. Each branch is typically a Chain, and a branch
+// that falls through calls the next handler after the Branch. A nil branch calls next directly.
+func Branch(cond func(*http.Request) bool, ifTrue, ifFalse Handler) Handler {
+	return HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		h := ifFalse
+		if cond(r) {
+			h = ifTrue
+		}
+		if h == nil {
+			next(rw, r)
+			return
+		}
+		h.ServeHTTP(rw, r, next)
+	})
+}
+
+// Chain returns a Handler that runs handlers in order, like a Kudret stack, and then calls the
+// next handler after the Chain once the last of them yields.
+func Chain(handlers ...Handler) Handler {
+	return HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		var run func(i int) http.HandlerFunc
+		run = func(i int) http.HandlerFunc {
+			if i == len(handlers) {
+				return next
+			}
+			return func(rw http.ResponseWriter, r *http.Request) {
+				handlers[i].ServeHTTP(rw, r, run(i+1))
+			}
+		}
+		run(0)(rw, r)
+	})
+}
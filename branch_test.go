@@ -0,0 +1,51 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// traced returns a Handler that appends name to the X-Trace response header and yields.
+func traced(name string) Handler {
+	return HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		rw.Header().Add("X-Trace", name)
+		next(rw, r)
+	})
+}
+
+func serveBranch(h Handler, path string) string {
+	k := New(h, traced("after"))
+	k.UseHandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return strings.Join(rec.Header().Values("X-Trace"), ",")
+}
+
+func TestBranch(t *testing.T) {
+	isAPI := func(r *http.Request) bool { return strings.HasPrefix(r.URL.Path, "/api") }
+	b := Branch(isAPI, Chain(traced("auth"), traced("json")), traced("html"))
+
+	if got := serveBranch(b, "/api/users"); got != "auth,json,after" {
+		t.Errorf("API request ran %q, want auth,json,after", got)
+	}
+	if got := serveBranch(b, "/home"); got != "html,after" {
+		t.Errorf("page request ran %q, want html,after", got)
+	}
+	if got := serveBranch(Branch(isAPI, nil, traced("html")), "/api"); got != "after" {
+		t.Errorf("nil branch ran %q, want after", got)
+	}
+}
+
+func TestChainStopsWhenHandlerDoesNotYield(t *testing.T) {
+	stop := HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		rw.Header().Add("X-Trace", "stop")
+	})
+	if got := serveBranch(Chain(traced("a"), stop, traced("b")), "/"); got != "a,stop" {
+		t.Errorf("chain ran %q, want a,stop", got)
+	}
+	if got := serveBranch(Chain(), "/"); got != "after" {
+		t.Errorf("empty chain ran %q, want after", got)
+	}
+}
@@ -0,0 +1,53 @@
+// Package brotli adds Brotli compression to the y_middleware Gzip handler. It is its own module
+// so that only programs using it depend on a Brotli implementation.
+//
+//	g := y_middleware.NewGzip(gzip.DefaultCompression).Prefer(brotli.New(brotli.DefaultCompression))
+package brotli
+
+import (
+	"io"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+
+	y_middleware "github.com/YusufSert/y_middleware"
+)
+
+// DefaultCompression is the Brotli quality level used by default.
+const DefaultCompression = brotli.DefaultCompression
+
+type compressor struct {
+	level int
+	pool  sync.Pool
+}
+
+// New returns a y_middleware.Compressor for the "br" content coding, compressing at level.
+func New(level int) y_middleware.Compressor {
+	return &compressor{level: level}
+}
+
+func (c *compressor) Encoding() string {
+	return "br"
+}
+
+func (c *compressor) NewWriter(w io.Writer) io.WriteCloser {
+	bw, ok := c.pool.Get().(*brotli.Writer)
+	if ok {
+		bw.Reset(w)
+	} else {
+		bw = brotli.NewWriterLevel(w, c.level)
+	}
+	return &pooledWriter{Writer: bw, pool: &c.pool}
+}
+
+// pooledWriter returns its brotli.Writer to the pool when closed.
+type pooledWriter struct {
+	*brotli.Writer
+	pool *sync.Pool
+}
+
+func (w *pooledWriter) Close() error {
+	err := w.Writer.Close()
+	w.pool.Put(w.Writer)
+	return err
+}
@@ -0,0 +1,64 @@
+package brotli
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+
+	y_middleware "github.com/YusufSert/y_middleware"
+)
+
+func TestGzipPrefersBrotli(t *testing.T) {
+	k := y_middleware.New(y_middleware.NewGzip(gzip.DefaultCompression).Prefer(New(DefaultCompression)))
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		io.WriteString(rw, "hello brotli")
+	})
+
+	tests := []struct {
+		accept, encoding string
+	}{
+		{"gzip, br", "br"},
+		{"gzip, br;q=0.5", "gzip"},
+		{"br;q=0", ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Encoding", tt.accept)
+		rec := httptest.NewRecorder()
+		k.ServeHTTP(rec, r)
+		if got := rec.Header().Get("Content-Encoding"); got != tt.encoding {
+			t.Errorf("%q: Content-Encoding = %q, want %q", tt.accept, got, tt.encoding)
+		}
+		if tt.encoding != "br" {
+			continue
+		}
+		body, err := io.ReadAll(brotli.NewReader(rec.Body))
+		if err != nil || string(body) != "hello brotli" {
+			t.Errorf("%q: decoded %q, %v, want %q", tt.accept, body, err, "hello brotli")
+		}
+	}
+}
+
+func TestWriterReuse(t *testing.T) {
+	c := New(DefaultCompression)
+	if got := c.Encoding(); got != "br" {
+		t.Errorf("Encoding() = %q, want br", got)
+	}
+	for _, s := range []string{"first", "second"} {
+		var buf bytes.Buffer
+		w := c.NewWriter(&buf)
+		io.WriteString(w, s)
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(brotli.NewReader(&buf))
+		if err != nil || string(body) != s {
+			t.Errorf("decoded %q, %v, want %q", body, err, s)
+		}
+	}
+}
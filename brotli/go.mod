@@ -0,0 +1,16 @@
+module github.com/YusufSert/y_middleware/brotli
+
+go 1.21
+
+require (
+	github.com/YusufSert/y_middleware v0.0.0-00010101000000-000000000000
+	github.com/andybalholm/brotli v1.1.0
+)
+
+require (
+	golang.org/x/sync v0.8.0 // indirect
+	golang.org/x/sys v0.24.0 // indirect
+	golang.org/x/text v0.17.0 // indirect
+)
+
+replace github.com/YusufSert/y_middleware => ../
@@ -0,0 +1,59 @@
+package y_middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ErrBudgetExceeded is returned by Spend once the request's time budget is used up.
+var ErrBudgetExceeded = errors.New("request time budget exceeded")
+
+type budgetKey struct{}
+
+type budget struct {
+	remaining atomic.Int64
+}
+
+// Budget is a middleware handler that gives every request a time budget that downstream code
+// draws from with Spend, so a cascade of operations can abort early once it is used up. Unlike
+// a timeout it measures only the time callers report, such as the cost of upstream calls.
+type Budget struct {
+	Total time.Duration
+}
+
+// NewBudget returns a new Budget instance giving each request a total budget.
+func NewBudget(total time.Duration) *Budget {
+	return &Budget{Total: total}
+}
+
+func (b *Budget) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	bg := &budget{}
+	bg.remaining.Store(int64(b.Total))
+	next(rw, r.WithContext(context.WithValue(r.Context(), budgetKey{}, bg)))
+}
+
+// Spend deducts d from the request's time budget. It returns ErrBudgetExceeded if the budget is
+// used up, and nil without a Budget in the chain.
+func Spend(ctx context.Context, d time.Duration) error {
+	bg, ok := ctx.Value(budgetKey{}).(*budget)
+	if !ok {
+		return nil
+	}
+	if bg.remaining.Add(-int64(d)) < 0 {
+		return ErrBudgetExceeded
+	}
+	return nil
+}
+
+// RemainingBudget returns what is left of the request's time budget, which is negative once it
+// has been overspent. It returns false without a Budget in the chain.
+func RemainingBudget(ctx context.Context) (time.Duration, bool) {
+	bg, ok := ctx.Value(budgetKey{}).(*budget)
+	if !ok {
+		return 0, false
+	}
+	return time.Duration(bg.remaining.Load()), true
+}
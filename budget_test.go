@@ -0,0 +1,68 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBudgetSpend(t *testing.T) {
+	k := New(NewBudget(100 * time.Millisecond))
+	var errs []error
+	var remaining time.Duration
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		errs = append(errs, Spend(ctx, 60*time.Millisecond), Spend(ctx, 40*time.Millisecond), Spend(ctx, time.Millisecond))
+		remaining, _ = RemainingBudget(ctx)
+	})
+	k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if errs[0] != nil || errs[1] != nil || errs[2] != ErrBudgetExceeded {
+		t.Errorf("Spend errors = %v, want nil, nil, %v", errs, ErrBudgetExceeded)
+	}
+	if remaining != -time.Millisecond {
+		t.Errorf("RemainingBudget = %v, want -1ms", remaining)
+	}
+}
+
+func TestBudgetPerRequest(t *testing.T) {
+	k := New(NewBudget(10 * time.Millisecond))
+	var mu sync.Mutex
+	var remaining []time.Duration
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		Spend(r.Context(), 4*time.Millisecond)
+		left, _ := RemainingBudget(r.Context())
+		mu.Lock()
+		remaining = append(remaining, left)
+		mu.Unlock()
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}()
+	}
+	wg.Wait()
+	if len(remaining) != 5 {
+		t.Fatalf("%d requests served, want 5", len(remaining))
+	}
+	for _, left := range remaining {
+		if left != 6*time.Millisecond {
+			t.Errorf("RemainingBudget = %v, want every request to get its own budget", left)
+		}
+	}
+}
+
+func TestSpendWithoutBudget(t *testing.T) {
+	if err := Spend(context.Background(), time.Hour); err != nil {
+		t.Errorf("Spend without a Budget = %v, want nil", err)
+	}
+	if _, ok := RemainingBudget(context.Background()); ok {
+		t.Error("RemainingBudget reported a budget without a Budget in the chain")
+	}
+}
@@ -0,0 +1,100 @@
+package y_middleware
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+// DefaultBufferResponseMaxSize is the largest response body BufferResponse holds in memory by default.
+const DefaultBufferResponseMaxSize = 64 << 10
+
+// BufferResponse is a middleware handler that holds the response in memory until the handler
+// returns, so it is sent in one piece. Responses that grow past MaxSize, that the handler
+// flushes, or that are Server-Sent Events are streamed from that point on.
+type BufferResponse struct {
+	// MaxSize is the largest body that is buffered. Zero means no limit.
+	MaxSize int
+	// ContentLength sets a Content-Length header on fully buffered responses so clients
+	// and proxies don't need chunked encoding.
+	ContentLength bool
+}
+
+// NewBufferResponse returns a new BufferResponse instance buffering bodies up to maxSize bytes.
+func NewBufferResponse(maxSize int) *BufferResponse {
+	return &BufferResponse{MaxSize: maxSize}
+}
+
+func (b *BufferResponse) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	buf, r := newResponseBuffer(rw, r, b.MaxSize)
+	next(buf, r)
+
+	if buf.Streamed() {
+		return
+	}
+	if !buf.Written() {
+		buf.copyHeader(rw)
+		return
+	}
+	h := buf.Header()
+	if b.ContentLength && r.Method != http.MethodHead && bodyAllowedForStatus(buf.Status()) &&
+		h.Get("Content-Length") == "" && h.Get("Transfer-Encoding") == "" {
+		h.Set("Content-Length", strconv.Itoa(buf.Len()))
+	}
+	buf.flush(rw)
+}
+
+type bufferingKey struct{}
+
+type bufferingFlag struct {
+	off int32
+}
+
+// bufferingFlagFor returns the buffering flag of r, and r with a context carrying a new one
+// if it has none yet.
+func bufferingFlagFor(r *http.Request) (*bufferingFlag, *http.Request) {
+	if flag, ok := r.Context().Value(bufferingKey{}).(*bufferingFlag); ok {
+		return flag, r
+	}
+	flag := &bufferingFlag{}
+	return flag, r.WithContext(context.WithValue(r.Context(), bufferingKey{}, flag))
+}
+
+func (f *bufferingFlag) disabled() bool {
+	return atomic.LoadInt32(&f.off) == 1
+}
+
+// DisableBuffering tells buffering middleware earlier in the stack, such as BufferResponse,
+// Retry, SingleFlight or Idempotency, not to hold back the response of the current request,
+// e.g. for long-polling endpoints. It takes effect at the handler's next write.
+func DisableBuffering(ctx context.Context) {
+	if flag, ok := ctx.Value(bufferingKey{}).(*bufferingFlag); ok {
+		atomic.StoreInt32(&flag.off, 1)
+	}
+}
+
+// BufferingDisabled reports whether DisableBuffering was called for the request.
+func BufferingDisabled(ctx context.Context) bool {
+	flag, ok := ctx.Value(bufferingKey{}).(*bufferingFlag)
+	return ok && flag.disabled()
+}
+
+// isStreamingResponse reports whether a response with header h is a stream that must not be
+// held back by buffering middleware, such as Server-Sent Events.
+func isStreamingResponse(h http.Header) bool {
+	mediaType, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	return mediaType == "text/event-stream"
+}
+
+// bodyAllowedForStatus reports whether a response with the given status may have a body.
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
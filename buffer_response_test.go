@@ -0,0 +1,161 @@
+package y_middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// serveBuffered serves h behind b and reports how much of the body had reached the client
+// when h returned from each write.
+func serveBuffered(b *BufferResponse, h func(rw http.ResponseWriter, r *http.Request, sent func() int)) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	k := New(b)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		h(rw, r, rec.Body.Len)
+	})
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	return rec
+}
+
+func TestBufferResponseHoldsBody(t *testing.T) {
+	b := NewBufferResponse(DefaultBufferResponseMaxSize)
+	b.ContentLength = true
+	rec := serveBuffered(b, func(rw http.ResponseWriter, r *http.Request, sent func() int) {
+		rw.WriteHeader(http.StatusCreated)
+		io.WriteString(rw, "hello ")
+		io.WriteString(rw, "world")
+		if n := sent(); n != 0 {
+			t.Errorf("%d bytes sent before the handler returned", n)
+		}
+	})
+
+	if rec.Code != http.StatusCreated {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if rec.Body.String() != "hello world" {
+		t.Errorf("body = %q, want %q", rec.Body, "hello world")
+	}
+	if got := rec.Header().Get("Content-Length"); got != "11" {
+		t.Errorf("Content-Length = %q, want 11", got)
+	}
+}
+
+func TestBufferResponseStreamsPastMaxSize(t *testing.T) {
+	b := NewBufferResponse(8)
+	b.ContentLength = true
+	rec := serveBuffered(b, func(rw http.ResponseWriter, r *http.Request, sent func() int) {
+		io.WriteString(rw, "small")
+		io.WriteString(rw, " and then larger")
+		if n := sent(); n != 21 {
+			t.Errorf("%d bytes sent after passing MaxSize, want 21", n)
+		}
+	})
+	if rec.Body.String() != "small and then larger" {
+		t.Errorf("body = %q", rec.Body)
+	}
+	if got := rec.Header().Get("Content-Length"); got != "" {
+		t.Errorf("Content-Length = %q on a streamed response, want none", got)
+	}
+}
+
+func TestBufferResponseStreams(t *testing.T) {
+	tests := []struct {
+		name string
+		h    func(rw http.ResponseWriter, r *http.Request)
+	}{
+		{"flush", func(rw http.ResponseWriter, r *http.Request) {
+			io.WriteString(rw, "data")
+			rw.(http.Flusher).Flush()
+		}},
+		{"event stream", func(rw http.ResponseWriter, r *http.Request) {
+			rw.Header().Set("Content-Type", "text/event-stream")
+			io.WriteString(rw, "data")
+		}},
+		{"DisableBuffering", func(rw http.ResponseWriter, r *http.Request) {
+			DisableBuffering(r.Context())
+			if !BufferingDisabled(r.Context()) {
+				t.Error("BufferingDisabled = false after DisableBuffering")
+			}
+			io.WriteString(rw, "data")
+		}},
+	}
+	for _, tt := range tests {
+		serveBuffered(NewBufferResponse(DefaultBufferResponseMaxSize), func(rw http.ResponseWriter, r *http.Request, sent func() int) {
+			tt.h(rw, r)
+			if n := sent(); n != 4 {
+				t.Errorf("%s: %d bytes sent before the handler returned, want 4", tt.name, n)
+			}
+		})
+	}
+}
+
+func TestBufferResponseEmptyResponse(t *testing.T) {
+	b := NewBufferResponse(DefaultBufferResponseMaxSize)
+	b.ContentLength = true
+	rec := serveBuffered(b, func(rw http.ResponseWriter, r *http.Request, sent func() int) {
+		rw.WriteHeader(http.StatusNoContent)
+	})
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if rec.Header().Get("Content-Length") != "" {
+		t.Error("Content-Length set on a 204")
+	}
+}
+
+func TestBufferResponseDisableBuffering(t *testing.T) {
+	rec := serveBuffered(NewBufferResponse(DefaultBufferResponseMaxSize), func(rw http.ResponseWriter, r *http.Request, sent func() int) {
+		if BufferingDisabled(r.Context()) {
+			t.Error("buffering disabled before DisableBuffering")
+		}
+		DisableBuffering(r.Context())
+		if !BufferingDisabled(r.Context()) {
+			t.Error("BufferingDisabled = false after DisableBuffering")
+		}
+		io.WriteString(rw, "poll")
+		if n := sent(); n != 4 {
+			t.Errorf("%d bytes sent after the first write, want 4", n)
+		}
+	})
+	if rec.Body.String() != "poll" {
+		t.Errorf("body = %q, want %q", rec.Body, "poll")
+	}
+}
+
+func TestDisableBufferingNestedBuffers(t *testing.T) {
+	pretty := NewPrettyJSON()
+	pretty.Always = true
+	rec := httptest.NewRecorder()
+	k := New(NewBufferResponse(DefaultBufferResponseMaxSize), pretty)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		DisableBuffering(r.Context())
+		rw.Header().Set("Content-Type", "application/json")
+		io.WriteString(rw, `{"a":1}`)
+		if n := rec.Body.Len(); n != 7 {
+			t.Errorf("%d bytes sent after the write, want both buffers to stream", n)
+		}
+	})
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Body.String() != `{"a":1}` {
+		t.Errorf("body = %q, want it unindented", rec.Body)
+	}
+}
+
+func TestDisableBufferingWithoutBuffer(t *testing.T) {
+	ctx := httptest.NewRequest(http.MethodGet, "/", nil).Context()
+	DisableBuffering(ctx)
+	if BufferingDisabled(ctx) {
+		t.Error("BufferingDisabled = true without buffering middleware")
+	}
+}
+
+func TestBufferResponseKeepsHeadersOfEmptyResponses(t *testing.T) {
+	rec := serveBuffered(NewBufferResponse(DefaultBufferResponseMaxSize), func(rw http.ResponseWriter, r *http.Request, sent func() int) {
+		rw.Header().Set("X-Test", "1")
+	})
+	if rec.Header().Get("X-Test") != "1" {
+		t.Errorf("header of a response without a body was dropped: %v", rec.Header())
+	}
+}
@@ -0,0 +1,67 @@
+package y_middleware
+
+import (
+	"context"
+	"hash/fnv"
+	"net/http"
+)
+
+type canaryKey struct{}
+
+// Canary is a middleware handler that marks a fraction of requests as canary traffic, so
+// downstream handlers can check IsCanary and route them to new behavior. The decision is a hash
+// of a sticky key, so a given client consistently lands on the same side. The key is the value
+// of Cookie, then of Header, then the client IP, whichever is found first.
+type Canary struct {
+	// Fraction is the share of keys, between 0 and 1, marked as canary.
+	Fraction float64
+	Cookie   string
+	Header   string
+	// KeyFunc, if set, replaces the cookie, header and client IP lookup.
+	KeyFunc func(r *http.Request) string
+}
+
+// NewCanary returns a new Canary instance marking fraction of clients as canary, keyed on the
+// "canary" cookie or the X-Canary-Key header.
+func NewCanary(fraction float64) *Canary {
+	return &Canary{
+		Fraction: fraction,
+		Cookie:   "canary",
+		Header:   "X-Canary-Key",
+	}
+}
+
+func (c *Canary) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	canary := canaryBucket(c.key(r)) < c.Fraction
+	next(rw, r.WithContext(context.WithValue(r.Context(), canaryKey{}, canary)))
+}
+
+func (c *Canary) key(r *http.Request) string {
+	if c.KeyFunc != nil {
+		return c.KeyFunc(r)
+	}
+	if c.Cookie != "" {
+		if cookie, err := r.Cookie(c.Cookie); err == nil && cookie.Value != "" {
+			return cookie.Value
+		}
+	}
+	if c.Header != "" {
+		if key := r.Header.Get(c.Header); key != "" {
+			return key
+		}
+	}
+	return clientIP(r)
+}
+
+// canaryBucket maps key to a stable value in [0, 1).
+func canaryBucket(key string) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return float64(h.Sum64()%10000) / 10000
+}
+
+// IsCanary reports whether Canary marked the request as canary traffic.
+func IsCanary(ctx context.Context) bool {
+	canary, _ := ctx.Value(canaryKey{}).(bool)
+	return canary
+}
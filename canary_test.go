@@ -0,0 +1,63 @@
+package y_middleware
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func serveCanary(c *Canary, r *http.Request) bool {
+	var canary bool
+	k := New(c)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		canary = IsCanary(r.Context())
+	})
+	k.ServeHTTP(httptest.NewRecorder(), r)
+	return canary
+}
+
+func TestCanaryFraction(t *testing.T) {
+	c := NewCanary(0.25)
+	canaries := 0
+	for i := 0; i < 2000; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Canary-Key", "user-"+strconv.Itoa(i))
+		first := serveCanary(c, r)
+		if serveCanary(c, r) != first {
+			t.Fatalf("user-%d switched sides between requests", i)
+		}
+		if first {
+			canaries++
+		}
+	}
+	if share := float64(canaries) / 2000; math.Abs(share-0.25) > 0.05 {
+		t.Errorf("canary share = %.3f, want about 0.25", share)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if serveCanary(NewCanary(0), r) || !serveCanary(NewCanary(1), r) {
+		t.Error("fractions 0 and 1 should mark no and all requests")
+	}
+}
+
+func TestCanaryKey(t *testing.T) {
+	c := NewCanary(0.5)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if got := c.key(r); got != "192.0.2.1" {
+		t.Errorf("key without cookie or header = %q, want the client IP", got)
+	}
+	r.Header.Set("X-Canary-Key", "header")
+	if got := c.key(r); got != "header" {
+		t.Errorf("key = %q, want the header", got)
+	}
+	r.AddCookie(&http.Cookie{Name: "canary", Value: "cookie"})
+	if got := c.key(r); got != "cookie" {
+		t.Errorf("key = %q, want the cookie to win", got)
+	}
+	c.KeyFunc = func(*http.Request) string { return "func" }
+	if got := c.key(r); got != "func" {
+		t.Errorf("key = %q, want KeyFunc to win", got)
+	}
+}
@@ -0,0 +1,100 @@
+package y_middleware
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// DefaultChecksumMaxBodySize is the largest request body ChecksumVerify reads by default.
+const DefaultChecksumMaxBodySize = 10 << 20
+
+// ChecksumVerify is a middleware handler that checks request bodies against the checksum the
+// client sent, for upload integrity. The checksum is read from the Content-MD5 header or from a
+// Digest header with an md5 or sha-256 entry, all base64 encoded. Bodies that do not match get
+// a 400 and the rest of the chain is skipped. Requests without a supported checksum pass through,
+// unless Required is set, in which case they get a 400 too.
+//
+// The body is buffered so handlers can still read it after verification.
+type ChecksumVerify struct {
+	Required    bool
+	MaxBodySize int64
+}
+
+// NewChecksumVerify returns a new ChecksumVerify instance checking requests that carry a checksum.
+func NewChecksumVerify() *ChecksumVerify {
+	return &ChecksumVerify{MaxBodySize: DefaultChecksumMaxBodySize}
+}
+
+func (c *ChecksumVerify) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	algorithm, want, ok := requestChecksum(r.Header)
+	if !ok {
+		if c.Required {
+			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		next(rw, r)
+		return
+	}
+
+	body, err := bufferBody(r, c.MaxBodySize)
+	if errors.Is(err, errBodyTooLarge) {
+		http.Error(rw, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	var got []byte
+	switch algorithm {
+	case "md5":
+		sum := md5.Sum(body)
+		got = sum[:]
+	case "sha-256":
+		sum := sha256.Sum256(body)
+		got = sum[:]
+	}
+	if subtle.ConstantTimeCompare(got, want) != 1 {
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	next(rw, r)
+}
+
+// requestChecksum returns the strongest supported checksum in h and its algorithm.
+func requestChecksum(h http.Header) (string, []byte, bool) {
+	var md5Sum []byte
+	for _, v := range h.Values("Digest") {
+		for _, entry := range strings.Split(v, ",") {
+			algorithm, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+			if !ok {
+				continue
+			}
+			sum, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+			if err != nil {
+				continue
+			}
+			switch strings.ToLower(algorithm) {
+			case "sha-256":
+				return "sha-256", sum, true
+			case "md5":
+				md5Sum = sum
+			}
+		}
+	}
+	if md5Sum != nil {
+		return "md5", md5Sum, true
+	}
+	if v := h.Get("Content-MD5"); v != "" {
+		if sum, err := base64.StdEncoding.DecodeString(strings.TrimSpace(v)); err == nil {
+			return "md5", sum, true
+		}
+	}
+	return "", nil, false
+}
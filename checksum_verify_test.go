@@ -0,0 +1,82 @@
+package y_middleware
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const checksumBody = "uploaded file"
+
+func md5Of(s string) string {
+	sum := md5.Sum([]byte(s))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func sha256Of(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func serveChecksum(c *ChecksumVerify, headers map[string]string) (int, string) {
+	read := "<not served>"
+	k := New(c)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		read = string(b)
+	})
+	r := httptest.NewRequest(http.MethodPut, "/files/a", strings.NewReader(checksumBody))
+	for name, value := range headers {
+		r.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, r)
+	return rec.Code, read
+}
+
+func TestChecksumVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int
+	}{
+		{"Content-MD5", map[string]string{"Content-MD5": md5Of(checksumBody)}, http.StatusOK},
+		{"Digest md5", map[string]string{"Digest": "MD5=" + md5Of(checksumBody)}, http.StatusOK},
+		{"Digest sha-256", map[string]string{"Digest": "sha-256=" + sha256Of(checksumBody)}, http.StatusOK},
+		{"sha-256 wins", map[string]string{"Digest": "md5=" + md5Of("other") + ", sha-256=" + sha256Of(checksumBody)}, http.StatusOK},
+		{"mismatch", map[string]string{"Content-MD5": md5Of("other")}, http.StatusBadRequest},
+		{"sha-256 mismatch", map[string]string{"Digest": "sha-256=" + sha256Of("other")}, http.StatusBadRequest},
+		{"no checksum", nil, http.StatusOK},
+		{"unsupported", map[string]string{"Digest": "sha-512=AAAA"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		status, read := serveChecksum(NewChecksumVerify(), tt.headers)
+		if status != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, status, tt.status)
+		}
+		if status == http.StatusOK && read != checksumBody {
+			t.Errorf("%s: handler read %q, want the whole body", tt.name, read)
+		}
+	}
+}
+
+func TestChecksumVerifyRequired(t *testing.T) {
+	c := NewChecksumVerify()
+	c.Required = true
+	if status, _ := serveChecksum(c, nil); status != http.StatusBadRequest {
+		t.Errorf("missing checksum: status = %d, want %d", status, http.StatusBadRequest)
+	}
+}
+
+func TestChecksumVerifyMaxBodySize(t *testing.T) {
+	c := NewChecksumVerify()
+	c.MaxBodySize = 4
+	if status, _ := serveChecksum(c, map[string]string{"Content-MD5": md5Of(checksumBody)}); status != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want %d", status, http.StatusRequestEntityTooLarge)
+	}
+}
@@ -0,0 +1,136 @@
+package y_middleware
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// CircuitBreaker is a middleware handler that stops calling the rest of the chain while it is
+// failing. When the share of 5xx responses within Window reaches FailureThreshold, the breaker
+// opens and answers every request with a 503 for Cooldown. After that a single probe request is
+// let through: if it succeeds the breaker closes again, otherwise it stays open for another Cooldown.
+type CircuitBreaker struct {
+	// FailureThreshold is the share of failed requests, between 0 and 1, that opens the breaker.
+	FailureThreshold float64
+	// MinRequests is the number of requests a window needs before the breaker may open.
+	MinRequests int
+	Window      time.Duration
+	Cooldown    time.Duration
+
+	mu          sync.Mutex
+	state       circuitState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probing     bool
+	clock       Clock
+}
+
+// NewCircuitBreaker returns a new CircuitBreaker instance that opens when threshold of the requests
+// within window fail, and stays open for cooldown.
+func NewCircuitBreaker(threshold float64, window, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		FailureThreshold: threshold,
+		MinRequests:      10,
+		Window:           window,
+		Cooldown:         cooldown,
+	}
+}
+
+// WithClock makes the CircuitBreaker read the time from c instead of the system clock.
+func (cb *CircuitBreaker) WithClock(c Clock) *CircuitBreaker {
+	cb.clock = c
+	return cb
+}
+
+func (cb *CircuitBreaker) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	ok, probe := cb.allow()
+	if !ok {
+		http.Error(rw, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	res := wrapResponseWriter(rw)
+	completed := false
+	defer func() {
+		// A panicking handler counts as a failure.
+		cb.record(probe, !completed || res.Status() >= http.StatusInternalServerError)
+	}()
+	next(res, r)
+	completed = true
+}
+
+// allow reports whether a request may go through, and whether it is the half-open probe.
+func (cb *CircuitBreaker) allow() (ok, probe bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case circuitOpen:
+		if clockOrDefault(cb.clock).Now().Sub(cb.openedAt) < cb.Cooldown {
+			return false, false
+		}
+		cb.state = circuitHalfOpen
+		cb.probing = true
+		return true, true
+	case circuitHalfOpen:
+		if cb.probing {
+			return false, false
+		}
+		cb.probing = true
+		return true, true
+	}
+	return true, false
+}
+
+func (cb *CircuitBreaker) record(probe, failed bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := clockOrDefault(cb.clock).Now()
+	if probe {
+		cb.probing = false
+		if failed {
+			cb.open(now)
+		} else {
+			cb.state = circuitClosed
+			cb.resetWindow(now)
+		}
+		return
+	}
+	if cb.state != circuitClosed {
+		return
+	}
+
+	if now.Sub(cb.windowStart) >= cb.Window {
+		cb.resetWindow(now)
+	}
+	cb.requests++
+	if failed {
+		cb.failures++
+	}
+	if cb.requests >= cb.MinRequests && float64(cb.failures)/float64(cb.requests) >= cb.FailureThreshold {
+		cb.open(now)
+	}
+}
+
+func (cb *CircuitBreaker) open(now time.Time) {
+	cb.state = circuitOpen
+	cb.openedAt = now
+}
+
+func (cb *CircuitBreaker) resetWindow(now time.Time) {
+	cb.windowStart = now
+	cb.requests = 0
+	cb.failures = 0
+}
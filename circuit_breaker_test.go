@@ -0,0 +1,140 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// breakerBackend serves cb in front of a handler answering with *status.
+func breakerBackend(cb *CircuitBreaker, status *int) func() int {
+	k := New(cb)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(*status)
+	})
+	return func() int {
+		rec := httptest.NewRecorder()
+		k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rec.Code
+	}
+}
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	clock := newFakeClock()
+	cb := NewCircuitBreaker(0.5, time.Minute, 10*time.Second).WithClock(clock)
+	cb.MinRequests = 4
+	status := http.StatusOK
+	serve := breakerBackend(cb, &status)
+
+	serve()
+	serve()
+	status = http.StatusBadGateway
+	serve()
+	if got := serve(); got != http.StatusBadGateway {
+		t.Fatalf("status = %d before the breaker opened, want %d", got, http.StatusBadGateway)
+	}
+	if got := serve(); got != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d with half the requests failed, want the breaker open", got)
+	}
+
+	// A failed probe after the cooldown opens the breaker again.
+	clock.Advance(10 * time.Second)
+	if got := serve(); got != http.StatusBadGateway {
+		t.Fatalf("probe status = %d, want it served", got)
+	}
+	if got := serve(); got != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d after a failed probe, want the breaker open", got)
+	}
+
+	// A successful probe closes it.
+	clock.Advance(10 * time.Second)
+	status = http.StatusOK
+	if got := serve(); got != http.StatusOK {
+		t.Fatalf("probe status = %d, want %d", got, http.StatusOK)
+	}
+	for i := 0; i < 3; i++ {
+		if got := serve(); got != http.StatusOK {
+			t.Fatalf("status = %d after a successful probe, want the breaker closed", got)
+		}
+	}
+}
+
+func TestCircuitBreakerSingleProbe(t *testing.T) {
+	clock := newFakeClock()
+	cb := NewCircuitBreaker(0.5, time.Minute, time.Second).WithClock(clock)
+	cb.MinRequests = 1
+
+	failing := http.StatusInternalServerError
+	breakerBackend(cb, &failing)()
+	clock.Advance(time.Second)
+
+	release := make(chan struct{})
+	k := New(cb)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		<-release
+	})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+	for {
+		cb.mu.Lock()
+		probing := cb.probing
+		cb.mu.Unlock()
+		if probing {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d while a probe is in flight, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	close(release)
+	<-done
+}
+
+func TestCircuitBreakerWindowAndMinRequests(t *testing.T) {
+	clock := newFakeClock()
+	cb := NewCircuitBreaker(0.5, time.Minute, time.Second).WithClock(clock)
+	cb.MinRequests = 3
+	status := http.StatusInternalServerError
+	serve := breakerBackend(cb, &status)
+
+	serve()
+	serve()
+	// The failures of the last window don't count towards the new one.
+	clock.Advance(time.Minute)
+	status = http.StatusOK
+	serve()
+	serve()
+	status = http.StatusInternalServerError
+	serve()
+	if got := serve(); got != http.StatusInternalServerError {
+		t.Errorf("status = %d, want the breaker still closed after a window reset", got)
+	}
+}
+
+func TestCircuitBreakerCountsPanics(t *testing.T) {
+	cb := NewCircuitBreaker(0.5, time.Minute, time.Minute)
+	cb.MinRequests = 1
+	k := New(cb)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	func() {
+		defer func() { recover() }()
+		k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d after a panic, want the breaker open", rec.Code)
+	}
+}
@@ -0,0 +1,23 @@
+package y_middleware
+
+import "time"
+
+// Clock tells time-based middleware what time it is. Middleware default to the system
+// clock; tests can substitute their own through WithClock to control time.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// clockOrDefault returns c, or the system clock if c is nil.
+func clockOrDefault(c Clock) Clock {
+	if c == nil {
+		return realClock{}
+	}
+	return c
+}
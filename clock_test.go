@@ -0,0 +1,79 @@
+package y_middleware
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock for tests that only moves when advanced.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestLoggerLatencyWithFakeClock(t *testing.T) {
+	clock := newFakeClock()
+	var buf bytes.Buffer
+	l := NewLogger().WithClock(clock)
+	l.ALogger = log.New(&buf, "", 0)
+	l.SetFormat("{{.StartTime}} {{.Status}} {{.Duration}}")
+
+	k := New(l)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		clock.Advance(1500 * time.Millisecond)
+		rw.WriteHeader(http.StatusCreated)
+	})
+	k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got, want := strings.TrimSpace(buf.String()), "2024-01-01T00:00:00Z 201 1.5s"; got != want {
+		t.Errorf("logged %q, want %q", got, want)
+	}
+}
+
+func TestRateLimiterRefillsWithFakeClock(t *testing.T) {
+	clock := newFakeClock()
+	l := NewRateLimiter(1, 2).WithClock(clock)
+	status := func() int {
+		rec := httptest.NewRecorder()
+		l.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil), func(http.ResponseWriter, *http.Request) {})
+		return rec.Code
+	}
+
+	if status() != http.StatusOK || status() != http.StatusOK {
+		t.Fatal("burst of 2 was not allowed")
+	}
+	if status() != http.StatusTooManyRequests {
+		t.Fatal("request over the burst was allowed")
+	}
+	clock.Advance(500 * time.Millisecond)
+	if status() != http.StatusTooManyRequests {
+		t.Fatal("half a token was enough for a request")
+	}
+	clock.Advance(500 * time.Millisecond)
+	if status() != http.StatusOK {
+		t.Fatal("request was not allowed after a token refilled")
+	}
+}
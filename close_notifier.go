@@ -0,0 +1,49 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+)
+
+// CloseNotifier is a middleware handler that gives the rest of the chain a request context that
+// is cancelled as soon as the client connection closes, so handlers can abort expensive work.
+// The net/http server already cancels the request context when it notices a disconnect; this
+// also bridges writers that only report it through the deprecated http.CloseNotifier.
+type CloseNotifier struct{}
+
+// NewCloseNotifier returns a new CloseNotifier instance.
+func NewCloseNotifier() *CloseNotifier {
+	return &CloseNotifier{}
+}
+
+func (c *CloseNotifier) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	if notifier := findCloseNotifier(rw); notifier != nil {
+		closed := notifier.CloseNotify()
+		go func() {
+			select {
+			case <-closed:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+	next(rw, r.WithContext(ctx))
+}
+
+// findCloseNotifier looks for a http.CloseNotifier among rw and the writers it wraps.
+func findCloseNotifier(rw http.ResponseWriter) http.CloseNotifier {
+	for rw != nil {
+		if notifier, ok := rw.(http.CloseNotifier); ok {
+			return notifier
+		}
+		unwrapper, ok := rw.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil
+		}
+		rw = unwrapper.Unwrap()
+	}
+	return nil
+}
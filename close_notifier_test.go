@@ -0,0 +1,42 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// closeNotifyRecorder is a ResponseRecorder reporting a disconnect through http.CloseNotifier.
+type closeNotifyRecorder struct {
+	*httptest.ResponseRecorder
+	closed chan bool
+}
+
+func (r *closeNotifyRecorder) CloseNotify() <-chan bool {
+	return r.closed
+}
+
+func TestCloseNotifierCancelsContext(t *testing.T) {
+	rec := &closeNotifyRecorder{ResponseRecorder: httptest.NewRecorder(), closed: make(chan bool, 1)}
+	k := New(NewCloseNotifier())
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rec.closed <- true
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+			t.Error("request context was not cancelled after the connection closed")
+		}
+	})
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestCloseNotifierWithoutNotifier(t *testing.T) {
+	k := New(NewCloseNotifier())
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Context().Err() != nil {
+			t.Error("request context is done without a disconnect")
+		}
+	})
+	k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
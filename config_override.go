@@ -0,0 +1,125 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultOverridePrefix is the header prefix ConfigOverride reads overrides from by default.
+const DefaultOverridePrefix = "X-Override-"
+
+// Settings honored by the middleware in this package. Values that do not parse, or durations
+// and sizes that are not positive, are ignored.
+const (
+	// OverrideLogLevel set to "debug" makes LogSampler log the request whatever its sample draw.
+	OverrideLogLevel = "log-level"
+	// OverrideReadTimeout replaces the ReadTimeout timeout, e.g. "30s".
+	OverrideReadTimeout = "read-timeout"
+	// OverrideJSONBodyLimit replaces the JSONBodyLimit MaxSize, in bytes.
+	OverrideJSONBodyLimit = "json-body-limit"
+)
+
+type configOverrideKey struct{}
+
+// ConfigOverride is a middleware handler that lets trusted internal tools override per-request
+// settings, such as log verbosity or timeouts, by sending headers starting with Prefix. The
+// header name without the prefix, lowercased, is the setting name, e.g. "X-Override-Log-Level"
+// sets "log-level". Other middleware read the settings with Override; the Override constants
+// list the ones this package honors.
+//
+// Overrides are only honored when the request comes directly from an address in Trusted, and
+// only for the names in Allowed if it is not empty. The override headers of every other request
+// are removed so the rest of the chain never sees them.
+type ConfigOverride struct {
+	Trusted []netip.Prefix
+	Prefix  string
+	Allowed []string
+}
+
+// NewConfigOverride returns a new ConfigOverride instance honoring overrides from the trusted networks.
+func NewConfigOverride(trusted ...netip.Prefix) *ConfigOverride {
+	return &ConfigOverride{
+		Trusted: trusted,
+		Prefix:  DefaultOverridePrefix,
+	}
+}
+
+func (c *ConfigOverride) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	prefix := http.CanonicalHeaderKey(c.Prefix)
+	trusted := c.trusted(r)
+
+	overrides := make(map[string]string)
+	for name, values := range r.Header {
+		setting, ok := strings.CutPrefix(name, prefix)
+		if !ok || setting == "" {
+			continue
+		}
+		setting = strings.ToLower(setting)
+		if trusted && len(values) > 0 && c.allowed(setting) {
+			overrides[setting] = values[0]
+		}
+		r.Header.Del(name)
+	}
+
+	if len(overrides) > 0 {
+		r = r.WithContext(context.WithValue(r.Context(), configOverrideKey{}, overrides))
+	}
+	next(rw, r)
+}
+
+func (c *ConfigOverride) trusted(r *http.Request) bool {
+	addr, err := netip.ParseAddr(clientIP(r))
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range c.Trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *ConfigOverride) allowed(setting string) bool {
+	if len(c.Allowed) == 0 {
+		return true
+	}
+	for _, name := range c.Allowed {
+		if strings.EqualFold(name, setting) {
+			return true
+		}
+	}
+	return false
+}
+
+// Override returns the value a trusted source set for the named setting through ConfigOverride.
+func Override(ctx context.Context, name string) (string, bool) {
+	overrides, _ := ctx.Value(configOverrideKey{}).(map[string]string)
+	value, ok := overrides[strings.ToLower(name)]
+	return value, ok
+}
+
+// overrideDuration returns the positive duration set for name through ConfigOverride, or def.
+func overrideDuration(ctx context.Context, name string, def time.Duration) time.Duration {
+	if value, ok := Override(ctx, name); ok {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+	}
+	return def
+}
+
+// overrideInt64 returns the positive integer set for name through ConfigOverride, or def.
+func overrideInt64(ctx context.Context, name string, def int64) int64 {
+	if value, ok := Override(ctx, name); ok {
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil && n > 0 {
+			return n
+		}
+	}
+	return def
+}
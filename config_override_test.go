@@ -0,0 +1,67 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func serveOverride(c *ConfigOverride, remoteAddr string, headers map[string]string) (map[string]string, http.Header) {
+	got := map[string]string{}
+	var seen http.Header
+	k := New(c)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		for _, name := range []string{"log-level", "timeout"} {
+			if v, ok := Override(r.Context(), name); ok {
+				got[name] = v
+			}
+		}
+		seen = r.Header
+	})
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = remoteAddr
+	for name, value := range headers {
+		r.Header.Set(name, value)
+	}
+	k.ServeHTTP(httptest.NewRecorder(), r)
+	return got, seen
+}
+
+func TestConfigOverride(t *testing.T) {
+	c := NewConfigOverride(netip.MustParsePrefix("10.0.0.0/8"))
+	headers := map[string]string{"X-Override-Log-Level": "debug", "X-Override-Timeout": "5s", "Accept": "*/*"}
+
+	got, seen := serveOverride(c, "10.1.2.3:1234", headers)
+	if got["log-level"] != "debug" || got["timeout"] != "5s" {
+		t.Errorf("trusted overrides = %v, want log-level and timeout", got)
+	}
+	if seen.Get("X-Override-Log-Level") != "" || seen.Get("Accept") != "*/*" {
+		t.Errorf("headers = %v, want only the override headers removed", seen)
+	}
+
+	got, seen = serveOverride(c, "192.0.2.1:1234", headers)
+	if len(got) != 0 {
+		t.Errorf("untrusted overrides = %v, want none", got)
+	}
+	if seen.Get("X-Override-Timeout") != "" {
+		t.Error("override header of an untrusted request reached the handler")
+	}
+
+	got, _ = serveOverride(c, "[::ffff:10.0.0.1]:1234", headers)
+	if len(got) != 2 {
+		t.Errorf("IPv4-mapped trusted address: overrides = %v, want both", got)
+	}
+}
+
+func TestConfigOverrideAllowed(t *testing.T) {
+	c := NewConfigOverride(netip.MustParsePrefix("10.0.0.0/8"))
+	c.Allowed = []string{"Log-Level"}
+	got, seen := serveOverride(c, "10.0.0.1:1234", map[string]string{"X-Override-Log-Level": "debug", "X-Override-Timeout": "5s"})
+	if len(got) != 1 || got["log-level"] != "debug" {
+		t.Errorf("overrides = %v, want only log-level", got)
+	}
+	if seen.Get("X-Override-Timeout") != "" {
+		t.Error("disallowed override header reached the handler")
+	}
+}
@@ -0,0 +1,41 @@
+package y_middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+)
+
+type loggerKey struct{}
+
+var defaultContextLogger ALogger = log.New(os.Stdout, "[kudret] ", 0)
+
+// ContextLogger is a middleware handler that makes a logger available to the rest of the chain
+// through LoggerFrom, so handlers log through whatever the stack has set up instead of a global.
+type ContextLogger struct {
+	ALogger
+}
+
+// NewContextLogger returns a new ContextLogger instance handing out l.
+func NewContextLogger(l ALogger) *ContextLogger {
+	return &ContextLogger{ALogger: l}
+}
+
+func (c *ContextLogger) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	next(rw, r.WithContext(WithLogger(r.Context(), c.ALogger)))
+}
+
+// WithLogger returns a copy of ctx that LoggerFrom returns l for.
+func WithLogger(ctx context.Context, l ALogger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// LoggerFrom returns the logger set up for the request, or a logger writing to stdout when
+// there is none.
+func LoggerFrom(ctx context.Context) ALogger {
+	if l, ok := ctx.Value(loggerKey{}).(ALogger); ok {
+		return l
+	}
+	return defaultContextLogger
+}
@@ -0,0 +1,32 @@
+package y_middleware
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	k := New(NewContextLogger(log.New(&buf, "", 0)))
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		LoggerFrom(r.Context()).Println("from handler")
+	})
+	k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := buf.String(); got != "from handler\n" {
+		t.Errorf("log = %q, want %q", got, "from handler\n")
+	}
+}
+
+func TestLoggerFromDefault(t *testing.T) {
+	if l := LoggerFrom(context.Background()); l != defaultContextLogger {
+		t.Errorf("LoggerFrom without a logger = %v, want the default logger", l)
+	}
+	l := log.New(&bytes.Buffer{}, "", 0)
+	if got := LoggerFrom(WithLogger(context.Background(), l)); got != l {
+		t.Errorf("LoggerFrom(WithLogger(l)) = %v, want l", got)
+	}
+}
@@ -0,0 +1,56 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func serveCookies(c *CookiePolicy, write bool, cookies ...string) []string {
+	k := New(c)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		for _, cookie := range cookies {
+			rw.Header().Add("Set-Cookie", cookie)
+		}
+		if write {
+			rw.WriteHeader(http.StatusOK)
+		}
+	})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	return rec.Header()["Set-Cookie"]
+}
+
+func TestCookiePolicyAddsAttributes(t *testing.T) {
+	for _, write := range []bool{true, false} {
+		got := serveCookies(NewCookiePolicy(http.SameSiteLaxMode), write, "session=abc; Path=/")
+		if want := "session=abc; Path=/; Secure; HttpOnly; SameSite=Lax"; len(got) != 1 || got[0] != want {
+			t.Errorf("handler writing %v: Set-Cookie = %q, want %q", write, got, want)
+		}
+	}
+}
+
+func TestCookiePolicyKeepsHandlerAttributes(t *testing.T) {
+	got := serveCookies(NewCookiePolicy(http.SameSiteStrictMode), true,
+		"a=1; secure; SameSite=None",
+		"b=2; HttpOnly",
+	)
+	want := []string{
+		"a=1; secure; SameSite=None; HttpOnly",
+		"b=2; HttpOnly; Secure; SameSite=Strict",
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Set-Cookie = %q, want %q", got, want)
+	}
+}
+
+func TestCookiePolicyExemptAndDefaultSameSite(t *testing.T) {
+	c := NewCookiePolicy(http.SameSiteDefaultMode)
+	c.HttpOnly = false
+	c.Exempt = []string{"csrf"}
+	got := serveCookies(c, true, "csrf=token", "id=1")
+	want := []string{"csrf=token", "id=1; Secure"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Set-Cookie = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,151 @@
+package y_middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxSeenOrigins caps how many distinct origins CORS keeps a record of.
+const maxSeenOrigins = 1024
+
+// CORS is a middleware handler implementing Cross-Origin Resource Sharing for the
+// AllowedOrigins. Preflight requests are answered with a 204 without calling next; other
+// cross-origin requests get the Access-Control-Allow-Origin header and are served as usual.
+// Requests from other origins are served without CORS headers, so browsers block them.
+//
+// Preflight responses may be cached by browsers for MaxAge, or for the duration in OriginMaxAge
+// for the origins listed there. CORS records how often each origin was seen, see SeenOrigins,
+// and logs the first request from each origin that is not allowed when Logger is set, to help
+// spot misconfigured clients.
+type CORS struct {
+	// AllowedOrigins lists the allowed origins, e.g. "https://example.com". "*" allows any origin.
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	ExposedHeaders []string
+	// AllowCredentials allows credentialed requests from the origins listed in AllowedOrigins.
+	// It never applies to origins only allowed through "*".
+	AllowCredentials bool
+	MaxAge           time.Duration
+	// OriginMaxAge overrides MaxAge for individual origins.
+	OriginMaxAge map[string]time.Duration
+	Logger       ALogger
+
+	mu   sync.Mutex
+	seen map[string]int
+}
+
+// NewCORS returns a new CORS instance allowing simple methods from origins.
+func NewCORS(origins ...string) *CORS {
+	return &CORS{
+		AllowedOrigins: origins,
+		AllowedMethods: []string{http.MethodGet, http.MethodHead, http.MethodPost},
+		MaxAge:         5 * time.Minute,
+	}
+}
+
+func (c *CORS) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	origin := r.Header.Get("Origin")
+	h := rw.Header()
+	addVary(h, "Origin")
+	if origin == "" {
+		next(rw, r)
+		return
+	}
+
+	allowed := c.allowed(origin)
+	c.record(r, origin, allowed)
+	preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+	if !allowed {
+		if preflight {
+			rw.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next(rw, r)
+		return
+	}
+
+	// Credentials are only ever allowed for listed origins: reflecting any origin matched by
+	// "*" would let every site make credentialed requests.
+	if containsOrigin(c.AllowedOrigins, origin) {
+		h.Set("Access-Control-Allow-Origin", origin)
+		if c.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+	} else {
+		h.Set("Access-Control-Allow-Origin", "*")
+	}
+
+	if !preflight {
+		if len(c.ExposedHeaders) > 0 {
+			h.Set("Access-Control-Expose-Headers", strings.Join(c.ExposedHeaders, ", "))
+		}
+		next(rw, r)
+		return
+	}
+
+	addVary(h, "Access-Control-Request-Method")
+	addVary(h, "Access-Control-Request-Headers")
+	h.Set("Access-Control-Allow-Methods", strings.Join(c.AllowedMethods, ", "))
+	if len(c.AllowedHeaders) > 0 {
+		h.Set("Access-Control-Allow-Headers", strings.Join(c.AllowedHeaders, ", "))
+	} else if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+		h.Set("Access-Control-Allow-Headers", requested)
+	}
+	if maxAge := c.maxAge(origin); maxAge > 0 {
+		h.Set("Access-Control-Max-Age", strconv.Itoa(int(maxAge/time.Second)))
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (c *CORS) allowed(origin string) bool {
+	return containsOrigin(c.AllowedOrigins, "*") || containsOrigin(c.AllowedOrigins, origin)
+}
+
+func (c *CORS) maxAge(origin string) time.Duration {
+	if d, ok := c.OriginMaxAge[origin]; ok {
+		return d
+	}
+	return c.MaxAge
+}
+
+// record counts a request from origin, logging the first one from an origin that is not allowed.
+func (c *CORS) record(r *http.Request, origin string, allowed bool) {
+	c.mu.Lock()
+	if c.seen == nil {
+		c.seen = make(map[string]int)
+	}
+	n, known := c.seen[origin]
+	if known || len(c.seen) < maxSeenOrigins {
+		c.seen[origin] = n + 1
+	}
+	c.mu.Unlock()
+
+	if !known && !allowed && c.Logger != nil {
+		c.Logger.Printf("CORS request from unknown origin %q: %s %s", origin, r.Method, r.URL.Path)
+	}
+}
+
+// SeenOrigins returns how many requests were seen from each origin, allowed or not. At most
+// 1024 distinct origins are recorded.
+func (c *CORS) SeenOrigins() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	seen := make(map[string]int, len(c.seen))
+	for origin, n := range c.seen {
+		seen[origin] = n
+	}
+	return seen
+}
+
+func containsOrigin(origins []string, origin string) bool {
+	for _, o := range origins {
+		if strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	return false
+}
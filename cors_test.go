@@ -0,0 +1,116 @@
+package y_middleware
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func corsRequest(c *CORS, method, origin string, preflight bool) (*httptest.ResponseRecorder, bool) {
+	req := httptest.NewRequest(method, "/", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	if preflight {
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	}
+	rec := httptest.NewRecorder()
+	called := false
+	c.ServeHTTP(rec, req, func(http.ResponseWriter, *http.Request) { called = true })
+	return rec, called
+}
+
+func TestCORSPreflight(t *testing.T) {
+	c := NewCORS("https://a.example")
+	c.OriginMaxAge = map[string]time.Duration{"https://b.example": time.Hour}
+	c.AllowedOrigins = append(c.AllowedOrigins, "https://b.example")
+
+	rec, called := corsRequest(c, http.MethodOptions, "https://a.example", true)
+	if called {
+		t.Fatal("preflight reached next")
+	}
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want 204", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://a.example" {
+		t.Errorf("Access-Control-Allow-Origin = %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Max-Age"); got != "300" {
+		t.Errorf("Access-Control-Max-Age = %q, want the default 300", got)
+	}
+
+	rec, _ = corsRequest(c, http.MethodOptions, "https://b.example", true)
+	if got := rec.Header().Get("Access-Control-Max-Age"); got != "3600" {
+		t.Errorf("Access-Control-Max-Age = %q, want the per-origin 3600", got)
+	}
+}
+
+func TestCORSActualRequest(t *testing.T) {
+	c := NewCORS("https://a.example")
+	c.ExposedHeaders = []string{"X-Total"}
+
+	rec, called := corsRequest(c, http.MethodGet, "https://a.example", false)
+	if !called {
+		t.Fatal("next was not called")
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://a.example" {
+		t.Errorf("Access-Control-Allow-Origin = %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Expose-Headers"); got != "X-Total" {
+		t.Errorf("Access-Control-Expose-Headers = %q", got)
+	}
+
+	rec, called = corsRequest(c, http.MethodGet, "https://evil.example", false)
+	if !called {
+		t.Fatal("next was not called for a disallowed origin")
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q for a disallowed origin", got)
+	}
+}
+
+func TestCORSWildcardNeverAllowsCredentials(t *testing.T) {
+	c := NewCORS("*", "https://a.example")
+	c.AllowCredentials = true
+
+	rec, _ := corsRequest(c, http.MethodGet, "https://evil.example", false)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Access-Control-Allow-Credentials = %q for a wildcard match", got)
+	}
+
+	rec, _ = corsRequest(c, http.MethodGet, "https://a.example", false)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://a.example" {
+		t.Errorf("Access-Control-Allow-Origin = %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Access-Control-Allow-Credentials = %q for a listed origin", got)
+	}
+}
+
+func TestCORSRecordsAndLogsUnknownOrigins(t *testing.T) {
+	var buf bytes.Buffer
+	c := NewCORS("https://a.example")
+	c.Logger = log.New(&buf, "", 0)
+
+	corsRequest(c, http.MethodGet, "https://a.example", false)
+	corsRequest(c, http.MethodGet, "https://evil.example", false)
+	corsRequest(c, http.MethodOptions, "https://evil.example", true)
+
+	if n := strings.Count(buf.String(), "evil.example"); n != 1 {
+		t.Errorf("unknown origin logged %d times, want once:\n%s", n, buf.String())
+	}
+	if strings.Contains(buf.String(), "a.example\"") {
+		t.Errorf("allowed origin logged:\n%s", buf.String())
+	}
+	seen := c.SeenOrigins()
+	if seen["https://a.example"] != 1 || seen["https://evil.example"] != 2 {
+		t.Errorf("SeenOrigins() = %v", seen)
+	}
+}
@@ -0,0 +1,40 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+	"runtime/pprof"
+)
+
+// DebugLabels is a development middleware handler that attaches pprof labels for the request
+// method, path and request ID to the goroutine serving it, so goroutine profiles and traces show
+// which request a stuck goroutine belongs to. Labelling has a cost, so it only happens while
+// Enabled is set.
+type DebugLabels struct {
+	Enabled bool
+	// RequestIDHeader is the request header the request ID label is read from.
+	RequestIDHeader string
+}
+
+// NewDebugLabels returns a new, enabled DebugLabels instance.
+func NewDebugLabels() *DebugLabels {
+	return &DebugLabels{
+		Enabled:         true,
+		RequestIDHeader: "X-Request-Id",
+	}
+}
+
+func (d *DebugLabels) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !d.Enabled {
+		next(rw, r)
+		return
+	}
+
+	labels := []string{"method", r.Method, "path", r.URL.Path}
+	if id := r.Header.Get(d.RequestIDHeader); id != "" {
+		labels = append(labels, "request_id", id)
+	}
+	pprof.Do(r.Context(), pprof.Labels(labels...), func(ctx context.Context) {
+		next(rw, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,42 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime/pprof"
+	"testing"
+)
+
+func TestDebugLabels(t *testing.T) {
+	d := NewDebugLabels()
+	labels := map[string]string{}
+	k := New(d)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		clear(labels)
+		pprof.ForLabels(r.Context(), func(key, value string) bool {
+			labels[key] = value
+			return true
+		})
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	r.Header.Set("X-Request-Id", "abc")
+	k.ServeHTTP(httptest.NewRecorder(), r)
+	want := map[string]string{"method": "POST", "path": "/orders", "request_id": "abc"}
+	for key, value := range want {
+		if labels[key] != value {
+			t.Errorf("label %s = %q, want %q", key, labels[key], value)
+		}
+	}
+
+	k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if _, ok := labels["request_id"]; ok {
+		t.Error("request_id label set without a request ID header")
+	}
+
+	d.Enabled = false
+	k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if len(labels) != 0 {
+		t.Errorf("labels = %v while disabled, want none", labels)
+	}
+}
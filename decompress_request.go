@@ -0,0 +1,118 @@
+package y_middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// DefaultDecompressMaxSize is the largest decompressed request body allowed by default.
+	DefaultDecompressMaxSize = 10 << 20
+	// DefaultDecompressMaxRatio is the largest decompressed-to-compressed size ratio allowed by default.
+	DefaultDecompressMaxRatio = 100
+)
+
+// ErrDecompressionLimit is returned from reads of a request body decompressed by
+// DecompressRequest once it grew past MaxSize or MaxRatio.
+var ErrDecompressionLimit = errors.New("decompressed request body too large")
+
+// DecompressRequest is a middleware handler that transparently decompresses request bodies sent
+// with a gzip or deflate Content-Encoding. Bodies with any other encoding get a 415. The
+// Content-Encoding and Content-Length headers are removed and the request's ContentLength is
+// set to -1, as the decompressed length is unknown.
+//
+// To guard against decompression bombs, reads fail with ErrDecompressionLimit as soon as the
+// body grows past MaxSize, or past MaxRatio times the compressed bytes consumed so far. If the
+// handler has not written a response by then, the client gets a 413.
+type DecompressRequest struct {
+	MaxSize  int64
+	MaxRatio float64
+}
+
+// NewDecompressRequest returns a new DecompressRequest instance with the default limits.
+func NewDecompressRequest() *DecompressRequest {
+	return &DecompressRequest{
+		MaxSize:  DefaultDecompressMaxSize,
+		MaxRatio: DefaultDecompressMaxRatio,
+	}
+}
+
+func (d *DecompressRequest) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+	if encoding == "" || encoding == "identity" || r.Body == nil || r.Body == http.NoBody {
+		next(rw, r)
+		return
+	}
+
+	compressed := &countingReadCloser{ReadCloser: r.Body}
+	var (
+		decompressed io.ReadCloser
+		err          error
+	)
+	switch encoding {
+	case "gzip", "x-gzip":
+		decompressed, err = gzip.NewReader(compressed)
+	case "deflate":
+		decompressed, err = zlib.NewReader(compressed)
+	default:
+		http.Error(rw, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	body := &decompressedBody{
+		ReadCloser: decompressed,
+		compressed: compressed,
+		maxSize:    d.MaxSize,
+		maxRatio:   d.MaxRatio,
+	}
+	r.Body = body
+	r.Header.Del("Content-Encoding")
+	// The decompressed length is not known up front, and the compressed one would make
+	// handlers stop reading early.
+	r.Header.Del("Content-Length")
+	r.ContentLength = -1
+
+	res := wrapResponseWriter(rw)
+	next(res, r)
+
+	if body.exceeded && !res.Written() {
+		http.Error(res, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+	}
+}
+
+// decompressedBody enforces the DecompressRequest limits on a decompressing reader.
+type decompressedBody struct {
+	io.ReadCloser
+	compressed *countingReadCloser
+	maxSize    int64
+	maxRatio   float64
+	n          int64
+	exceeded   bool
+}
+
+func (b *decompressedBody) Read(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, ErrDecompressionLimit
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if b.maxSize > 0 && b.n > b.maxSize ||
+		b.maxRatio > 0 && float64(b.n) > b.maxRatio*float64(b.compressed.n) {
+		b.exceeded = true
+		return 0, ErrDecompressionLimit
+	}
+	return n, err
+}
+
+func (b *decompressedBody) Close() error {
+	b.ReadCloser.Close()
+	return b.compressed.Close()
+}
@@ -0,0 +1,158 @@
+package y_middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func gzipped(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := io.WriteString(zw, s); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// serveDecompress serves a request with body and encoding, and returns the response and what
+// the handler read.
+func serveDecompress(d *DecompressRequest, encoding string, body []byte) (*httptest.ResponseRecorder, string, error) {
+	var (
+		read string
+		err  error
+	)
+	k := New(d)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "" {
+			err = errors.New("Content-Encoding still set")
+			return
+		}
+		var b []byte
+		b, err = io.ReadAll(r.Body)
+		read = string(b)
+	})
+	r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	r.Header.Set("Content-Encoding", encoding)
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, r)
+	return rec, read, err
+}
+
+func TestDecompressRequest(t *testing.T) {
+	var deflated bytes.Buffer
+	zw := zlib.NewWriter(&deflated)
+	io.WriteString(zw, "deflated body")
+	zw.Close()
+
+	tests := []struct {
+		encoding string
+		body     []byte
+		want     string
+	}{
+		{"gzip", gzipped(t, "gzipped body"), "gzipped body"},
+		{"X-GZIP", gzipped(t, "x-gzip body"), "x-gzip body"},
+		{"deflate", deflated.Bytes(), "deflated body"},
+	}
+	for _, tt := range tests {
+		rec, read, err := serveDecompress(NewDecompressRequest(), tt.encoding, tt.body)
+		if rec.Code != http.StatusOK || err != nil || read != tt.want {
+			t.Errorf("%s: got %d, %q, %v, want %q", tt.encoding, rec.Code, read, err, tt.want)
+		}
+	}
+}
+
+func TestDecompressRequestRejects(t *testing.T) {
+	tests := []struct {
+		encoding string
+		body     []byte
+		status   int
+	}{
+		{"br", []byte("whatever"), http.StatusUnsupportedMediaType},
+		{"gzip", []byte("not gzip"), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if rec, _, _ := serveDecompress(NewDecompressRequest(), tt.encoding, tt.body); rec.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.encoding, rec.Code, tt.status)
+		}
+	}
+}
+
+func TestDecompressRequestLimits(t *testing.T) {
+	bomb := gzipped(t, strings.Repeat("a", 1<<20))
+
+	size := NewDecompressRequest()
+	size.MaxSize = 1 << 10
+	size.MaxRatio = 0
+	ratio := NewDecompressRequest()
+
+	for name, d := range map[string]*DecompressRequest{"MaxSize": size, "MaxRatio": ratio} {
+		rec, _, err := serveDecompress(d, "gzip", bomb)
+		if !errors.Is(err, ErrDecompressionLimit) {
+			t.Errorf("%s: read err = %v, want %v", name, err, ErrDecompressionLimit)
+		}
+		if rec.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("%s: status = %d, want %d", name, rec.Code, http.StatusRequestEntityTooLarge)
+		}
+	}
+
+	unlimited := &DecompressRequest{}
+	if rec, read, err := serveDecompress(unlimited, "gzip", bomb); rec.Code != http.StatusOK || err != nil || len(read) != 1<<20 {
+		t.Errorf("no limits: got %d, %d bytes, %v", rec.Code, len(read), err)
+	}
+}
+
+func TestDecompressRequestPassesThrough(t *testing.T) {
+	var body string
+	k := New(NewDecompressRequest())
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+	})
+	for _, encoding := range []string{"", "identity"} {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("plain"))
+		r.Header.Set("Content-Encoding", encoding)
+		rec := httptest.NewRecorder()
+		k.ServeHTTP(rec, r)
+		if rec.Code != http.StatusOK || body != "plain" {
+			t.Errorf("%q: got %d, %q, want the body untouched", encoding, rec.Code, body)
+		}
+	}
+}
+
+func TestDecompressRequestClearsContentLength(t *testing.T) {
+	body := gzipped(t, "a body longer than its compressed form, a body longer than its compressed form")
+	var (
+		length int64
+		header string
+		read   string
+	)
+	k := New(NewDecompressRequest())
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		length, header = r.ContentLength, r.Header.Get("Content-Length")
+		b, _ := io.ReadAll(r.Body)
+		read = string(b)
+	})
+	r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	r.Header.Set("Content-Encoding", "gzip")
+	r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	k.ServeHTTP(httptest.NewRecorder(), r)
+
+	if length != -1 || header != "" {
+		t.Errorf("ContentLength = %d, Content-Length = %q, want -1 and no header", length, header)
+	}
+	if want := "a body longer than its compressed form, a body longer than its compressed form"; read != want {
+		t.Errorf("read %q, want %q", read, want)
+	}
+}
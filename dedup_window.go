@@ -0,0 +1,138 @@
+package y_middleware
+
+import (
+	"container/list"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultDedupWindowCapacity is the number of recent requests DedupWindow remembers by default.
+	DefaultDedupWindowCapacity = 10000
+	// DefaultDedupWindowMaxBodySize is the largest request body DedupWindow reads by default.
+	DefaultDedupWindowMaxBodySize = 1 << 20
+)
+
+// DedupWindow is a middleware handler that rejects a request with a 409 when the same client sent
+// an identical request, by method, path and body, within Window, guarding against double-submits.
+// Only requests with one of Methods are checked; safe methods such as GET are left alone so
+// reloads and polling keep working. Recent requests are remembered in an LRU of up to Capacity
+// entries.
+//
+// Clients are told apart by KeyFunc, defaulting to the authenticated identity: the subject of
+// the JWT claims or a string principal from AuthChain. Unauthenticated requests are keyed by
+// client IP, so different users behind one NAT or proxy submitting identical requests within
+// the window are taken for one client.
+type DedupWindow struct {
+	Window      time.Duration
+	Capacity    int
+	MaxBodySize int64
+	// Methods are the request methods that are deduplicated. Defaults to POST, PUT, PATCH and DELETE.
+	Methods []string
+	// KeyFunc returns the client key for a request. Requests for which it returns an empty key
+	// are keyed by client IP.
+	KeyFunc func(r *http.Request) string
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	clock   Clock
+}
+
+type dedupEntry struct {
+	key  string
+	seen time.Time
+}
+
+// NewDedupWindow returns a new DedupWindow instance rejecting repeats within window.
+func NewDedupWindow(window time.Duration) *DedupWindow {
+	return &DedupWindow{
+		Window:      window,
+		Capacity:    DefaultDedupWindowCapacity,
+		MaxBodySize: DefaultDedupWindowMaxBodySize,
+		Methods:     []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+		KeyFunc:     authenticatedIdentity,
+		lru:         list.New(),
+		entries:     make(map[string]*list.Element),
+	}
+}
+
+// WithClock makes the DedupWindow read the time from c instead of the system clock.
+func (d *DedupWindow) WithClock(c Clock) *DedupWindow {
+	d.clock = c
+	return d
+}
+
+func (d *DedupWindow) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !containsMethod(d.Methods, r.Method) {
+		next(rw, r)
+		return
+	}
+
+	body, err := bufferBody(r, d.MaxBodySize)
+	if errors.Is(err, errBodyTooLarge) {
+		http.Error(rw, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	if d.duplicate(d.client(r) + " " + requestFingerprint(r, body)) {
+		http.Error(rw, "duplicate request", http.StatusConflict)
+		return
+	}
+	next(rw, r)
+}
+
+// client returns the key telling the client of r apart from others.
+func (d *DedupWindow) client(r *http.Request) string {
+	if d.KeyFunc != nil {
+		if key := d.KeyFunc(r); key != "" {
+			return "key:" + key
+		}
+	}
+	return "ip:" + clientIP(r)
+}
+
+// authenticatedIdentity returns the subject of the request's JWT claims, or its principal if
+// that is a string. It returns an empty string for unauthenticated requests.
+func authenticatedIdentity(r *http.Request) string {
+	if claims, ok := ClaimsFrom(r.Context()); ok {
+		if sub := claimString(claims, "sub"); sub != "" {
+			return sub
+		}
+	}
+	principal, _ := PrincipalFrom(r.Context())
+	id, _ := principal.(string)
+	return id
+}
+
+// duplicate reports whether key was seen within the window, and records it as seen now.
+func (d *DedupWindow) duplicate(key string) bool {
+	now := clockOrDefault(d.clock).Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if el, ok := d.entries[key]; ok {
+		e := el.Value.(*dedupEntry)
+		if now.Sub(e.seen) < d.Window {
+			return true
+		}
+		e.seen = now
+		d.lru.MoveToFront(el)
+		return false
+	}
+
+	d.entries[key] = d.lru.PushFront(&dedupEntry{key: key, seen: now})
+	for d.lru.Len() > d.Capacity {
+		oldest := d.lru.Back()
+		d.lru.Remove(oldest)
+		delete(d.entries, oldest.Value.(*dedupEntry).key)
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func dedupStatus(d *DedupWindow, r *http.Request) int {
+	rec := httptest.NewRecorder()
+	d.ServeHTTP(rec, r, func(http.ResponseWriter, *http.Request) {})
+	return rec.Code
+}
+
+func TestDedupWindowRejectsRepeatsWithinWindow(t *testing.T) {
+	clock := newFakeClock()
+	d := NewDedupWindow(time.Second).WithClock(clock)
+	submit := func(body string) int {
+		return dedupStatus(d, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body)))
+	}
+
+	if submit("a") != http.StatusOK {
+		t.Fatal("first submission rejected")
+	}
+	if submit("a") != http.StatusConflict {
+		t.Error("repeat within the window allowed")
+	}
+	if submit("b") != http.StatusOK {
+		t.Error("different body rejected")
+	}
+	clock.Advance(time.Second)
+	if submit("a") != http.StatusOK {
+		t.Error("repeat after the window rejected")
+	}
+}
+
+func TestDedupWindowIgnoresSafeMethods(t *testing.T) {
+	d := NewDedupWindow(time.Minute)
+	for i := 0; i < 3; i++ {
+		if code := dedupStatus(d, httptest.NewRequest(http.MethodGet, "/feed", nil)); code != http.StatusOK {
+			t.Fatalf("GET %d got %d, want 200", i, code)
+		}
+	}
+}
+
+func TestDedupWindowKeysOnAuthenticatedIdentity(t *testing.T) {
+	d := NewDedupWindow(time.Minute)
+	as := func(user string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/like", strings.NewReader("post=1"))
+		return r.WithContext(context.WithValue(r.Context(), principalKey{}, user))
+	}
+
+	if dedupStatus(d, as("alice")) != http.StatusOK || dedupStatus(d, as("bob")) != http.StatusOK {
+		t.Error("users behind the same IP were taken for one client")
+	}
+	if dedupStatus(d, as("alice")) != http.StatusConflict {
+		t.Error("repeat by the same user allowed")
+	}
+}
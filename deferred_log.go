@@ -0,0 +1,86 @@
+package y_middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+type deferredLogKey struct{}
+
+// DeferredLog is a middleware handler that holds back the lines logged through LoggerFrom while
+// the request is served. They are written to Sink only if the response status is at least
+// MinStatus or a handler called FlushLog, and are dropped otherwise. This keeps successful
+// requests quiet while preserving the diagnostics of failed ones. The lines of a request whose
+// handler panics are always written, before the panic goes on up the chain.
+type DeferredLog struct {
+	// Sink receives the flushed lines. When nil, they go to the logger the request already had.
+	Sink      ALogger
+	MinStatus int
+}
+
+// NewDeferredLog returns a new DeferredLog instance flushing the logs of requests that fail
+// with a 4xx or 5xx status.
+func NewDeferredLog() *DeferredLog {
+	return &DeferredLog{MinStatus: http.StatusBadRequest}
+}
+
+func (d *DeferredLog) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	sink := d.Sink
+	if sink == nil {
+		sink = LoggerFrom(r.Context())
+	}
+
+	buf := &deferredLogger{}
+	ctx := context.WithValue(WithLogger(r.Context(), buf), deferredLogKey{}, buf)
+	res := wrapResponseWriter(rw)
+
+	// Flushing without recovering lets the panic go on with its stack intact.
+	completed := false
+	defer func() {
+		buf.mu.Lock()
+		defer buf.mu.Unlock()
+		if !completed || res.Status() >= d.MinStatus || buf.flush {
+			for _, line := range buf.lines {
+				sink.Println(line)
+			}
+		}
+		buf.lines = nil
+	}()
+	next(res, r.WithContext(ctx))
+	completed = true
+}
+
+// FlushLog makes DeferredLog write the lines logged for the request whatever the response
+// status. It does nothing without a DeferredLog in the chain.
+func FlushLog(ctx context.Context) {
+	if buf, ok := ctx.Value(deferredLogKey{}).(*deferredLogger); ok {
+		buf.mu.Lock()
+		buf.flush = true
+		buf.mu.Unlock()
+	}
+}
+
+type deferredLogger struct {
+	mu    sync.Mutex
+	lines []string
+	flush bool
+}
+
+func (l *deferredLogger) Println(v ...interface{}) {
+	l.add(fmt.Sprintln(v...))
+}
+
+func (l *deferredLogger) Printf(format string, v ...interface{}) {
+	l.add(fmt.Sprintf(format, v...))
+}
+
+func (l *deferredLogger) add(line string) {
+	if n := len(line); n > 0 && line[n-1] == '\n' {
+		line = line[:n-1]
+	}
+	l.mu.Lock()
+	l.lines = append(l.lines, line)
+	l.mu.Unlock()
+}
@@ -0,0 +1,60 @@
+package y_middleware
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serveDeferredLog(t *testing.T, status int, flush bool) string {
+	t.Helper()
+	var out bytes.Buffer
+	d := NewDeferredLog()
+	d.Sink = log.New(&out, "", 0)
+	d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), func(rw http.ResponseWriter, r *http.Request) {
+		LoggerFrom(r.Context()).Printf("loading %s", "user")
+		LoggerFrom(r.Context()).Println("done")
+		if flush {
+			FlushLog(r.Context())
+		}
+		rw.WriteHeader(status)
+	})
+	return out.String()
+}
+
+func TestDeferredLogDropsLinesOfSuccessfulRequests(t *testing.T) {
+	if out := serveDeferredLog(t, http.StatusOK, false); out != "" {
+		t.Errorf("logged %q for a successful request", out)
+	}
+}
+
+func TestDeferredLogFlushesFailedRequests(t *testing.T) {
+	if out := serveDeferredLog(t, http.StatusInternalServerError, false); out != "loading user\ndone\n" {
+		t.Errorf("logged %q, want both lines", out)
+	}
+	if out := serveDeferredLog(t, http.StatusOK, true); !strings.Contains(out, "loading user") {
+		t.Errorf("FlushLog did not flush: %q", out)
+	}
+}
+
+func TestDeferredLogFlushesOnPanic(t *testing.T) {
+	var out bytes.Buffer
+	d := NewDeferredLog()
+	d.Sink = log.New(&out, "", 0)
+
+	defer func() {
+		if v := recover(); v != "boom" {
+			t.Errorf("recovered %v, want the handler's panic to propagate", v)
+		}
+		if !strings.Contains(out.String(), "about to fail") {
+			t.Errorf("lines of the panicking request were dropped: %q", out.String())
+		}
+	}()
+	d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), func(rw http.ResponseWriter, r *http.Request) {
+		LoggerFrom(r.Context()).Println("about to fail")
+		panic("boom")
+	})
+}
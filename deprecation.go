@@ -0,0 +1,71 @@
+package y_middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DeprecationPolicy describes how a deprecated route is announced to clients.
+type DeprecationPolicy struct {
+	// Since is when the route was deprecated. When zero, the Deprecation header is "true".
+	Since time.Time
+	// Sunset is when the route stops working. When zero, no Sunset header is sent.
+	Sunset time.Time
+	// Link, if set, points to documentation about the deprecation.
+	Link string
+}
+
+// Deprecation is a middleware handler that announces deprecated routes to clients with the
+// Deprecation and Sunset (RFC 8594) response headers, while still serving them. Routes are
+// route templates as understood by RouteLabel. When Logger is set, every use of a deprecated
+// route is logged.
+type Deprecation struct {
+	Logger ALogger
+
+	routes   *RouteLabel
+	policies map[string]DeprecationPolicy
+}
+
+// NewDeprecation returns a new Deprecation instance with no deprecated routes.
+func NewDeprecation() *Deprecation {
+	return &Deprecation{
+		routes:   NewRouteLabel(),
+		policies: make(map[string]DeprecationPolicy),
+	}
+}
+
+// Deprecate marks the routes matching template as deprecated under policy. It must not be
+// called while requests are being served.
+func (d *Deprecation) Deprecate(template string, policy DeprecationPolicy) {
+	if _, ok := d.policies[template]; !ok {
+		d.routes.Add(template)
+	}
+	d.policies[template] = policy
+}
+
+func (d *Deprecation) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	template, ok := d.routes.Match(r.URL.Path)
+	if !ok {
+		next(rw, r)
+		return
+	}
+
+	policy := d.policies[template]
+	h := rw.Header()
+	if policy.Since.IsZero() {
+		h.Set("Deprecation", "true")
+	} else {
+		h.Set("Deprecation", "@"+strconv.FormatInt(policy.Since.Unix(), 10))
+	}
+	if !policy.Sunset.IsZero() {
+		h.Set("Sunset", policy.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if policy.Link != "" {
+		h.Add("Link", "<"+policy.Link+`>; rel="deprecation"`)
+	}
+	if d.Logger != nil {
+		d.Logger.Printf("deprecated route %s used: %s %s from %s", template, r.Method, r.URL.Path, clientIP(r))
+	}
+	next(rw, r)
+}
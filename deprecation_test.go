@@ -0,0 +1,59 @@
+package y_middleware
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDeprecation(t *testing.T) {
+	var logged bytes.Buffer
+	d := NewDeprecation()
+	d.Logger = log.New(&logged, "", 0)
+	d.Deprecate("/v1/users/:id", DeprecationPolicy{
+		Since:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Sunset: time.Date(2024, 7, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*3600)),
+		Link:   "https://example.com/migrate",
+	})
+	d.Deprecate("/v1/legacy", DeprecationPolicy{})
+
+	k := New(d)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	})
+	serve := func(path string) http.Header {
+		rec := httptest.NewRecorder()
+		k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: status = %d, want deprecated routes still served", path, rec.Code)
+		}
+		return rec.Header()
+	}
+
+	h := serve("/v1/users/42")
+	want := map[string]string{
+		"Deprecation": "@1704067200",
+		"Sunset":      "Mon, 01 Jul 2024 10:00:00 GMT",
+		"Link":        `<https://example.com/migrate>; rel="deprecation"`,
+	}
+	for name, value := range want {
+		if got := h.Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+	if want := "deprecated route /v1/users/:id used: GET /v1/users/42 from 192.0.2.1\n"; logged.String() != want {
+		t.Errorf("log = %q, want %q", logged.String(), want)
+	}
+
+	h = serve("/v1/legacy")
+	if h.Get("Deprecation") != "true" || h.Get("Sunset") != "" || h.Get("Link") != "" {
+		t.Errorf("headers = %v, want only Deprecation: true", h)
+	}
+
+	if h = serve("/v2/users/42"); h.Get("Deprecation") != "" {
+		t.Errorf("Deprecation = %q on a current route", h.Get("Deprecation"))
+	}
+}
@@ -0,0 +1,26 @@
+package y_middleware
+
+import "net/http"
+
+// DrainConnections is a middleware handler that asks clients to close their connection once
+// draining has started, by adding "Connection: close" to every response. Keep-alive clients
+// then reconnect, letting a load balancer move them to a healthy instance before this one
+// shuts down. Until draining starts it does nothing.
+type DrainConnections struct {
+	draining <-chan struct{}
+}
+
+// NewDrainConnections returns a new DrainConnections instance that starts draining when the
+// draining channel is closed.
+func NewDrainConnections(draining <-chan struct{}) *DrainConnections {
+	return &DrainConnections{draining: draining}
+}
+
+func (d *DrainConnections) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	select {
+	case <-d.draining:
+		rw.Header().Set("Connection", "close")
+	default:
+	}
+	next(rw, r)
+}
@@ -0,0 +1,26 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDrainConnections(t *testing.T) {
+	draining := make(chan struct{})
+	k := New(NewDrainConnections(draining))
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {})
+	connection := func() string {
+		rec := httptest.NewRecorder()
+		k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rec.Header().Get("Connection")
+	}
+
+	if got := connection(); got != "" {
+		t.Errorf("Connection = %q before draining, want none", got)
+	}
+	close(draining)
+	if got := connection(); got != "close" {
+		t.Errorf("Connection = %q while draining, want close", got)
+	}
+}
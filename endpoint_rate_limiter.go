@@ -0,0 +1,49 @@
+package y_middleware
+
+import "net/http"
+
+// EndpointRateLimiter is a middleware handler that applies a separate RateLimiter per route
+// template, so e.g. /login can be limited more strictly than /search. The template comes from
+// RouteLabel when it runs earlier in the stack, and otherwise from the templates registered with
+// Limit. Requests for other routes use the default limiter.
+type EndpointRateLimiter struct {
+	routes   *RouteLabel
+	limiters map[string]*RateLimiter
+	fallback *RateLimiter
+}
+
+// NewEndpointRateLimiter returns a new EndpointRateLimiter instance applying fallback to routes
+// without a limiter of their own. A nil fallback leaves those routes unlimited.
+func NewEndpointRateLimiter(fallback *RateLimiter) *EndpointRateLimiter {
+	return &EndpointRateLimiter{
+		routes:   NewRouteLabel(),
+		limiters: make(map[string]*RateLimiter),
+		fallback: fallback,
+	}
+}
+
+// Limit applies limiter to requests matching template. It must not be called while requests
+// are being served.
+func (e *EndpointRateLimiter) Limit(template string, limiter *RateLimiter) {
+	if _, ok := e.limiters[template]; !ok {
+		e.routes.Add(template)
+	}
+	e.limiters[template] = limiter
+}
+
+func (e *EndpointRateLimiter) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	template := RouteTemplate(r.Context())
+	if template == "" {
+		template, _ = e.routes.Match(r.URL.Path)
+	}
+
+	limiter, ok := e.limiters[template]
+	if !ok {
+		limiter = e.fallback
+	}
+	if limiter == nil {
+		next(rw, r)
+		return
+	}
+	limiter.ServeHTTP(rw, r, next)
+}
@@ -0,0 +1,63 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func endpointStatus(handler Handler, path string) int {
+	k := New(handler)
+	k.UseHandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec.Code
+}
+
+func TestEndpointRateLimiterPerRoute(t *testing.T) {
+	clock := newFakeClock()
+	e := NewEndpointRateLimiter(NewRateLimiter(1, 3).WithClock(clock))
+	e.Limit("/login", NewRateLimiter(1, 1).WithClock(clock))
+
+	if got := endpointStatus(e, "/login"); got != http.StatusOK {
+		t.Fatalf("first login: status = %d, want %d", got, http.StatusOK)
+	}
+	if got := endpointStatus(e, "/login"); got != http.StatusTooManyRequests {
+		t.Errorf("second login: status = %d, want %d", got, http.StatusTooManyRequests)
+	}
+	for i := 0; i < 3; i++ {
+		if got := endpointStatus(e, "/search"); got != http.StatusOK {
+			t.Errorf("search %d: status = %d, want %d", i, got, http.StatusOK)
+		}
+	}
+	if got := endpointStatus(e, "/search"); got != http.StatusTooManyRequests {
+		t.Errorf("search over the default burst: status = %d, want %d", got, http.StatusTooManyRequests)
+	}
+}
+
+func TestEndpointRateLimiterWithoutFallback(t *testing.T) {
+	e := NewEndpointRateLimiter(nil)
+	e.Limit("/login", NewRateLimiter(1, 1).WithClock(newFakeClock()))
+	for i := 0; i < 3; i++ {
+		if got := endpointStatus(e, "/search"); got != http.StatusOK {
+			t.Errorf("search %d: status = %d, want unlimited", i, got)
+		}
+	}
+}
+
+func TestEndpointRateLimiterUsesRouteLabel(t *testing.T) {
+	e := NewEndpointRateLimiter(nil)
+	e.Limit("/users/:id", NewRateLimiter(1, 1).WithClock(newFakeClock()))
+	k := New(NewRouteLabel("/users/:id"), e)
+	k.UseHandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	var codes []int
+	for _, path := range []string{"/users/1", "/users/2"} {
+		rec := httptest.NewRecorder()
+		k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		codes = append(codes, rec.Code)
+	}
+	if codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests {
+		t.Errorf("statuses = %v, want both users to share the /users/:id limiter", codes)
+	}
+}
@@ -0,0 +1,44 @@
+package y_middleware
+
+import (
+	"net/http"
+	"sync"
+)
+
+// ErrorPages is a middleware handler that renders a friendly body for 4xx and 5xx responses
+// that downstream handlers sent without one. Responses that already have a body, and
+// status codes without a configured page, pass through untouched.
+type ErrorPages struct {
+	mu    sync.RWMutex
+	pages map[int][]byte
+}
+
+// NewErrorPages returns a new ErrorPages instance with no pages configured.
+func NewErrorPages() *ErrorPages {
+	return &ErrorPages{
+		pages: make(map[int][]byte),
+	}
+}
+
+// Set configures the body rendered for responses with the given status code.
+func (e *ErrorPages) Set(code int, body []byte) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.pages[code] = body
+}
+
+func (e *ErrorPages) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	res := wrapResponseWriter(rw)
+	next(res, r)
+
+	if !res.Written() || res.Size() > 0 || res.Status() < http.StatusBadRequest {
+		return
+	}
+
+	e.mu.RLock()
+	body, ok := e.pages[res.Status()]
+	e.mu.RUnlock()
+	if ok {
+		res.Write(body)
+	}
+}
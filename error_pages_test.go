@@ -0,0 +1,55 @@
+package y_middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func serveErrorPages(h http.HandlerFunc) *httptest.ResponseRecorder {
+	e := NewErrorPages()
+	e.Set(http.StatusNotFound, []byte("page not found"))
+	k := New(e)
+	k.UseHandlerFunc(h)
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	return rec
+}
+
+func TestErrorPagesRendersEmptyErrors(t *testing.T) {
+	rec := serveErrorPages(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusNotFound)
+	})
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if rec.Body.String() != "page not found" {
+		t.Errorf("body = %q, want the configured page", rec.Body)
+	}
+}
+
+func TestErrorPagesPassesThrough(t *testing.T) {
+	tests := []struct {
+		name string
+		h    http.HandlerFunc
+		want string
+	}{
+		{"body already written", func(rw http.ResponseWriter, r *http.Request) {
+			rw.WriteHeader(http.StatusNotFound)
+			io.WriteString(rw, "custom")
+		}, "custom"},
+		{"no page configured", func(rw http.ResponseWriter, r *http.Request) {
+			rw.WriteHeader(http.StatusInternalServerError)
+		}, ""},
+		{"success", func(rw http.ResponseWriter, r *http.Request) {
+			rw.WriteHeader(http.StatusOK)
+		}, ""},
+		{"nothing written", func(rw http.ResponseWriter, r *http.Request) {}, ""},
+	}
+	for _, tt := range tests {
+		if rec := serveErrorPages(tt.h); rec.Body.String() != tt.want {
+			t.Errorf("%s: body = %q, want %q", tt.name, rec.Body, tt.want)
+		}
+	}
+}
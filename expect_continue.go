@@ -0,0 +1,27 @@
+package y_middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ExpectContinue is a middleware handler that decides whether uploads announced with
+// "Expect: 100-continue" are admitted before their body is read. Rejected requests get a
+// 417 Expectation Failed and the rest of the chain is skipped, so the client never sends the body.
+type ExpectContinue struct {
+	// Allow reports whether the upload may proceed, e.g. by checking r.ContentLength.
+	Allow func(r *http.Request) bool
+}
+
+// NewExpectContinue returns a new ExpectContinue instance admitting uploads allow returns true for.
+func NewExpectContinue(allow func(r *http.Request) bool) *ExpectContinue {
+	return &ExpectContinue{Allow: allow}
+}
+
+func (e *ExpectContinue) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !strings.EqualFold(r.Header.Get("Expect"), "100-continue") || e.Allow == nil || e.Allow(r) {
+		next(rw, r)
+		return
+	}
+	http.Error(rw, http.StatusText(http.StatusExpectationFailed), http.StatusExpectationFailed)
+}
@@ -0,0 +1,43 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExpectContinue(t *testing.T) {
+	e := NewExpectContinue(func(r *http.Request) bool {
+		return r.ContentLength <= 10
+	})
+	tests := []struct {
+		name   string
+		expect string
+		body   string
+		want   int
+	}{
+		{"small upload", "100-continue", "small", http.StatusOK},
+		{"large upload", "100-Continue", "much too large", http.StatusExpectationFailed},
+		{"no expectation", "", "much too large", http.StatusOK},
+	}
+	for _, tt := range tests {
+		served := false
+		k := New(e)
+		k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			served = true
+		})
+		req := httptest.NewRequest(http.MethodPut, "/upload", strings.NewReader(tt.body))
+		if tt.expect != "" {
+			req.Header.Set("Expect", tt.expect)
+		}
+		rec := httptest.NewRecorder()
+		k.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+		if served != (tt.want == http.StatusOK) {
+			t.Errorf("%s: served = %v", tt.name, served)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package y_middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// FaultInjection is a chaos testing middleware handler that fails a fraction of requests with
+// Status, skipping the rest of the chain, to exercise client retry and circuit-breaker behavior.
+type FaultInjection struct {
+	Status int
+	// Fraction is the share of matching requests, between 0 and 1, that fail.
+	Fraction float64
+	// Methods limits faults to these request methods. An empty list matches every method.
+	Methods []string
+	// PathPrefixes limits faults to paths with one of these prefixes. An empty list matches every path.
+	PathPrefixes []string
+
+	rng RNG
+}
+
+// NewFaultInjection returns a new FaultInjection instance failing fraction of all requests with status.
+func NewFaultInjection(status int, fraction float64) *FaultInjection {
+	return &FaultInjection{
+		Status:   status,
+		Fraction: fraction,
+	}
+}
+
+// WithRNG makes the FaultInjection draw its randomness from rng instead of the global source.
+func (f *FaultInjection) WithRNG(rng RNG) *FaultInjection {
+	f.rng = rng
+	return f
+}
+
+func (f *FaultInjection) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if f.matches(r) && rngOrDefault(f.rng).Float64() < f.Fraction {
+		http.Error(rw, http.StatusText(f.Status), f.Status)
+		return
+	}
+	next(rw, r)
+}
+
+func (f *FaultInjection) matches(r *http.Request) bool {
+	if len(f.Methods) > 0 && !containsMethod(f.Methods, r.Method) {
+		return false
+	}
+	if len(f.PathPrefixes) == 0 {
+		return true
+	}
+	for _, prefix := range f.PathPrefixes {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFaultInjection(t *testing.T) {
+	f := NewFaultInjection(http.StatusServiceUnavailable, 0.5).WithRNG(fixedRNG{f: 0.25})
+	f.Methods = []string{http.MethodPost}
+	f.PathPrefixes = []string{"/api/"}
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodPost, "/api/orders", http.StatusServiceUnavailable},
+		{http.MethodGet, "/api/orders", http.StatusOK},
+		{http.MethodPost, "/health", http.StatusOK},
+	}
+	for _, tt := range tests {
+		k := New(f)
+		k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {})
+		rec := httptest.NewRecorder()
+		k.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.path, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestFaultInjectionFraction(t *testing.T) {
+	f := NewFaultInjection(http.StatusInternalServerError, 0.3).WithRNG(NewSeededRNG(1))
+	failed := 0
+	for i := 0; i < 1000; i++ {
+		rec := httptest.NewRecorder()
+		f.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil), func(http.ResponseWriter, *http.Request) {})
+		if rec.Code == http.StatusInternalServerError {
+			failed++
+		}
+	}
+	if failed < 250 || failed > 350 {
+		t.Errorf("%d of 1000 requests failed, want about 300", failed)
+	}
+}
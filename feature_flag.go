@@ -0,0 +1,19 @@
+package y_middleware
+
+import "net/http"
+
+// FlagProvider reports whether a feature flag is enabled for a request, allowing per-request
+// targeting such as percentage rollouts or allowlisted users.
+type FlagProvider func(flag string, r *http.Request) bool
+
+// Flagged returns a Handler that runs h only when provider reports flag as enabled for the
+// request. Otherwise h is skipped and the next handler is called directly.
+func Flagged(flag string, h Handler, provider FlagProvider) Handler {
+	return HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		if provider(flag, r) {
+			h.ServeHTTP(rw, r, next)
+			return
+		}
+		next(rw, r)
+	})
+}
@@ -0,0 +1,43 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFlagged(t *testing.T) {
+	var gotFlag string
+	provider := func(flag string, r *http.Request) bool {
+		gotFlag = flag
+		return r.Header.Get("X-Beta") == "1"
+	}
+	marker := HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		rw.Header().Set("X-New-Checkout", "1")
+		next(rw, r)
+	})
+
+	k := New(Flagged("new-checkout", marker, provider))
+	served := 0
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		served++
+	})
+
+	for _, beta := range []bool{true, false} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if beta {
+			req.Header.Set("X-Beta", "1")
+		}
+		rec := httptest.NewRecorder()
+		k.ServeHTTP(rec, req)
+		if got := rec.Header().Get("X-New-Checkout") == "1"; got != beta {
+			t.Errorf("flag enabled %v: handler ran = %v", beta, got)
+		}
+	}
+	if served != 2 {
+		t.Errorf("next was called %d times, want 2", served)
+	}
+	if gotFlag != "new-checkout" {
+		t.Errorf("provider got flag %q, want new-checkout", gotFlag)
+	}
+}
@@ -0,0 +1,9 @@
+module github.com/YusufSert/y_middleware
+
+go 1.21
+
+require (
+	golang.org/x/sync v0.8.0
+	golang.org/x/sys v0.24.0
+	golang.org/x/text v0.17.0
+)
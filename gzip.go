@@ -0,0 +1,152 @@
+package y_middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Compressor produces the compressed writers for one content coding. Gzip supports gzip out of
+// the box; other codings, such as the one in the brotli subpackage, are added with Prefer.
+type Compressor interface {
+	// Encoding returns the Content-Encoding token of the coding, e.g. "gzip".
+	Encoding() string
+	// NewWriter returns a writer compressing into w. If it has a Flush() error method, it is
+	// called when the handler flushes.
+	NewWriter(w io.Writer) io.WriteCloser
+}
+
+// Gzip is a middleware handler that compresses responses with the best content coding the
+// client accepts, going by the Accept-Encoding qvalues. Among codings with the same quality,
+// the most preferred one wins. Responses that already have a Content-Encoding, and responses
+// without a body, are sent as they are.
+type Gzip struct {
+	compressors []Compressor
+}
+
+// NewGzip returns a new Gzip instance compressing with gzip at level.
+func NewGzip(level int) *Gzip {
+	return &Gzip{compressors: []Compressor{newGzipCompressor(level)}}
+}
+
+// Prefer adds c to the supported codings, ahead of the ones already supported. It must not be
+// called while requests are being served.
+func (g *Gzip) Prefer(c Compressor) *Gzip {
+	g.compressors = append([]Compressor{c}, g.compressors...)
+	return g
+}
+
+func (g *Gzip) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	addVary(rw.Header(), "Accept-Encoding")
+
+	c := g.choose(r.Header.Get("Accept-Encoding"))
+	if c == nil || r.Method == http.MethodHead {
+		next(rw, r)
+		return
+	}
+
+	cw := &compressResponseWriter{ResponseWriter: wrapResponseWriter(rw), compressor: c}
+	cw.Before(func(res ResponseWriter) {
+		// The hook stays registered on the shared writer after Gzip returns; responses
+		// written further up the chain, e.g. by Recovery, must go out uncompressed.
+		if cw.done {
+			return
+		}
+		h := res.Header()
+		if h.Get("Content-Encoding") != "" || !bodyAllowedForStatus(res.Status()) {
+			return
+		}
+		h.Set("Content-Encoding", c.Encoding())
+		h.Del("Content-Length")
+		cw.w = c.NewWriter(cw.ResponseWriter)
+	})
+	defer cw.close()
+	next(cw, r)
+}
+
+func (g *Gzip) choose(accept string) Compressor {
+	var best Compressor
+	bestQ := 0.0
+	for _, c := range g.compressors {
+		if q, ok := encodingQuality(accept, c.Encoding()); ok && q > bestQ {
+			best, bestQ = c, q
+		}
+	}
+	return best
+}
+
+// compressResponseWriter sends the body through a compressed writer once the headers chose one.
+type compressResponseWriter struct {
+	ResponseWriter
+	compressor Compressor
+	w          io.WriteCloser
+	done       bool
+}
+
+func (cw *compressResponseWriter) Write(p []byte) (int, error) {
+	if !cw.Written() {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.w == nil {
+		return cw.ResponseWriter.Write(p)
+	}
+	return cw.w.Write(p)
+}
+
+func (cw *compressResponseWriter) Flush() {
+	if flusher, ok := cw.w.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	cw.ResponseWriter.Flush()
+}
+
+func (cw *compressResponseWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+func (cw *compressResponseWriter) close() {
+	cw.done = true
+	if cw.w != nil {
+		cw.w.Close()
+	}
+}
+
+// gzipCompressor is the built in gzip Compressor. It pools its writers.
+type gzipCompressor struct {
+	level int
+	pool  sync.Pool
+}
+
+func newGzipCompressor(level int) *gzipCompressor {
+	return &gzipCompressor{level: level}
+}
+
+func (c *gzipCompressor) Encoding() string {
+	return "gzip"
+}
+
+func (c *gzipCompressor) NewWriter(w io.Writer) io.WriteCloser {
+	gz, ok := c.pool.Get().(*gzip.Writer)
+	if ok {
+		gz.Reset(w)
+	} else {
+		var err error
+		if gz, err = gzip.NewWriterLevel(w, c.level); err != nil {
+			gz = gzip.NewWriter(w)
+		}
+	}
+	return &pooledGzipWriter{Writer: gz, pool: &c.pool}
+}
+
+// pooledGzipWriter returns its gzip.Writer to the pool when closed.
+type pooledGzipWriter struct {
+	*gzip.Writer
+	pool *sync.Pool
+}
+
+func (w *pooledGzipWriter) Close() error {
+	err := w.Writer.Close()
+	w.pool.Put(w.Writer)
+	return err
+}
@@ -0,0 +1,106 @@
+package y_middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGzipCompressesResponse(t *testing.T) {
+	k := New(NewGzip(gzip.DefaultCompression))
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Length", "11")
+		io.WriteString(rw, "hello world")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	if got := rec.Header().Get("Content-Length"); got != "" {
+		t.Errorf("Content-Length = %q, want it removed", got)
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(zr)
+	if string(body) != "hello world" {
+		t.Errorf("body = %q, want %q", body, "hello world")
+	}
+}
+
+func TestGzipSkipsClientsWithoutGzip(t *testing.T) {
+	k := New(NewGzip(gzip.DefaultCompression))
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		io.WriteString(rw, "plain")
+	})
+
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding = %q, want none", got)
+	}
+	if rec.Body.String() != "plain" {
+		t.Errorf("body = %q, want %q", rec.Body.String(), "plain")
+	}
+	if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+		t.Errorf("Vary = %q, want Accept-Encoding", got)
+	}
+}
+
+func TestGzipDoesNotCompressRecoveryResponse(t *testing.T) {
+	rec := NewRecovery()
+	rec.Logger = log.New(io.Discard, "", 0)
+	k := New(rec, NewGzip(gzip.DefaultCompression))
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	res := httptest.NewRecorder()
+	k.ServeHTTP(res, req)
+
+	if res.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", res.Code)
+	}
+	if got := res.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding = %q on a plain recovery body", got)
+	}
+}
+
+func TestGzipDoesNotCompressOuterResponse(t *testing.T) {
+	outer := HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		next(rw, r)
+		if !rw.(ResponseWriter).Written() {
+			http.Error(rw, "not found", http.StatusNotFound)
+		}
+	})
+	k := New(outer, NewGzip(gzip.DefaultCompression))
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	res := httptest.NewRecorder()
+	k.ServeHTTP(res, req)
+
+	if res.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", res.Code)
+	}
+	if got := res.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding = %q on a plain body", got)
+	}
+	if !strings.Contains(res.Body.String(), "not found") {
+		t.Errorf("body = %q", res.Body.String())
+	}
+}
@@ -0,0 +1,119 @@
+package y_middleware
+
+import (
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var hmacSecret = []byte("shared secret")
+
+func signedRequest(method, path, body string, ts time.Time, secret []byte) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	req.Header.Set("X-Timestamp", timestamp)
+	req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(hmacSignature(secret, method, req.URL.RequestURI(), timestamp, []byte(body))))
+	return req
+}
+
+func serveHMAC(h *HMACVerify, req *http.Request) (*httptest.ResponseRecorder, string) {
+	var body string
+	served := false
+	k := New(h)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		served = true
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+	})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, req)
+	if !served {
+		body = "<not served>"
+	}
+	return rec, body
+}
+
+func TestHMACVerifyValidSignature(t *testing.T) {
+	clock := newFakeClock()
+	h := NewHMACVerify(hmacSecret).WithClock(clock)
+
+	rec, body := serveHMAC(h, signedRequest(http.MethodPost, "/hooks", `{"event":"push"}`, clock.Now(), hmacSecret))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if body != `{"event":"push"}` {
+		t.Errorf("handler read body %q, want the verified body", body)
+	}
+
+	if rec, _ := serveHMAC(h, signedRequest(http.MethodPost, "/orders?amount=10&currency=EUR", "", clock.Now(), hmacSecret)); rec.Code != http.StatusOK {
+		t.Errorf("status = %d for a signed query, want %d", rec.Code, http.StatusOK)
+	}
+
+	// The "sha256=" prefix is optional.
+	req := signedRequest(http.MethodPost, "/hooks", "x", clock.Now(), hmacSecret)
+	req.Header.Set("X-Signature", strings.TrimPrefix(req.Header.Get("X-Signature"), "sha256="))
+	if rec, _ := serveHMAC(h, req); rec.Code != http.StatusOK {
+		t.Errorf("status = %d without the sha256= prefix, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestHMACVerifyRejectsInvalidRequests(t *testing.T) {
+	clock := newFakeClock()
+	now := clock.Now()
+
+	wrongBody := signedRequest(http.MethodPost, "/hooks", "original", now, hmacSecret)
+	wrongBody.Body = io.NopCloser(strings.NewReader("tampered"))
+	wrongPath := signedRequest(http.MethodPost, "/hooks", "x", now, hmacSecret)
+	wrongPath.URL.Path = "/admin"
+	wrongQuery := signedRequest(http.MethodPost, "/orders?amount=10", "x", now, hmacSecret)
+	wrongQuery.URL.RawQuery = "amount=10000"
+	wrongMethod := signedRequest(http.MethodPost, "/hooks", "", now, hmacSecret)
+	wrongMethod.Method = http.MethodDelete
+	noSignature := signedRequest(http.MethodPost, "/hooks", "x", now, hmacSecret)
+	noSignature.Header.Del("X-Signature")
+	noTimestamp := signedRequest(http.MethodPost, "/hooks", "x", now, hmacSecret)
+	noTimestamp.Header.Del("X-Timestamp")
+	notHex := signedRequest(http.MethodPost, "/hooks", "x", now, hmacSecret)
+	notHex.Header.Set("X-Signature", "sha256=zz")
+
+	tests := []struct {
+		name string
+		req  *http.Request
+	}{
+		{"wrong secret", signedRequest(http.MethodPost, "/hooks", "x", now, []byte("other"))},
+		{"tampered body", wrongBody},
+		{"tampered path", wrongPath},
+		{"tampered query", wrongQuery},
+		{"tampered method", wrongMethod},
+		{"missing signature", noSignature},
+		{"missing timestamp", noTimestamp},
+		{"signature not hex", notHex},
+		{"stale timestamp", signedRequest(http.MethodPost, "/hooks", "x", now.Add(-6*time.Minute), hmacSecret)},
+		{"future timestamp", signedRequest(http.MethodPost, "/hooks", "x", now.Add(6*time.Minute), hmacSecret)},
+	}
+	for _, tt := range tests {
+		rec, body := serveHMAC(NewHMACVerify(hmacSecret).WithClock(clock), tt.req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, http.StatusUnauthorized)
+		}
+		if body != "<not served>" {
+			t.Errorf("%s: handler was served", tt.name)
+		}
+	}
+}
+
+func TestHMACVerifyBodyTooLarge(t *testing.T) {
+	clock := newFakeClock()
+	h := NewHMACVerify(hmacSecret).WithClock(clock)
+	h.MaxBodySize = 4
+
+	rec, _ := serveHMAC(h, signedRequest(http.MethodPost, "/hooks", "too large", clock.Now(), hmacSecret))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+}
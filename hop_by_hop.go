@@ -0,0 +1,58 @@
+package y_middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// hopByHopHeaders are the connection-specific headers an intermediary must not forward,
+// as listed in RFC 7230 section 6.1 and RFC 2616 section 13.5.1.
+var hopByHopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailers",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// StripHopByHop is a middleware handler that removes hop-by-hop headers, including any
+// header named in the Connection header, from the request before calling next.
+// It can do the same to the response when Kudret sits in front of a reverse proxy.
+type StripHopByHop struct {
+	// Response also strips hop-by-hop headers from the response.
+	Response bool
+}
+
+// NewStripHopByHop returns a new StripHopByHop instance that only strips request headers.
+func NewStripHopByHop() *StripHopByHop {
+	return &StripHopByHop{}
+}
+
+func (s *StripHopByHop) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	removeHopByHopHeaders(r.Header)
+
+	if s.Response {
+		res := wrapResponseWriter(rw)
+		res.Before(func(ResponseWriter) {
+			removeHopByHopHeaders(res.Header())
+		})
+		rw = res
+	}
+	next(rw, r)
+}
+
+func removeHopByHopHeaders(h http.Header) {
+	for _, v := range h["Connection"] {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				h.Del(name)
+			}
+		}
+	}
+	for _, name := range hopByHopHeaders {
+		h.Del(name)
+	}
+}
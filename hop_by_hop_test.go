@@ -0,0 +1,61 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStripHopByHopRequest(t *testing.T) {
+	var got http.Header
+	k := New(NewStripHopByHop())
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		rw.Header().Set("Keep-Alive", "timeout=5")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Connection", "keep-alive, X-Custom-Hop")
+	req.Header.Set("Keep-Alive", "timeout=5")
+	req.Header.Set("Proxy-Authorization", "Basic secret")
+	req.Header.Set("X-Custom-Hop", "1")
+	req.Header.Set("X-End-To-End", "1")
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, req)
+
+	for _, name := range []string{"Connection", "Keep-Alive", "Proxy-Authorization", "X-Custom-Hop"} {
+		if v := got.Get(name); v != "" {
+			t.Errorf("request header %s = %q, want it removed", name, v)
+		}
+	}
+	if got.Get("X-End-To-End") != "1" {
+		t.Error("end-to-end request header was removed")
+	}
+	if rec.Header().Get("Keep-Alive") == "" {
+		t.Error("response headers were stripped although Response is false")
+	}
+}
+
+func TestStripHopByHopResponse(t *testing.T) {
+	s := NewStripHopByHop()
+	s.Response = true
+	k := New(s)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Connection", "X-Backend-Hop")
+		rw.Header().Set("X-Backend-Hop", "1")
+		rw.Header().Set("Upgrade", "h2c")
+		rw.Header().Set("Content-Type", "text/plain")
+		rw.WriteHeader(http.StatusOK)
+	})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	for _, name := range []string{"Connection", "X-Backend-Hop", "Upgrade"} {
+		if v := rec.Header().Get(name); v != "" {
+			t.Errorf("response header %s = %q, want it removed", name, v)
+		}
+	}
+	if rec.Header().Get("Content-Type") != "text/plain" {
+		t.Error("end-to-end response header was removed")
+	}
+}
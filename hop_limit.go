@@ -0,0 +1,70 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// DefaultHopHeader is the header HopLimit counts hops in by default.
+	DefaultHopHeader = "X-Request-Hops"
+	// DefaultMaxHops is the number of hops HopLimit allows by default.
+	DefaultMaxHops = 10
+)
+
+type hopsKey struct{}
+
+type hops struct {
+	header string
+	n      int
+}
+
+// HopLimit is a middleware handler that protects a service mesh against request loops. Every
+// service increments the hop count carried in Header, and requests whose count goes past MaxHops
+// get a 508 Loop Detected and are not served. Malformed counts get a 400. Handlers pass the
+// incremented count on to the services they call with InjectHops.
+type HopLimit struct {
+	Header  string
+	MaxHops int
+}
+
+// NewHopLimit returns a new HopLimit instance allowing maxHops hops in the X-Request-Hops header.
+func NewHopLimit(maxHops int) *HopLimit {
+	return &HopLimit{
+		Header:  DefaultHopHeader,
+		MaxHops: maxHops,
+	}
+}
+
+func (h *HopLimit) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	n := 0
+	if v := r.Header.Get(h.Header); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		n = parsed
+	}
+	n++
+	if n > h.MaxHops {
+		http.Error(rw, http.StatusText(http.StatusLoopDetected), http.StatusLoopDetected)
+		return
+	}
+	next(rw, r.WithContext(context.WithValue(r.Context(), hopsKey{}, hops{header: h.Header, n: n})))
+}
+
+// Hops returns the hop count of the request including the current service, or zero without a
+// HopLimit in the chain.
+func Hops(ctx context.Context) int {
+	h, _ := ctx.Value(hopsKey{}).(hops)
+	return h.n
+}
+
+// InjectHops sets the hop count HopLimit computed on an outbound request.
+func InjectHops(ctx context.Context, out *http.Request) {
+	if h, ok := ctx.Value(hopsKey{}).(hops); ok {
+		out.Header.Set(h.header, strconv.Itoa(h.n))
+	}
+}
@@ -0,0 +1,53 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHopLimit(t *testing.T) {
+	var hops int
+	var injected string
+	k := New(NewHopLimit(3))
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		hops = Hops(r.Context())
+		out := httptest.NewRequest(http.MethodGet, "http://upstream/", nil)
+		InjectHops(r.Context(), out)
+		injected = out.Header.Get(DefaultHopHeader)
+	})
+
+	tests := []struct {
+		header   string
+		status   int
+		hops     int
+		injected string
+	}{
+		{"", http.StatusOK, 1, "1"},
+		{"2", http.StatusOK, 3, "3"},
+		{"3", http.StatusLoopDetected, 0, ""},
+		{"many", http.StatusBadRequest, 0, ""},
+		{"-1", http.StatusBadRequest, 0, ""},
+	}
+	for _, tt := range tests {
+		hops, injected = 0, ""
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.header != "" {
+			r.Header.Set(DefaultHopHeader, tt.header)
+		}
+		rec := httptest.NewRecorder()
+		k.ServeHTTP(rec, r)
+		if rec.Code != tt.status || hops != tt.hops || injected != tt.injected {
+			t.Errorf("%q: got %d, Hops %d, injected %q, want %d, %d, %q", tt.header, rec.Code, hops, injected, tt.status, tt.hops, tt.injected)
+		}
+	}
+}
+
+func TestHopsWithoutHopLimit(t *testing.T) {
+	out := httptest.NewRequest(http.MethodGet, "http://upstream/", nil)
+	InjectHops(context.Background(), out)
+	if Hops(context.Background()) != 0 || out.Header.Get(DefaultHopHeader) != "" {
+		t.Error("hops reported or injected without a HopLimit in the chain")
+	}
+}
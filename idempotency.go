@@ -0,0 +1,247 @@
+package y_middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultIdempotencyTTL is how long Idempotency keeps responses by default.
+	DefaultIdempotencyTTL = 24 * time.Hour
+	// DefaultIdempotencyMaxBodySize is the largest request body Idempotency reads by default.
+	DefaultIdempotencyMaxBodySize = 1 << 20
+	// DefaultIdempotencyMaxResponseSize is the largest response body Idempotency records by default.
+	DefaultIdempotencyMaxResponseSize = 1 << 20
+)
+
+// IdempotentResponse is a response recorded by Idempotency for replay.
+type IdempotentResponse struct {
+	// Fingerprint identifies the request that produced the response.
+	Fingerprint string
+	Status      int
+	Header      http.Header
+	Body        []byte
+}
+
+// IdempotencyStore is the cache Idempotency records responses in. Implementations must be
+// safe for concurrent use.
+type IdempotencyStore interface {
+	// Get returns the response recorded for key, if it has not expired.
+	Get(key string) (*IdempotentResponse, bool)
+	// Reserve claims key for a request in progress for at most ttl. It must be atomic: it
+	// reports false if key is already claimed or has a response recorded.
+	Reserve(key string, ttl time.Duration) bool
+	// Set records res for key for the duration of ttl, replacing its reservation.
+	Set(key string, res *IdempotentResponse, ttl time.Duration)
+	// Release drops the reservation of key without recording a response.
+	Release(key string)
+}
+
+// Idempotency is a middleware handler that makes requests carrying an Idempotency-Key header
+// safe to retry. The first response for a key is recorded and replayed for later requests with
+// the same key without calling next. Reusing a key for a different request gets a 409, and so
+// does a request whose key is still in use by a request in progress.
+//
+// Request bodies larger than MaxBodySize get a 413. Responses larger than MaxResponseSize,
+// server errors and streamed responses, see DisableBuffering, are sent as they are and not
+// recorded, so the key can be used again.
+type Idempotency struct {
+	// Methods are the request methods idempotency keys are honored for. Defaults to POST.
+	Methods []string
+	// TTL is how long a recorded response is replayed for.
+	TTL   time.Duration
+	Store IdempotencyStore
+	// MaxBodySize is the largest request body read to fingerprint the request. Zero means no limit.
+	MaxBodySize int64
+	// MaxResponseSize is the largest response body recorded. Zero means no limit.
+	MaxResponseSize int
+}
+
+// NewIdempotency returns a new Idempotency instance recording POST responses in store.
+// A nil store uses an in-memory one.
+func NewIdempotency(store IdempotencyStore) *Idempotency {
+	if store == nil {
+		store = NewIdempotencyMemoryStore()
+	}
+	return &Idempotency{
+		Methods:         []string{http.MethodPost},
+		TTL:             DefaultIdempotencyTTL,
+		Store:           store,
+		MaxBodySize:     DefaultIdempotencyMaxBodySize,
+		MaxResponseSize: DefaultIdempotencyMaxResponseSize,
+	}
+}
+
+func (i *Idempotency) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	key := r.Header.Get("Idempotency-Key")
+	if key == "" || !containsMethod(i.Methods, r.Method) {
+		next(rw, r)
+		return
+	}
+
+	body, err := bufferBody(r, i.MaxBodySize)
+	if errors.Is(err, errBodyTooLarge) {
+		http.Error(rw, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	fingerprint := requestFingerprint(r, body)
+
+	if i.replay(rw, key, fingerprint) {
+		return
+	}
+	if !i.Store.Reserve(key, i.TTL) {
+		// The key was claimed since Get, either by a request still in progress or by one
+		// that has just finished.
+		if !i.replay(rw, key, fingerprint) {
+			http.Error(rw, "Idempotency-Key in use by a request in progress", http.StatusConflict)
+		}
+		return
+	}
+
+	recorded := false
+	defer func() {
+		if !recorded {
+			i.Store.Release(key)
+		}
+	}()
+
+	buf, r := newResponseBuffer(rw, r, i.MaxResponseSize)
+	next(buf, r)
+	if buf.Streamed() {
+		return
+	}
+	if buf.Status() < http.StatusInternalServerError {
+		i.Store.Set(key, &IdempotentResponse{
+			Fingerprint: fingerprint,
+			Status:      buf.Status(),
+			Header:      buf.Header().Clone(),
+			Body:        append([]byte(nil), buf.body.Bytes()...),
+		}, i.TTL)
+		recorded = true
+	}
+	buf.flush(rw)
+}
+
+// replay writes the response recorded for key, or a 409 if it was recorded for a different
+// request. It reports false if there is no response recorded for key.
+func (i *Idempotency) replay(rw http.ResponseWriter, key, fingerprint string) bool {
+	cached, ok := i.Store.Get(key)
+	if !ok {
+		return false
+	}
+	if cached.Fingerprint != fingerprint {
+		http.Error(rw, "Idempotency-Key reused for a different request", http.StatusConflict)
+		return true
+	}
+	h := rw.Header()
+	for k, v := range cached.Header {
+		h[k] = append([]string(nil), v...)
+	}
+	rw.WriteHeader(cached.Status)
+	rw.Write(cached.Body)
+	return true
+}
+
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// idempotencyEntry is a recorded response, or a reservation if res is nil.
+type idempotencyEntry struct {
+	res     *IdempotentResponse
+	expires time.Time
+}
+
+type idempotencyMemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]idempotencyEntry
+	nextSweep time.Time
+}
+
+// NewIdempotencyMemoryStore returns an IdempotencyStore that keeps responses in memory.
+// Expired responses are dropped when they are next looked up, and swept out as new ones
+// are added.
+func NewIdempotencyMemoryStore() IdempotencyStore {
+	return &idempotencyMemoryStore{
+		entries: make(map[string]idempotencyEntry),
+	}
+}
+
+func (s *idempotencyMemoryStore) Get(key string) (*IdempotentResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok || e.res == nil {
+		return nil, false
+	}
+	if time.Now().After(e.expires) {
+		delete(s.entries, key)
+		return nil, false
+	}
+	return e.res, true
+}
+
+func (s *idempotencyMemoryStore) Reserve(key string, ttl time.Duration) bool {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now, ttl)
+	if e, ok := s.entries[key]; ok && !now.After(e.expires) {
+		return false
+	}
+	s.entries[key] = idempotencyEntry{expires: now.Add(ttl)}
+	return true
+}
+
+func (s *idempotencyMemoryStore) Set(key string, res *IdempotentResponse, ttl time.Duration) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now, ttl)
+	s.entries[key] = idempotencyEntry{res: res, expires: now.Add(ttl)}
+}
+
+func (s *idempotencyMemoryStore) Release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok && e.res == nil {
+		delete(s.entries, key)
+	}
+}
+
+// sweep drops expired entries, at most once a minute or every ttl if that is shorter.
+func (s *idempotencyMemoryStore) sweep(now time.Time, ttl time.Duration) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	for key, e := range s.entries {
+		if now.After(e.expires) {
+			delete(s.entries, key)
+		}
+	}
+	s.nextSweep = now.Add(min(ttl, time.Minute))
+}
@@ -0,0 +1,145 @@
+package y_middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func idempotentRequest(key, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	r.Header.Set("Idempotency-Key", key)
+	return r
+}
+
+func TestIdempotencyReplaysResponse(t *testing.T) {
+	i := NewIdempotency(nil)
+	n := 0
+	handler := func(rw http.ResponseWriter, r *http.Request) {
+		n++
+		rw.Header().Set("Location", "/orders/1")
+		rw.WriteHeader(http.StatusCreated)
+		io.WriteString(rw, "created")
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		rec := httptest.NewRecorder()
+		i.ServeHTTP(rec, idempotentRequest("k1", "order"), handler)
+		if rec.Code != http.StatusCreated || rec.Body.String() != "created" || rec.Header().Get("Location") != "/orders/1" {
+			t.Errorf("attempt %d: %d %q %v", attempt, rec.Code, rec.Body.String(), rec.Header())
+		}
+	}
+	if n != 1 {
+		t.Errorf("handler ran %d times, want once", n)
+	}
+
+	rec := httptest.NewRecorder()
+	i.ServeHTTP(rec, idempotentRequest("k1", "another order"), handler)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("reused key status = %d, want 409", rec.Code)
+	}
+}
+
+func TestIdempotencyConcurrentRequestsRunOnce(t *testing.T) {
+	i := NewIdempotency(nil)
+	var calls int32
+	release := make(chan struct{})
+	handler := func(rw http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		rw.WriteHeader(http.StatusCreated)
+	}
+
+	const n = 5
+	codes := make([]int, n)
+	var wg sync.WaitGroup
+	for j := 0; j < n; j++ {
+		wg.Add(1)
+		go func(j int) {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			i.ServeHTTP(rec, idempotentRequest("k", "order"), handler)
+			codes[j] = rec.Code
+		}(j)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want once", calls)
+	}
+	created, conflicts := 0, 0
+	for _, code := range codes {
+		switch code {
+		case http.StatusCreated:
+			created++
+		case http.StatusConflict:
+			conflicts++
+		}
+	}
+	if created != 1 || conflicts != n-1 {
+		t.Errorf("codes = %v, want one 201 and 409 for the rest", codes)
+	}
+}
+
+func TestIdempotencyDoesNotRecordFailures(t *testing.T) {
+	i := NewIdempotency(nil)
+	i.MaxResponseSize = 4
+	n := 0
+	respond := func(status int, body string) http.HandlerFunc {
+		return func(rw http.ResponseWriter, r *http.Request) {
+			n++
+			rw.WriteHeader(status)
+			io.WriteString(rw, body)
+		}
+	}
+
+	i.ServeHTTP(httptest.NewRecorder(), idempotentRequest("k", "x"), respond(http.StatusInternalServerError, ""))
+	i.ServeHTTP(httptest.NewRecorder(), idempotentRequest("k", "x"), respond(http.StatusOK, "too large"))
+	func() {
+		defer func() { recover() }()
+		i.ServeHTTP(httptest.NewRecorder(), idempotentRequest("k", "x"), func(http.ResponseWriter, *http.Request) {
+			n++
+			panic("boom")
+		})
+	}()
+	rec := httptest.NewRecorder()
+	i.ServeHTTP(rec, idempotentRequest("k", "x"), respond(http.StatusOK, "ok"))
+
+	if n != 4 || rec.Body.String() != "ok" {
+		t.Errorf("handler ran %d times with last body %q, want the key reusable after each failure", n, rec.Body.String())
+	}
+}
+
+func TestIdempotencyLimitsBodySize(t *testing.T) {
+	i := NewIdempotency(nil)
+	i.MaxBodySize = 4
+	rec := httptest.NewRecorder()
+	i.ServeHTTP(rec, idempotentRequest("k", "too large"), func(http.ResponseWriter, *http.Request) {
+		t.Error("handler ran for an oversized body")
+	})
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want 413", rec.Code)
+	}
+}
+
+func TestIdempotencyMemoryStoreSweepsExpired(t *testing.T) {
+	store := NewIdempotencyMemoryStore().(*idempotencyMemoryStore)
+	for _, key := range []string{"a", "b", "c"} {
+		store.Set(key, &IdempotentResponse{}, time.Millisecond)
+	}
+	time.Sleep(5 * time.Millisecond)
+	store.Set("d", &IdempotentResponse{}, time.Hour)
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.entries) != 1 {
+		t.Errorf("%d entries left, want the expired ones swept", len(store.entries))
+	}
+}
@@ -0,0 +1,28 @@
+package y_middleware
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// InFlight is a middleware handler that counts the requests currently being served by the rest
+// of the chain.
+type InFlight struct {
+	n int64
+}
+
+// NewInFlight returns a new InFlight instance.
+func NewInFlight() *InFlight {
+	return &InFlight{}
+}
+
+// Count returns the number of requests currently in flight.
+func (f *InFlight) Count() int64 {
+	return atomic.LoadInt64(&f.n)
+}
+
+func (f *InFlight) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	atomic.AddInt64(&f.n, 1)
+	defer atomic.AddInt64(&f.n, -1)
+	next(rw, r)
+}
@@ -0,0 +1,24 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestInFlightCount(t *testing.T) {
+	f := NewInFlight()
+	var during int64
+	k := New(f)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		during = f.Count()
+	})
+	k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if during != 1 {
+		t.Errorf("Count() = %d while serving, want 1", during)
+	}
+	if f.Count() != 0 {
+		t.Errorf("Count() = %d after serving, want 0", f.Count())
+	}
+}
@@ -0,0 +1,53 @@
+package y_middleware
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// DefaultJSONBodyLimit is the largest JSON request body JSONBodyLimit allows by default.
+const DefaultJSONBodyLimit = 1 << 20
+
+// JSONBodyLimit is a middleware handler for JSON APIs that checks POST, PUT and PATCH requests
+// before handlers decode them. Bodies that are not application/json, or another +json media
+// type, get a 415, and bodies whose Content-Length exceeds MaxSize get a 413; either way the
+// rest of the chain is skipped. Bodies of unknown length are cut off at MaxSize, so reading past
+// it fails with an *http.MaxBytesError. ConfigOverride can change MaxSize for a request with
+// OverrideJSONBodyLimit.
+type JSONBodyLimit struct {
+	MaxSize int64
+}
+
+// NewJSONBodyLimit returns a new JSONBodyLimit instance allowing JSON bodies up to maxSize bytes.
+func NewJSONBodyLimit(maxSize int64) *JSONBodyLimit {
+	return &JSONBodyLimit{MaxSize: maxSize}
+}
+
+func (j *JSONBodyLimit) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !methodHasBody(r.Method) || r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+		next(rw, r)
+		return
+	}
+
+	if !isJSONMediaType(r.Header.Get("Content-Type")) {
+		http.Error(rw, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+		return
+	}
+	maxSize := overrideInt64(r.Context(), OverrideJSONBodyLimit, j.MaxSize)
+	if r.ContentLength > maxSize {
+		http.Error(rw, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+	r.Body = http.MaxBytesReader(rw, r.Body, maxSize)
+	next(rw, r)
+}
+
+// isJSONMediaType reports whether contentType is application/json or a +json media type.
+func isJSONMediaType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
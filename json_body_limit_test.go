@@ -0,0 +1,84 @@
+package y_middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func serveJSONBodyLimit(j *JSONBodyLimit, r *http.Request) (int, error) {
+	var readErr error
+	k := New(j)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, r)
+	return rec.Code, readErr
+}
+
+func jsonRequest(method, contentType, body string) *http.Request {
+	r := httptest.NewRequest(method, "/", strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	return r
+}
+
+func TestJSONBodyLimit(t *testing.T) {
+	j := NewJSONBodyLimit(16)
+	tests := []struct {
+		name   string
+		r      *http.Request
+		status int
+	}{
+		{"json", jsonRequest(http.MethodPost, "application/json; charset=utf-8", `{"a":1}`), http.StatusOK},
+		{"+json", jsonRequest(http.MethodPatch, "application/merge-patch+json", `{"a":1}`), http.StatusOK},
+		{"form", jsonRequest(http.MethodPut, "application/x-www-form-urlencoded", "a=1"), http.StatusUnsupportedMediaType},
+		{"no content type", jsonRequest(http.MethodPost, "", `{"a":1}`), http.StatusUnsupportedMediaType},
+		{"too large", jsonRequest(http.MethodPost, "application/json", `{"a":"0123456789abcdef"}`), http.StatusRequestEntityTooLarge},
+		{"GET", jsonRequest(http.MethodGet, "text/plain", "ignored"), http.StatusOK},
+		{"empty POST", jsonRequest(http.MethodPost, "text/plain", ""), http.StatusOK},
+	}
+	for _, tt := range tests {
+		if status, _ := serveJSONBodyLimit(j, tt.r); status != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, status, tt.status)
+		}
+	}
+}
+
+func TestJSONBodyLimitUnknownLength(t *testing.T) {
+	r := jsonRequest(http.MethodPost, "application/json", `{"a":"0123456789abcdef"}`)
+	r.ContentLength = -1
+	_, err := serveJSONBodyLimit(NewJSONBodyLimit(16), r)
+	var tooLarge *http.MaxBytesError
+	if !errors.As(err, &tooLarge) || tooLarge.Limit != 16 {
+		t.Errorf("read err = %v, want an *http.MaxBytesError at 16 bytes", err)
+	}
+}
+
+func TestJSONBodyLimitOverride(t *testing.T) {
+	c := NewConfigOverride(netip.MustParsePrefix("192.0.2.0/24"))
+	j := NewJSONBodyLimit(16)
+	for _, tt := range []struct {
+		remoteAddr, limit string
+		status            int
+	}{
+		{"192.0.2.1:1234", "64", http.StatusOK},
+		{"192.0.2.1:1234", "-1", http.StatusRequestEntityTooLarge},
+		{"203.0.113.1:1234", "64", http.StatusRequestEntityTooLarge},
+	} {
+		r := jsonRequest(http.MethodPost, "application/json", `{"a":"0123456789abcdef"}`)
+		r.RemoteAddr = tt.remoteAddr
+		r.Header.Set("X-Override-JSON-Body-Limit", tt.limit)
+		k := New(c, j)
+		k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {})
+		rec := httptest.NewRecorder()
+		k.ServeHTTP(rec, r)
+		if rec.Code != tt.status {
+			t.Errorf("limit %s from %s: status = %d, want %d", tt.limit, tt.remoteAddr, rec.Code, tt.status)
+		}
+	}
+}
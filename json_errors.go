@@ -0,0 +1,96 @@
+package y_middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// maxJSONErrorMessage caps how much of a plain text error body becomes the envelope message.
+const maxJSONErrorMessage = 1 << 10
+
+// JSONErrors is a middleware handler that gives API clients a consistent error shape. Responses
+// with a 4xx or 5xx status and an empty or plain text body are rewritten into the JSON document
+//
+//	{"error":{"code":404,"message":"Not Found","request_id":"..."}}
+//
+// where the message is the original text, or the status text if there was none, and the
+// request ID is the one RequestID assigned. Error responses of any other type, such as JSON,
+// pass through untouched.
+type JSONErrors struct{}
+
+// NewJSONErrors returns a new JSONErrors instance.
+func NewJSONErrors() *JSONErrors {
+	return &JSONErrors{}
+}
+
+func (j *JSONErrors) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	ew := &jsonErrorWriter{ResponseWriter: wrapResponseWriter(rw)}
+	next(ew, r)
+	if !ew.wrapping {
+		return
+	}
+
+	message := strings.TrimSpace(ew.body.String())
+	if message == "" {
+		message = http.StatusText(ew.Status())
+	}
+	var envelope struct {
+		Error struct {
+			Code      int    `json:"code"`
+			Message   string `json:"message"`
+			RequestID string `json:"request_id,omitempty"`
+		} `json:"error"`
+	}
+	envelope.Error.Code = ew.Status()
+	envelope.Error.Message = message
+	envelope.Error.RequestID = RequestIDFrom(r.Context())
+	json.NewEncoder(ew.ResponseWriter).Encode(envelope)
+}
+
+// jsonErrorWriter holds back the plain text body of error responses.
+type jsonErrorWriter struct {
+	ResponseWriter
+	wrapping bool
+	body     bytes.Buffer
+}
+
+func (w *jsonErrorWriter) WriteHeader(code int) {
+	if !w.Written() && code >= http.StatusBadRequest && plainErrorBody(w.Header()) {
+		w.wrapping = true
+		h := w.Header()
+		h.Del("Content-Length")
+		h.Set("Content-Type", "application/json")
+		h.Set("X-Content-Type-Options", "nosniff")
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *jsonErrorWriter) Write(p []byte) (int, error) {
+	if !w.Written() {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.wrapping {
+		return w.ResponseWriter.Write(p)
+	}
+	if room := maxJSONErrorMessage - w.body.Len(); room > 0 {
+		w.body.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
+
+func (w *jsonErrorWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// plainErrorBody reports whether a response with header h has no body type or a plain text one.
+func plainErrorBody(h http.Header) bool {
+	contentType := h.Get("Content-Type")
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "text/plain"
+}
@@ -0,0 +1,77 @@
+package y_middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serveJSONErrors(h http.HandlerFunc) *httptest.ResponseRecorder {
+	k := New(NewRequestID(), NewJSONErrors())
+	k.UseHandlerFunc(h)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Request-Id", "req-1")
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestJSONErrorsWrapsPlainErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		h    http.HandlerFunc
+		want string
+	}{
+		{"http.Error", func(rw http.ResponseWriter, r *http.Request) {
+			http.Error(rw, "order not found", http.StatusNotFound)
+		}, `{"error":{"code":404,"message":"order not found","request_id":"req-1"}}`},
+		{"empty body", func(rw http.ResponseWriter, r *http.Request) {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+		}, `{"error":{"code":503,"message":"Service Unavailable","request_id":"req-1"}}`},
+	}
+	for _, tt := range tests {
+		rec := serveJSONErrors(tt.h)
+		if got := strings.TrimSpace(rec.Body.String()); got != tt.want {
+			t.Errorf("%s: body = %s, want %s", tt.name, got, tt.want)
+		}
+		if got := rec.Header().Get("Content-Type"); got != "application/json" {
+			t.Errorf("%s: Content-Type = %q, want application/json", tt.name, got)
+		}
+	}
+}
+
+func TestJSONErrorsPassesThrough(t *testing.T) {
+	tests := []struct {
+		name string
+		h    http.HandlerFunc
+		want string
+	}{
+		{"JSON error", func(rw http.ResponseWriter, r *http.Request) {
+			rw.Header().Set("Content-Type", "application/problem+json")
+			rw.WriteHeader(http.StatusBadRequest)
+			io.WriteString(rw, `{"title":"bad"}`)
+		}, `{"title":"bad"}`},
+		{"success", func(rw http.ResponseWriter, r *http.Request) {
+			io.WriteString(rw, "ok")
+		}, "ok"},
+	}
+	for _, tt := range tests {
+		if rec := serveJSONErrors(tt.h); rec.Body.String() != tt.want {
+			t.Errorf("%s: body = %q, want %q", tt.name, rec.Body, tt.want)
+		}
+	}
+}
+
+func TestJSONErrorsWithoutRequestID(t *testing.T) {
+	k := New(NewJSONErrors())
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusForbidden)
+	})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got, want := strings.TrimSpace(rec.Body.String()), `{"error":{"code":403,"message":"Forbidden"}}`; got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,58 @@
+package y_middleware
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+)
+
+type jsonNumberKey struct{}
+
+// JSONNumberMode is a middleware handler that chooses, per request, whether JSON numbers are
+// decoded as json.Number instead of float64, so large integers such as 64-bit IDs keep their
+// precision. When Header is set, requests can pick the mode with it set to "number" or "float";
+// others get Default. Leave Header empty unless every handler behind it accepts both
+// representations, since a client can otherwise change what they decode. DecodeJSON, the
+// JSONEncoder chosen by Negotiate and ValidateJSON validators implementing JSONContextValidator
+// honor the mode, read with UseJSONNumber.
+type JSONNumberMode struct {
+	Default bool
+	// Header is the request header that overrides Default for a single request. Empty, the
+	// default, disables the override.
+	Header string
+}
+
+// NewJSONNumberMode returns a new JSONNumberMode instance decoding every request with useNumber.
+func NewJSONNumberMode(useNumber bool) *JSONNumberMode {
+	return &JSONNumberMode{Default: useNumber}
+}
+
+func (m *JSONNumberMode) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	useNumber := m.Default
+	if m.Header != "" {
+		switch strings.ToLower(strings.TrimSpace(r.Header.Get(m.Header))) {
+		case "number":
+			useNumber = true
+		case "float":
+			useNumber = false
+		}
+	}
+	next(rw, r.WithContext(context.WithValue(r.Context(), jsonNumberKey{}, useNumber)))
+}
+
+// UseJSONNumber reports whether JSON numbers should be decoded as json.Number for the request.
+func UseJSONNumber(ctx context.Context) bool {
+	useNumber, _ := ctx.Value(jsonNumberKey{}).(bool)
+	return useNumber
+}
+
+// DecodeJSON decodes the JSON value in r into v, honoring the request's JSONNumberMode.
+func DecodeJSON(ctx context.Context, r io.Reader, v any) error {
+	dec := json.NewDecoder(r)
+	if UseJSONNumber(ctx) {
+		dec.UseNumber()
+	}
+	return dec.Decode(v)
+}
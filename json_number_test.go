@@ -0,0 +1,61 @@
+package y_middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func decodedNumber(t *testing.T, m *JSONNumberMode, header string) any {
+	t.Helper()
+	var got any
+	k := New(m)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var v map[string]any
+		if err := DecodeJSON(r.Context(), strings.NewReader(`{"id":9007199254740993}`), &v); err != nil {
+			t.Fatal(err)
+		}
+		got = v["id"]
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	if header != "" {
+		req.Header.Set("X-JSON-Numbers", header)
+	}
+	k.ServeHTTP(httptest.NewRecorder(), req)
+	return got
+}
+
+func TestJSONNumberModeDefault(t *testing.T) {
+	if got, ok := decodedNumber(t, NewJSONNumberMode(true), "").(json.Number); !ok || got != "9007199254740993" {
+		t.Errorf("id = %#v, want json.Number 9007199254740993", got)
+	}
+	if _, ok := decodedNumber(t, NewJSONNumberMode(false), "").(float64); !ok {
+		t.Error("id is not a float64 with useNumber false")
+	}
+}
+
+func TestJSONNumberModeIgnoresHeaderByDefault(t *testing.T) {
+	if _, ok := decodedNumber(t, NewJSONNumberMode(false), "number").(float64); !ok {
+		t.Error("X-JSON-Numbers changed the mode although Header is not set")
+	}
+	if _, ok := decodedNumber(t, NewJSONNumberMode(true), "float").(json.Number); !ok {
+		t.Error("X-JSON-Numbers changed the mode although Header is not set")
+	}
+}
+
+func TestJSONNumberModeHeaderOverride(t *testing.T) {
+	m := NewJSONNumberMode(false)
+	m.Header = "X-JSON-Numbers"
+	if _, ok := decodedNumber(t, m, "number").(json.Number); !ok {
+		t.Error(`id is not a json.Number with the header set to "number"`)
+	}
+
+	m = NewJSONNumberMode(true)
+	m.Header = "X-JSON-Numbers"
+	if _, ok := decodedNumber(t, m, "float").(float64); !ok {
+		t.Error(`id is not a float64 with the header set to "float"`)
+	}
+}
@@ -0,0 +1,447 @@
+package y_middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultJWTLeeway is how much clock skew JWT allows for when checking exp and nbf by default.
+const DefaultJWTLeeway = time.Minute
+
+type claimsKey struct{}
+
+// Errors returned by JWT verifiers and claim checks for tokens that are not valid.
+var (
+	ErrJWTMalformed        = errors.New("jwt: malformed token")
+	ErrJWTUnsupportedAlg   = errors.New("jwt: unsupported signing algorithm")
+	ErrJWTUnknownKey       = errors.New("jwt: unknown signing key")
+	ErrJWTInvalidSignature = errors.New("jwt: invalid signature")
+	ErrJWTExpired          = errors.New("jwt: token expired")
+	ErrJWTNotYetValid      = errors.New("jwt: token not valid yet")
+	ErrJWTInvalidClaims    = errors.New("jwt: invalid issuer or audience")
+)
+
+// Claims are the claims of a verified JWT, as decoded from its JSON payload.
+type Claims map[string]any
+
+// JWTVerifier checks the signature of a compact serialized JWT and returns its claims. It is
+// the extension point for plugging in a JWT library; NewJWTKeyVerifier provides one built on
+// the standard library. Verifiers report invalid tokens with the ErrJWT errors above, wrapped
+// or not; any other error means the token could not be checked, e.g. because its keys could
+// not be fetched.
+type JWTVerifier interface {
+	Verify(token string) (Claims, error)
+}
+
+// JWT is a middleware handler that authenticates requests with a JWT bearer token. The token
+// signature is checked by Verifier, then its exp and nbf claims, allowing for Leeway of clock
+// skew, and its iss and aud claims if Issuer or Audience are set. The claims of a valid token
+// are stored in the request context, where handlers read them with ClaimsFrom. Requests with a
+// missing or invalid token get a 401 and the rest of the chain is skipped. If the Verifier fails
+// with an error other than an ErrJWT one, the error is recorded with AddError and the request
+// gets a 500 instead.
+//
+// Authenticate lets JWT be used as one of the schemes of an AuthChain instead.
+type JWT struct {
+	Verifier JWTVerifier
+	Issuer   string
+	Audience string
+	Leeway   time.Duration
+
+	clock Clock
+}
+
+// NewJWT returns a new JWT instance checking token signatures with verifier.
+func NewJWT(verifier JWTVerifier) *JWT {
+	return &JWT{
+		Verifier: verifier,
+		Leeway:   DefaultJWTLeeway,
+	}
+}
+
+// WithClock makes the JWT read the time from c instead of the system clock.
+func (j *JWT) WithClock(c Clock) *JWT {
+	j.clock = c
+	return j
+}
+
+func (j *JWT) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	claims, ok, err := j.Authenticate(r)
+	if err != nil {
+		AddError(r.Context(), err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		rw.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	next(rw, r.WithContext(context.WithValue(r.Context(), claimsKey{}, claims)))
+}
+
+// Authenticate is an Authenticator returning the Claims of the request's bearer token, if it
+// carries a valid one. Verifier errors other than the ErrJWT ones are returned, so an AuthChain
+// stops there instead of trying its other schemes.
+func (j *JWT) Authenticate(r *http.Request) (any, bool, error) {
+	token, ok := bearerToken(r)
+	if !ok {
+		return nil, false, nil
+	}
+	claims, err := j.Verifier.Verify(token)
+	if err != nil {
+		if isInvalidJWT(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	if j.check(claims) != nil {
+		return nil, false, nil
+	}
+	return claims, true, nil
+}
+
+// isInvalidJWT reports whether err is one of the errors for tokens that are not valid.
+func isInvalidJWT(err error) bool {
+	for _, invalid := range []error{
+		ErrJWTMalformed, ErrJWTUnsupportedAlg, ErrJWTUnknownKey, ErrJWTInvalidSignature,
+		ErrJWTExpired, ErrJWTNotYetValid, ErrJWTInvalidClaims,
+	} {
+		if errors.Is(err, invalid) {
+			return true
+		}
+	}
+	return false
+}
+
+func (j *JWT) check(claims Claims) error {
+	now := clockOrDefault(j.clock).Now()
+	if exp, ok := claimTime(claims, "exp"); ok && !now.Before(exp.Add(j.Leeway)) {
+		return ErrJWTExpired
+	}
+	if nbf, ok := claimTime(claims, "nbf"); ok && now.Before(nbf.Add(-j.Leeway)) {
+		return ErrJWTNotYetValid
+	}
+	if j.Issuer != "" && claimString(claims, "iss") != j.Issuer {
+		return ErrJWTInvalidClaims
+	}
+	if j.Audience != "" {
+		for _, aud := range claimStrings(claims, "aud") {
+			if aud == j.Audience {
+				return nil
+			}
+		}
+		return ErrJWTInvalidClaims
+	}
+	return nil
+}
+
+// ClaimsFrom returns the claims of the token authenticated by JWT, either on its own or as part
+// of an AuthChain, and whether there are any.
+func ClaimsFrom(ctx context.Context) (Claims, bool) {
+	if claims, ok := ctx.Value(claimsKey{}).(Claims); ok {
+		return claims, true
+	}
+	principal, _ := PrincipalFrom(ctx)
+	claims, ok := principal.(Claims)
+	return claims, ok
+}
+
+// claimString returns the string claim name, or an empty string if there is none.
+func claimString(c Claims, name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+// claimStrings returns the claim name as a list of strings. A single string is returned as a
+// list of one, as allowed for "aud".
+func claimStrings(c Claims, name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		return []string{v}
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, s := range v {
+			if s, ok := s.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+// claimTime returns the NumericDate claim name, and whether it is present.
+func claimTime(c Claims, name string) (time.Time, bool) {
+	n, ok := c[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(n*float64(time.Second))), true
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// JWTKeyFunc returns the key to verify a token signed with alg by key ID kid with. HMAC keys are
+// []byte; others are *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey. It should return
+// ErrJWTUnknownKey or ErrJWTUnsupportedAlg for keys the token must not be accepted with, and
+// other errors only when the keys are unavailable.
+type JWTKeyFunc func(alg, kid string) (any, error)
+
+// JWTKey returns a JWTKeyFunc always returning key.
+func JWTKey(key any) JWTKeyFunc {
+	return func(string, string) (any, error) {
+		return key, nil
+	}
+}
+
+type jwtKeyVerifier struct {
+	keys JWTKeyFunc
+}
+
+// NewJWTKeyVerifier returns a JWTVerifier checking signatures with the keys returned by keys.
+// It supports the HS, RS, PS and ES families of algorithms and EdDSA; unsigned tokens are
+// always rejected.
+func NewJWTKeyVerifier(keys JWTKeyFunc) JWTVerifier {
+	return &jwtKeyVerifier{keys: keys}
+}
+
+func (v *jwtKeyVerifier) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrJWTMalformed
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrJWTMalformed
+	}
+	key, err := v.keys(header.Alg, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyJWTSignature(header.Alg, key, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, err
+	}
+	var claims Claims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func decodeJWTPart(part string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return ErrJWTMalformed
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return ErrJWTMalformed
+	}
+	return nil
+}
+
+func verifyJWTSignature(alg string, key any, signed string, sig []byte) error {
+	if alg == "EdDSA" {
+		pub, ok := key.(ed25519.PublicKey)
+		return jwtSignatureError(ok && ed25519.Verify(pub, []byte(signed), sig))
+	}
+
+	if len(alg) != 5 {
+		return ErrJWTUnsupportedAlg
+	}
+	var hash crypto.Hash
+	var curve string
+	switch alg[2:] {
+	case "256":
+		hash, curve = crypto.SHA256, "P-256"
+	case "384":
+		hash, curve = crypto.SHA384, "P-384"
+	case "512":
+		hash, curve = crypto.SHA512, "P-521"
+	default:
+		return ErrJWTUnsupportedAlg
+	}
+
+	switch alg[:2] {
+	case "HS":
+		secret, ok := key.([]byte)
+		if !ok {
+			return ErrJWTInvalidSignature
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write([]byte(signed))
+		return jwtSignatureError(hmac.Equal(sig, mac.Sum(nil)))
+	case "RS", "PS", "ES":
+	default:
+		return ErrJWTUnsupportedAlg
+	}
+
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+	switch alg[:2] {
+	case "RS":
+		pub, ok := key.(*rsa.PublicKey)
+		return jwtSignatureError(ok && rsa.VerifyPKCS1v15(pub, hash, digest, sig) == nil)
+	case "PS":
+		pub, ok := key.(*rsa.PublicKey)
+		return jwtSignatureError(ok && rsa.VerifyPSS(pub, hash, digest, sig, nil) == nil)
+	default:
+		// Each ES algorithm is bound to one curve, so a key on another curve is rejected.
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || pub.Curve.Params().Name != curve {
+			return ErrJWTInvalidSignature
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return ErrJWTInvalidSignature
+		}
+		r, s := new(big.Int).SetBytes(sig[:size]), new(big.Int).SetBytes(sig[size:])
+		return jwtSignatureError(ecdsa.Verify(pub, digest, r, s))
+	}
+}
+
+func jwtSignatureError(valid bool) error {
+	if !valid {
+		return ErrJWTInvalidSignature
+	}
+	return nil
+}
+
+// jwk is a public key of a JSON Web Key Set.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS returns a JWTKeyFunc looking keys up by key ID in the JSON Web Key Set data. Tokens
+// without a key ID are verified with the only key of single-key sets. RSA, EC and Ed25519 keys
+// are supported; keys of other types are skipped.
+func ParseJWKS(data []byte) (JWTKeyFunc, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+
+	type entry struct {
+		alg string
+		key any
+	}
+	keys := make(map[string]entry, len(set.Keys))
+	for _, k := range set.Keys {
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("jwks: key %q: %w", k.Kid, err)
+		}
+		if key != nil {
+			keys[k.Kid] = entry{alg: k.Alg, key: key}
+		}
+	}
+
+	return func(alg, kid string) (any, error) {
+		e, ok := keys[kid]
+		if !ok && kid == "" && len(keys) == 1 {
+			for _, e = range keys {
+				ok = true
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("jwks: key %q: %w", kid, ErrJWTUnknownKey)
+		}
+		if e.alg != "" && e.alg != alg {
+			return nil, ErrJWTUnsupportedAlg
+		}
+		return e.key, nil
+	}, nil
+}
+
+func (k jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeJWKInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeJWKInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeJWKInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeJWKInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, nil
+}
+
+func decodeJWKInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
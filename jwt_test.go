@@ -0,0 +1,394 @@
+package y_middleware
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var jwtSecret = []byte("test secret")
+
+// signJWT returns a compact JWT with claims signed with key using alg.
+func signJWT(t *testing.T, alg, kid string, key any, claims Claims) string {
+	t.Helper()
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	h, _ := json.Marshal(header)
+	c, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+
+	var hash crypto.Hash
+	switch alg[len(alg)-3:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	}
+	digest := func() []byte {
+		h := hash.New()
+		h.Write([]byte(signed))
+		return h.Sum(nil)
+	}
+
+	var sig []byte
+	var err error
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(hash.New, k)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		if alg[:2] == "PS" {
+			sig, err = rsa.SignPSS(rand.Reader, k, hash, digest(), nil)
+		} else {
+			sig, err = rsa.SignPKCS1v15(rand.Reader, k, hash, digest())
+		}
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k, digest())
+		size := (k.Curve.Params().BitSize + 7) / 8
+		sig = make([]byte, 2*size)
+		r.FillBytes(sig[:size])
+		s.FillBytes(sig[size:])
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(k, []byte(signed))
+	case nil:
+	default:
+		t.Fatalf("unsupported key %T", key)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func serveJWT(j *JWT, authorization string) (*httptest.ResponseRecorder, Claims) {
+	var claims Claims
+	k := New(j)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		claims, _ = ClaimsFrom(r.Context())
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, req)
+	return rec, claims
+}
+
+func authorized(authorization string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", authorization)
+	return r
+}
+
+func TestJWTValidToken(t *testing.T) {
+	clock := newFakeClock()
+	j := NewJWT(NewJWTKeyVerifier(JWTKey(jwtSecret))).WithClock(clock)
+	token := signJWT(t, "HS256", "", jwtSecret, Claims{
+		"sub": "alice",
+		"exp": float64(clock.Now().Add(time.Hour).Unix()),
+	})
+
+	rec, claims := serveJWT(j, "Bearer "+token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if claims["sub"] != "alice" {
+		t.Errorf("sub claim = %v, want alice", claims["sub"])
+	}
+}
+
+func TestJWTRejectsInvalidTokens(t *testing.T) {
+	clock := newFakeClock()
+	now := clock.Now()
+	valid := Claims{"sub": "alice", "exp": float64(now.Add(time.Hour).Unix())}
+	none := signJWT(t, "none", "", nil, valid)
+
+	tests := []struct {
+		name          string
+		authorization string
+	}{
+		{"missing", ""},
+		{"other scheme", "Basic " + base64.StdEncoding.EncodeToString([]byte("alice:secret"))},
+		{"empty bearer", "Bearer "},
+		{"malformed", "Bearer not.a.jwt"},
+		{"wrong signature", "Bearer " + signJWT(t, "HS256", "", []byte("other secret"), valid)},
+		{"unsigned", "Bearer " + none},
+		{"expired", "Bearer " + signJWT(t, "HS256", "", jwtSecret, Claims{"exp": float64(now.Add(-2 * time.Minute).Unix())})},
+		{"not yet valid", "Bearer " + signJWT(t, "HS256", "", jwtSecret, Claims{"nbf": float64(now.Add(2 * time.Minute).Unix())})},
+	}
+	for _, tt := range tests {
+		j := NewJWT(NewJWTKeyVerifier(JWTKey(jwtSecret))).WithClock(clock)
+		rec, claims := serveJWT(j, tt.authorization)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, http.StatusUnauthorized)
+		}
+		if got := rec.Header().Get("WWW-Authenticate"); got != "Bearer" {
+			t.Errorf("%s: WWW-Authenticate = %q, want Bearer", tt.name, got)
+		}
+		if claims != nil {
+			t.Errorf("%s: handler was served", tt.name)
+		}
+	}
+}
+
+func TestJWTTamperedPayload(t *testing.T) {
+	token := signJWT(t, "HS256", "", jwtSecret, Claims{"sub": "alice"})
+	forged := signJWT(t, "HS256", "", jwtSecret, Claims{"sub": "admin"})
+
+	// Keep the signature of the first token with the payload of the second.
+	parts, forgedParts := strings.Split(token, "."), strings.Split(forged, ".")
+	tampered := parts[0] + "." + forgedParts[1] + "." + parts[2]
+
+	if _, err := NewJWTKeyVerifier(JWTKey(jwtSecret)).Verify(tampered); !errors.Is(err, ErrJWTInvalidSignature) {
+		t.Errorf("Verify(tampered) = %v, want ErrJWTInvalidSignature", err)
+	}
+}
+
+func TestJWTLeeway(t *testing.T) {
+	clock := newFakeClock()
+	j := NewJWT(NewJWTKeyVerifier(JWTKey(jwtSecret))).WithClock(clock)
+	token := signJWT(t, "HS256", "", jwtSecret, Claims{"exp": float64(clock.Now().Add(-30 * time.Second).Unix())})
+
+	if rec, _ := serveJWT(j, "Bearer "+token); rec.Code != http.StatusOK {
+		t.Errorf("status = %d within the leeway, want %d", rec.Code, http.StatusOK)
+	}
+	j.Leeway = 0
+	if rec, _ := serveJWT(j, "Bearer "+token); rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d without leeway, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestJWTIssuerAndAudience(t *testing.T) {
+	tests := []struct {
+		claims Claims
+		want   int
+	}{
+		{Claims{"iss": "https://issuer", "aud": "api"}, http.StatusOK},
+		{Claims{"iss": "https://issuer", "aud": []any{"web", "api"}}, http.StatusOK},
+		{Claims{"iss": "https://other", "aud": "api"}, http.StatusUnauthorized},
+		{Claims{"iss": "https://issuer", "aud": "web"}, http.StatusUnauthorized},
+		{Claims{"iss": "https://issuer"}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		j := NewJWT(NewJWTKeyVerifier(JWTKey(jwtSecret)))
+		j.Issuer = "https://issuer"
+		j.Audience = "api"
+		if rec, _ := serveJWT(j, "Bearer "+signJWT(t, "HS256", "", jwtSecret, tt.claims)); rec.Code != tt.want {
+			t.Errorf("claims %v: status = %d, want %d", tt.claims, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestJWTKeyVerifierAlgorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		alg      string
+		priv     any
+		pub      any
+		otherPub any
+	}{
+		{"HS512", jwtSecret, jwtSecret, []byte("other")},
+		{"RS256", rsaKey, &rsaKey.PublicKey, &ecKey.PublicKey},
+		{"PS384", rsaKey, &rsaKey.PublicKey, jwtSecret},
+		{"ES256", ecKey, &ecKey.PublicKey, &rsaKey.PublicKey},
+		{"EdDSA", edKey, edPub, &rsaKey.PublicKey},
+	}
+	for _, tt := range tests {
+		token := signJWT(t, tt.alg, "", tt.priv, Claims{"sub": "alice"})
+		claims, err := NewJWTKeyVerifier(JWTKey(tt.pub)).Verify(token)
+		if err != nil {
+			t.Errorf("%s: Verify = %v", tt.alg, err)
+		} else if claims["sub"] != "alice" {
+			t.Errorf("%s: sub claim = %v, want alice", tt.alg, claims["sub"])
+		}
+		if _, err := NewJWTKeyVerifier(JWTKey(tt.otherPub)).Verify(token); !errors.Is(err, ErrJWTInvalidSignature) {
+			t.Errorf("%s with a %T key: Verify = %v, want ErrJWTInvalidSignature", tt.alg, tt.otherPub, err)
+		}
+	}
+}
+
+func TestJWTRejectsHMACWithPublicKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An attacker signing with the public key bytes as HMAC secret must not pass for RS256.
+	token := signJWT(t, "HS256", "", rsaKey.PublicKey.N.Bytes(), Claims{"sub": "admin"})
+	if _, err := NewJWTKeyVerifier(JWTKey(&rsaKey.PublicKey)).Verify(token); err == nil {
+		t.Error("HS256 token verified with an RSA public key")
+	}
+}
+
+func TestJWTRejectsECKeyOnOtherCurve(t *testing.T) {
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := NewJWTKeyVerifier(JWTKey(&p384.PublicKey))
+	if _, err := v.Verify(signJWT(t, "ES256", "", p384, Claims{})); !errors.Is(err, ErrJWTInvalidSignature) {
+		t.Errorf("ES256 with a P-384 key: Verify = %v, want ErrJWTInvalidSignature", err)
+	}
+	if _, err := v.Verify(signJWT(t, "ES384", "", p384, Claims{})); err != nil {
+		t.Errorf("ES384 with a P-384 key: Verify = %v", err)
+	}
+}
+
+func TestJWTKeySourceErrors(t *testing.T) {
+	errFetch := errors.New("fetching keys failed")
+	j := NewJWT(NewJWTKeyVerifier(func(string, string) (any, error) { return nil, errFetch }))
+	token := signJWT(t, "HS256", "", jwtSecret, Claims{"sub": "alice"})
+
+	if _, ok, err := j.Authenticate(authorized("Bearer " + token)); ok || !errors.Is(err, errFetch) {
+		t.Errorf("Authenticate = %v, %v; want the key source error", ok, err)
+	}
+	if rec, _ := serveJWT(j, "Bearer "+token); rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+
+	fallback := false
+	a := NewAuthChain(j.Authenticate, func(*http.Request) (any, bool, error) {
+		fallback = true
+		return "anonymous", true, nil
+	})
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, authorized("Bearer "+token), func(http.ResponseWriter, *http.Request) {})
+	if rec.Code != http.StatusInternalServerError || fallback {
+		t.Errorf("AuthChain status = %d, fell through = %v; want a 500 without trying other schemes", rec.Code, fallback)
+	}
+}
+
+func TestJWTUnknownKeyIsUnauthorized(t *testing.T) {
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := ParseJWKS([]byte(fmt.Sprintf(`{"keys": [{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": %q}]}`,
+		base64.RawURLEncoding.EncodeToString(edPub))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	j := NewJWT(NewJWTKeyVerifier(keys))
+	token := signJWT(t, "EdDSA", "rotated", edKey, Claims{})
+	if _, ok, err := j.Authenticate(authorized("Bearer " + token)); ok || err != nil {
+		t.Errorf("Authenticate = %v, %v; want an invalid token without error", ok, err)
+	}
+	if rec, _ := serveJWT(j, "Bearer "+token); rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	jwks := fmt.Sprintf(`{"keys": [
+		{"kty": "RSA", "kid": "rsa", "alg": "RS256", "n": %q, "e": %q},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": %q, "y": %q},
+		{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": %q},
+		{"kty": "oct", "kid": "secret", "k": "c2VjcmV0"}
+	]}`,
+		b64(rsaKey.N.Bytes()), b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+		b64(ecKey.X.Bytes()), b64(ecKey.Y.Bytes()),
+		b64(edPub))
+	keys, err := ParseJWKS([]byte(jwks))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := NewJWTKeyVerifier(keys)
+
+	for _, tt := range []struct {
+		alg, kid string
+		priv     any
+	}{
+		{"RS256", "rsa", rsaKey},
+		{"ES256", "ec", ecKey},
+		{"EdDSA", "ed", edKey},
+	} {
+		if _, err := v.Verify(signJWT(t, tt.alg, tt.kid, tt.priv, Claims{})); err != nil {
+			t.Errorf("key %s: Verify = %v", tt.kid, err)
+		}
+	}
+
+	if _, err := v.Verify(signJWT(t, "RS256", "unknown", rsaKey, Claims{})); !errors.Is(err, ErrJWTUnknownKey) {
+		t.Errorf("token with an unknown key ID: Verify = %v, want ErrJWTUnknownKey", err)
+	}
+	if _, err := v.Verify(signJWT(t, "PS256", "rsa", rsaKey, Claims{})); !errors.Is(err, ErrJWTUnsupportedAlg) {
+		t.Errorf("token with an algorithm other than the key's: Verify = %v, want ErrJWTUnsupportedAlg", err)
+	}
+	if _, err := v.Verify(signJWT(t, "HS256", "secret", []byte("secret"), Claims{})); err == nil {
+		t.Error("token verified with a symmetric key from the JWKS")
+	}
+}
+
+func TestParseJWKSSingleKeyWithoutKid(t *testing.T) {
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := ParseJWKS([]byte(fmt.Sprintf(`{"keys": [{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": %q}]}`,
+		base64.RawURLEncoding.EncodeToString(edPub))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewJWTKeyVerifier(keys).Verify(signJWT(t, "EdDSA", "", edKey, Claims{})); err != nil {
+		t.Errorf("Verify = %v for a token without kid and a single-key set", err)
+	}
+}
+
+func TestParseJWKSInvalid(t *testing.T) {
+	for _, data := range []string{
+		`not json`,
+		`{"keys": [{"kty": "EC", "kid": "ec", "crv": "P-192", "x": "AA", "y": "AA"}]}`,
+		`{"keys": [{"kty": "RSA", "kid": "rsa", "n": "", "e": "AQAB"}]}`,
+		`{"keys": [{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": "AAAA"}]}`,
+	} {
+		if _, err := ParseJWKS([]byte(data)); err == nil {
+			t.Errorf("ParseJWKS(%s) succeeded", data)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package y_middleware
+
+import (
+	"net/http"
+	"time"
+)
+
+// LastModified is a middleware handler that answers conditional GET and HEAD requests based on
+// modification times. It sets the Last-Modified header, and when the If-Modified-Since request
+// header is at or after the modification time it responds 304 Not Modified without calling next.
+type LastModified struct {
+	// ModTime returns the modification time of the resource a request is for, and false
+	// if it has none.
+	ModTime func(r *http.Request) (time.Time, bool)
+}
+
+// NewLastModified returns a new LastModified instance looking up resources with modTime.
+func NewLastModified(modTime func(r *http.Request) (time.Time, bool)) *LastModified {
+	return &LastModified{ModTime: modTime}
+}
+
+func (l *LastModified) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		next(rw, r)
+		return
+	}
+	modTime, ok := l.ModTime(r)
+	if !ok || modTime.IsZero() {
+		next(rw, r)
+		return
+	}
+
+	// HTTP dates have a resolution of one second.
+	modTime = modTime.UTC().Truncate(time.Second)
+	rw.Header().Set("Last-Modified", modTime.Format(http.TimeFormat))
+
+	// If-None-Match takes precedence over If-Modified-Since.
+	if r.Header.Get("If-None-Match") == "" {
+		if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !modTime.After(since) {
+			rw.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	next(rw, r)
+}
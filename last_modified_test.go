@@ -0,0 +1,57 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLastModified(t *testing.T) {
+	modTime := time.Date(2024, 3, 1, 12, 0, 0, 500, time.UTC)
+	l := NewLastModified(func(r *http.Request) (time.Time, bool) {
+		return modTime, r.URL.Path == "/doc"
+	})
+
+	tests := []struct {
+		name, method, path string
+		header             http.Header
+		want               int
+	}{
+		{"unconditional", http.MethodGet, "/doc", nil, http.StatusOK},
+		{"not modified", http.MethodGet, "/doc", http.Header{"If-Modified-Since": {"Fri, 01 Mar 2024 12:00:00 GMT"}}, http.StatusNotModified},
+		{"head not modified", http.MethodHead, "/doc", http.Header{"If-Modified-Since": {"Sat, 02 Mar 2024 00:00:00 GMT"}}, http.StatusNotModified},
+		{"modified", http.MethodGet, "/doc", http.Header{"If-Modified-Since": {"Fri, 01 Mar 2024 11:59:59 GMT"}}, http.StatusOK},
+		{"If-None-Match wins", http.MethodGet, "/doc", http.Header{"If-Modified-Since": {"Sat, 02 Mar 2024 00:00:00 GMT"}, "If-None-Match": {`"v1"`}}, http.StatusOK},
+		{"invalid date", http.MethodGet, "/doc", http.Header{"If-Modified-Since": {"yesterday"}}, http.StatusOK},
+		{"unsafe method", http.MethodPut, "/doc", http.Header{"If-Modified-Since": {"Sat, 02 Mar 2024 00:00:00 GMT"}}, http.StatusOK},
+		{"unknown resource", http.MethodGet, "/other", http.Header{"If-Modified-Since": {"Sat, 02 Mar 2024 00:00:00 GMT"}}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		served := false
+		k := New(l)
+		k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			served = true
+		})
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		for name, values := range tt.header {
+			req.Header[name] = values
+		}
+		rec := httptest.NewRecorder()
+		k.ServeHTTP(rec, req)
+
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+		if served != (tt.want == http.StatusOK) {
+			t.Errorf("%s: served = %v", tt.name, served)
+		}
+		wantHeader := ""
+		if tt.path == "/doc" && tt.method != http.MethodPut {
+			wantHeader = "Fri, 01 Mar 2024 12:00:00 GMT"
+		}
+		if got := rec.Header().Get("Last-Modified"); got != wantHeader {
+			t.Errorf("%s: Last-Modified = %q, want %q", tt.name, got, wantHeader)
+		}
+	}
+}
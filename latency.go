@@ -0,0 +1,47 @@
+package y_middleware
+
+import (
+	"net/http"
+	"time"
+)
+
+// Latency is a chaos testing middleware handler that delays requests by a random duration
+// between Min and Max before calling next, to exercise client timeout handling. If the
+// request context is done during the delay, the rest of the chain is skipped.
+type Latency struct {
+	Min time.Duration
+	Max time.Duration
+	// Probability is the fraction of requests, between 0 and 1, that are delayed.
+	Probability float64
+
+	rng RNG
+}
+
+// NewLatency returns a new Latency instance delaying the given fraction of requests by min to max.
+func NewLatency(min, max time.Duration, probability float64) *Latency {
+	return &Latency{
+		Min:         min,
+		Max:         max,
+		Probability: probability,
+	}
+}
+
+// WithRNG makes the Latency draw its randomness from rng instead of the global source.
+func (l *Latency) WithRNG(rng RNG) *Latency {
+	l.rng = rng
+	return l
+}
+
+func (l *Latency) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if rngOrDefault(l.rng).Float64() < l.Probability && !sleepContext(r, l.delay()) {
+		return
+	}
+	next(rw, r)
+}
+
+func (l *Latency) delay() time.Duration {
+	if l.Max <= l.Min {
+		return l.Min
+	}
+	return l.Min + time.Duration(rngOrDefault(l.rng).Int63n(int64(l.Max-l.Min)+1))
+}
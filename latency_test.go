@@ -0,0 +1,76 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fixedRNG is an RNG always returning f from Float64 and n-1 scaled by frac from Int63n.
+type fixedRNG struct {
+	f    float64
+	frac float64
+}
+
+func (r fixedRNG) Float64() float64 { return r.f }
+
+func (r fixedRNG) Int63n(n int64) int64 { return int64(float64(n-1) * r.frac) }
+
+func TestLatencyDelayRange(t *testing.T) {
+	tests := []struct {
+		frac float64
+		want time.Duration
+	}{
+		{0, 10 * time.Millisecond},
+		{0.5, 15 * time.Millisecond},
+		{1, 20 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		l := NewLatency(10*time.Millisecond, 20*time.Millisecond, 1).WithRNG(fixedRNG{frac: tt.frac})
+		if got := l.delay(); got != tt.want {
+			t.Errorf("delay with %v of the range = %v, want %v", tt.frac, got, tt.want)
+		}
+	}
+	if got := NewLatency(time.Second, 0, 1).delay(); got != time.Second {
+		t.Errorf("delay with Max below Min = %v, want Min", got)
+	}
+}
+
+func TestLatencyDelaysRequests(t *testing.T) {
+	l := NewLatency(20*time.Millisecond, 20*time.Millisecond, 1).WithRNG(fixedRNG{f: 0.5})
+	served := false
+	k := New(l)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		served = true
+	})
+
+	start := time.Now()
+	k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("request took %v, want at least 20ms", elapsed)
+	}
+	if !served {
+		t.Error("delayed request was not served")
+	}
+
+	l.Probability = 0.5
+	start = time.Now()
+	k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if elapsed := time.Since(start); elapsed >= 20*time.Millisecond {
+		t.Errorf("request outside the probability took %v, want no delay", elapsed)
+	}
+}
+
+func TestLatencyCanceledRequest(t *testing.T) {
+	l := NewLatency(time.Hour, time.Hour, 1)
+	k := New(l)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		t.Error("canceled request was served")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+}
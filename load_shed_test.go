@@ -0,0 +1,129 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// holdRequests starts n requests to path on k, which must block in its handler until release
+// is closed, and waits until they are all being served.
+func holdRequests(k *Kudret, n int, path string, started <-chan struct{}) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		}()
+	}
+	for i := 0; i < n; i++ {
+		<-started
+	}
+	return &wg
+}
+
+// blockingStack returns a stack of handlers ending in one that signals started and blocks
+// until release is closed.
+func blockingStack(handlers ...Handler) (*Kudret, chan struct{}, chan struct{}) {
+	started, release := make(chan struct{}, 16), make(chan struct{})
+	k := New(handlers...)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	})
+	return k, started, release
+}
+
+func status(k *Kudret, path string) int {
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec.Code
+}
+
+func TestLoadShed(t *testing.T) {
+	l := NewLoadShed(2)
+	l.Exempt = []string{"/health"}
+	k, started, release := blockingStack(l)
+
+	work := holdRequests(k, 2, "/work", started)
+	// Exempt paths are served above the high-water mark.
+	health := holdRequests(k, 1, "/health", started)
+	if l.InFlight() != 3 {
+		t.Errorf("InFlight() = %d, want 3", l.InFlight())
+	}
+	if got := status(New(l), "/work"); got != http.StatusTooManyRequests {
+		t.Errorf("status = %d above the high-water mark, want %d", got, http.StatusTooManyRequests)
+	}
+	l.SetHighWater(4)
+	if got := status(New(l), "/work"); got != http.StatusOK {
+		t.Errorf("status = %d after raising the high-water mark, want %d", got, http.StatusOK)
+	}
+
+	close(release)
+	work.Wait()
+	health.Wait()
+	if l.InFlight() != 0 {
+		t.Errorf("InFlight() = %d after every request finished, want 0", l.InFlight())
+	}
+}
+
+func TestLoadShedPriorityReserve(t *testing.T) {
+	l := NewLoadShed(3)
+	l.PriorityReserve = 2
+	k, started, release := blockingStack(NewPriority(), l)
+	held := holdRequests(k, 1, "/work", started)
+
+	prioritized := func(priority string) int {
+		k := New(NewPriority(), l)
+		r := httptest.NewRequest(http.MethodGet, "/work", nil)
+		r.Header.Set("Priority", priority)
+		rec := httptest.NewRecorder()
+		k.ServeHTTP(rec, r)
+		return rec.Code
+	}
+	if got := prioritized("u=5"); got != http.StatusTooManyRequests {
+		t.Errorf("background request: status = %d, want %d inside the reserve", got, http.StatusTooManyRequests)
+	}
+	for _, priority := range []string{"u=3", "u=0"} {
+		if got := prioritized(priority); got != http.StatusOK {
+			t.Errorf("%s: status = %d, want %d", priority, got, http.StatusOK)
+		}
+	}
+
+	close(release)
+	held.Wait()
+}
+
+func TestLoadShedShedUrgency(t *testing.T) {
+	p := NewPriority()
+	p.DefaultUrgency = 5
+	l := NewLoadShed(2)
+	l.PriorityReserve = 1
+	l.ShedUrgency = p.DefaultUrgency
+	k, started, release := blockingStack(p, l)
+	held := holdRequests(k, 1, "/work", started)
+
+	prioritized := func(priority string) int {
+		k := New(p, l)
+		r := httptest.NewRequest(http.MethodGet, "/work", nil)
+		if priority != "" {
+			r.Header.Set("Priority", priority)
+		}
+		rec := httptest.NewRecorder()
+		k.ServeHTTP(rec, r)
+		return rec.Code
+	}
+	if got := prioritized("u=6"); got != http.StatusTooManyRequests {
+		t.Errorf("u=6: status = %d, want %d inside the reserve", got, http.StatusTooManyRequests)
+	}
+	for _, priority := range []string{"", "u=5", "u=4"} {
+		if got := prioritized(priority); got != http.StatusOK {
+			t.Errorf("%q: status = %d, want %d", priority, got, http.StatusOK)
+		}
+	}
+
+	close(release)
+	held.Wait()
+}
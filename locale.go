@@ -0,0 +1,59 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+
+	"golang.org/x/text/language"
+)
+
+type localeKey struct{}
+
+// Locale is a middleware handler that negotiates the request's Accept-Language header
+// against a set of supported languages and stores the best match in the request context.
+// Requests without an acceptable language get the fallback.
+type Locale struct {
+	fallback  language.Tag
+	supported []language.Tag
+	matcher   language.Matcher
+}
+
+// NewLocale returns a new Locale instance negotiating between the fallback and the
+// supported languages.
+func NewLocale(fallback language.Tag, supported ...language.Tag) *Locale {
+	tags := append([]language.Tag{fallback}, supported...)
+	return &Locale{
+		fallback:  fallback,
+		supported: tags,
+		matcher:   language.NewMatcher(tags),
+	}
+}
+
+func (l *Locale) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	ctx := context.WithValue(r.Context(), localeKey{}, l.match(r.Header.Get("Accept-Language")))
+	next(rw, r.WithContext(ctx))
+}
+
+func (l *Locale) match(accept string) language.Tag {
+	if accept == "" {
+		return l.fallback
+	}
+	tags, _, err := language.ParseAcceptLanguage(accept)
+	if err != nil || len(tags) == 0 {
+		return l.fallback
+	}
+	_, i, confidence := l.matcher.Match(tags...)
+	if confidence == language.No {
+		return l.fallback
+	}
+	return l.supported[i]
+}
+
+// LocaleFrom returns the language negotiated by Locale, or language.Und if Locale
+// did not run for the request.
+func LocaleFrom(ctx context.Context) language.Tag {
+	if tag, ok := ctx.Value(localeKey{}).(language.Tag); ok {
+		return tag
+	}
+	return language.Und
+}
@@ -0,0 +1,46 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestLocaleNegotiation(t *testing.T) {
+	l := NewLocale(language.English, language.German, language.French)
+	tests := []struct {
+		accept string
+		want   language.Tag
+	}{
+		{"", language.English},
+		{"de", language.German},
+		{"fr-CH, fr;q=0.9, en;q=0.8", language.French},
+		{"ja, de;q=0.5", language.German},
+		{"ja", language.English},
+		{"not a language;;;", language.English},
+	}
+	for _, tt := range tests {
+		var got language.Tag
+		k := New(l)
+		k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			got = LocaleFrom(r.Context())
+		})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept-Language", tt.accept)
+		}
+		k.ServeHTTP(httptest.NewRecorder(), req)
+		if got != tt.want {
+			t.Errorf("Accept-Language %q: locale = %v, want %v", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestLocaleFromWithoutLocale(t *testing.T) {
+	if got := LocaleFrom(context.Background()); got != language.Und {
+		t.Errorf("LocaleFrom = %v, want und", got)
+	}
+}
@@ -0,0 +1,53 @@
+package y_middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultLogSamplerSlow is the duration from which LogSampler always logs requests by default.
+const DefaultLogSamplerSlow = time.Second
+
+// LogSampler is a middleware handler that keeps the log volume of a Logger down at high request
+// rates. It is used in the stack instead of the Logger, and only logs Rate of the successful
+// requests, while requests that fail with a 4xx or 5xx status or take at least Slow are always
+// logged. Requests that ConfigOverride sets OverrideLogLevel to "debug" for are logged too.
+type LogSampler struct {
+	Logger *Logger
+	// Rate is the share of successful requests, between 0 and 1, that are logged.
+	Rate float64
+	Slow time.Duration
+
+	rng RNG
+}
+
+// NewLogSampler returns a new LogSampler instance logging rate of the successful requests
+// through logger.
+func NewLogSampler(logger *Logger, rate float64) *LogSampler {
+	return &LogSampler{
+		Logger: logger,
+		Rate:   rate,
+		Slow:   DefaultLogSamplerSlow,
+	}
+}
+
+// WithRNG makes the LogSampler draw its randomness from rng instead of the global source.
+func (s *LogSampler) WithRNG(rng RNG) *LogSampler {
+	s.rng = rng
+	return s
+}
+
+func (s *LogSampler) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	s.Logger.serve(rw, r, next, s.keep)
+}
+
+func (s *LogSampler) keep(entry LoggerEntry) bool {
+	if entry.Status >= http.StatusBadRequest || (s.Slow > 0 && entry.Duration >= s.Slow) {
+		return true
+	}
+	if level, ok := Override(entry.Request.Context(), OverrideLogLevel); ok && strings.EqualFold(level, "debug") {
+		return true
+	}
+	return rngOrDefault(s.rng).Float64() < s.Rate
+}
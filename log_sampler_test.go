@@ -0,0 +1,66 @@
+package y_middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogSampler(t *testing.T) {
+	tests := []struct {
+		name   string
+		draw   float64
+		status int
+		took   time.Duration
+		logged bool
+	}{
+		{"sampled in", 0.3, http.StatusOK, 0, true},
+		{"sampled out", 0.7, http.StatusOK, 0, false},
+		{"client error", 0.7, http.StatusNotFound, 0, true},
+		{"server error", 0.7, http.StatusBadGateway, 0, true},
+		{"slow", 0.7, http.StatusOK, 2 * time.Second, true},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		l, clock := bufferedLogger(&buf, "{{.Status}} {{.Path}}")
+		s := NewLogSampler(l, 0.5).WithRNG(fixedRNG{f: tt.draw})
+		k := New(s)
+		k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			clock.Advance(tt.took)
+			rw.WriteHeader(tt.status)
+		})
+		k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items", nil))
+
+		if logged := strings.TrimSpace(buf.String()) != ""; logged != tt.logged {
+			t.Errorf("%s: logged = %v (%q), want %v", tt.name, logged, buf.String(), tt.logged)
+		}
+	}
+}
+
+func TestLogSamplerDebugOverride(t *testing.T) {
+	c := NewConfigOverride(netip.MustParsePrefix("192.0.2.0/24"))
+	for _, tt := range []struct {
+		remoteAddr string
+		logged     bool
+	}{
+		{"192.0.2.1:1234", true},
+		{"203.0.113.1:1234", false},
+	} {
+		var buf bytes.Buffer
+		l, _ := bufferedLogger(&buf, "{{.Status}} {{.Path}}")
+		k := New(c, NewLogSampler(l, 0.5).WithRNG(fixedRNG{f: 0.7}))
+		k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {})
+		r := httptest.NewRequest(http.MethodGet, "/items", nil)
+		r.RemoteAddr = tt.remoteAddr
+		r.Header.Set("X-Override-Log-Level", "debug")
+		k.ServeHTTP(httptest.NewRecorder(), r)
+
+		if logged := strings.TrimSpace(buf.String()) != ""; logged != tt.logged {
+			t.Errorf("%s: logged = %v (%q), want %v", tt.remoteAddr, logged, buf.String(), tt.logged)
+		}
+	}
+}
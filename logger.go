@@ -0,0 +1,115 @@
+package y_middleware
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"os"
+	"text/template"
+	"time"
+)
+
+// LoggerEntry is the structure passed to the template.
+type LoggerEntry struct {
+	StartTime string
+	Status    int
+	Duration  time.Duration
+	Hostname  string
+	Method    string
+	Path      string
+	Request   *http.Request
+	// HasDeadline reports whether the request context had a deadline.
+	HasDeadline bool
+	// Deadline is the time that was left until the deadline when the request completed.
+	// It is negative if the deadline had already passed.
+	Deadline time.Duration
+	// Cancelled reports whether the request context was done by the time the request completed,
+	// e.g. because the client went away.
+	Cancelled bool
+	// Attempt is the attempt number of a request served by Retry, or 0 without a Retry.
+	Attempt int
+}
+
+// LoggerDefaultFormat is the format logged used by the default Logger instance.
+var LoggerDefaultFormat = "{{.StartTime}} | {{.Status}} | \t {{.Duration}} | {{.Hostname}} | {{.Method}} {{.Path}}" +
+	"{{if .HasDeadline}} | deadline in {{.Deadline}}{{end}}{{if .Cancelled}} | cancelled{{end}}" +
+	"{{if .Attempt}} | attempt {{.Attempt}}{{end}}"
+
+// LoggerDefaultDateFormat is the format used for date by the default Logger instance.
+var LoggerDefaultDateFormat = time.RFC3339
+
+// ALogger interface
+type ALogger interface {
+	Println(v ...interface{})
+	Printf(format string, v ...interface{})
+}
+
+// Logger is a middleware handler that logs the request as it goes in and the response as it goes out.
+type Logger struct {
+	// ALogger implements just enough log.Logger interface to be compatible with other implementations
+	ALogger
+	dateFormat string
+	template   *template.Template
+	clock      Clock
+}
+
+// NewLogger returns a new Logger instance
+func NewLogger() *Logger {
+	logger := &Logger{ALogger: log.New(os.Stdout, "[kudret] ", 0), dateFormat: LoggerDefaultDateFormat}
+	logger.SetFormat(LoggerDefaultFormat)
+	return logger
+}
+
+// SetFormat sets the template used to render each log line.
+func (l *Logger) SetFormat(format string) {
+	l.template = template.Must(template.New("kudret_parser").Parse(format))
+}
+
+// SetDateFormat sets the layout StartTime is formatted with.
+func (l *Logger) SetDateFormat(format string) {
+	l.dateFormat = format
+}
+
+// WithClock makes the Logger read the time from c instead of the system clock.
+func (l *Logger) WithClock(c Clock) *Logger {
+	l.clock = c
+	return l
+}
+
+func (l *Logger) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	l.serve(rw, r, next, nil)
+}
+
+// serve runs next and logs the request, unless keep is set and returns false for its entry.
+func (l *Logger) serve(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc, keep func(LoggerEntry) bool) {
+	clock := clockOrDefault(l.clock)
+	start := clock.Now()
+
+	res := wrapResponseWriter(rw)
+	next(res, r)
+
+	end := clock.Now()
+	r = RedactRequest(r)
+	log := LoggerEntry{
+		StartTime: start.Format(l.dateFormat),
+		Status:    res.Status(),
+		Duration:  end.Sub(start),
+		Hostname:  r.Host,
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		Request:   r,
+		Cancelled: r.Context().Err() != nil,
+		Attempt:   Attempt(r.Context()),
+	}
+	if deadline, ok := r.Context().Deadline(); ok {
+		log.HasDeadline = true
+		log.Deadline = deadline.Sub(end)
+	}
+	if keep != nil && !keep(log) {
+		return
+	}
+
+	buff := &bytes.Buffer{}
+	l.template.Execute(buff, log)
+	l.Println(buff.String())
+}
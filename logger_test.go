@@ -0,0 +1,61 @@
+package y_middleware
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// bufferedLogger returns a Logger writing format to buf with a fake clock.
+func bufferedLogger(buf *bytes.Buffer, format string) (*Logger, *fakeClock) {
+	clock := newFakeClock()
+	l := NewLogger().WithClock(clock)
+	l.ALogger = log.New(buf, "", 0)
+	l.SetFormat(format)
+	return l, clock
+}
+
+func TestLoggerDefaultFormat(t *testing.T) {
+	var buf bytes.Buffer
+	l, clock := bufferedLogger(&buf, LoggerDefaultFormat)
+	k := New(l)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		clock.Advance(time.Second)
+		rw.WriteHeader(http.StatusAccepted)
+	})
+	k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "http://example.com/items", nil))
+
+	want := "2024-01-01T00:00:00Z | 202 | \t 1s | example.com | POST /items"
+	if got := strings.TrimSpace(buf.String()); got != want {
+		t.Errorf("logged %q, want %q", got, want)
+	}
+}
+
+func TestLoggerDeadlineAndCancellation(t *testing.T) {
+	var buf bytes.Buffer
+	l, _ := bufferedLogger(&buf, "{{if .HasDeadline}}deadline in {{.Deadline}}{{end}}{{if .Cancelled}}cancelled{{end}}")
+	// The context deadline runs on the system clock.
+	l.WithClock(nil)
+	k := New(l)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	if got := strings.TrimSpace(buf.String()); !strings.HasPrefix(got, "deadline in 59m") {
+		t.Errorf("logged %q, want the time left until the deadline", got)
+	}
+
+	buf.Reset()
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	if got := strings.TrimSpace(buf.String()); got != "cancelled" {
+		t.Errorf("logged %q, want %q", got, "cancelled")
+	}
+}
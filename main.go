@@ -1,12 +1,20 @@
 package y_middleware
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
 	"net/http"
 	"os"
+	"sync"
 )
 
 const (
 	DefaultAddress = ":8080"
+	// DefaultSocketMode is the permission RunUnix sets on its socket file.
+	DefaultSocketMode os.FileMode = 0660
 )
 
 // Handler is an interface that objects can implement to be registered to serve as middleware
@@ -63,6 +71,10 @@ func WrapFunc(handlerFunc http.HandlerFunc) Handler {
 type Kudret struct {
 	middleware middleware
 	handlers   []Handler
+
+	mu        sync.Mutex
+	server    *http.Server
+	reusePort bool
 }
 
 // New returns a new Kudret instance with no middleware preconfigured
@@ -82,7 +94,7 @@ func (k *Kudret) With(handlers ...Handler) *Kudret {
 }
 
 func (k *Kudret) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
-	k.middleware.ServeHTTP(rw, r)
+	k.middleware.ServeHTTP(NewResponseWriter(rw), r)
 }
 
 // Use adds a Handler onto the middleware stack. Handlers are invoked in the order they are added to a Negroni.
@@ -111,8 +123,119 @@ func (k *Kudret) UseHandlerFunc(handlerFunc func(rw http.ResponseWriter, r *http
 	// k.UseHandler(http.HandlerFunc(handlerFunc)) // this one works
 }
 
+// Run is a convenience function that runs the kudret stack as an HTTP
+// server. The addr string, if provided, takes the same format as http.ListenAndServe.
+// If no address is provided but the PORT environment variable is set, the PORT value is used.
+// If neither is provided, the address' value will equal the DefaultAddress constant.
 func (k *Kudret) Run(addr ...string) {
+	l := log.New(os.Stdout, "[kudret] ", 0)
+	finalAddr := detectAddress(addr...)
+	l.Printf("listening on %s", finalAddr)
+	l.Fatal(k.RunWithContext(context.Background(), finalAddr))
+}
+
+// RunWithContext runs the kudret stack as an HTTP server until ctx is done,
+// then shuts the server down gracefully. It returns nil after a clean shutdown.
+func (k *Kudret) RunWithContext(ctx context.Context, addr ...string) error {
+	finalAddr := detectAddress(addr...)
+	var lc net.ListenConfig
+	if k.reusePort {
+		lc.Control = reusePortControl
+	}
+	l, err := lc.Listen(ctx, "tcp", finalAddr)
+	if err != nil {
+		return err
+	}
+	return k.serve(ctx, &http.Server{Addr: finalAddr, Handler: k}, l)
+}
+
+// WithReusePort makes Run and RunWithContext bind with SO_REUSEPORT, so an old and a new
+// process can listen on the same port during a zero-downtime restart. It is supported on
+// Linux and the BSDs, including macOS; on other platforms the Run methods return an error.
+func (k *Kudret) WithReusePort() *Kudret {
+	k.reusePort = true
+	return k
+}
+
+// RunUnix runs the kudret stack as an HTTP server listening on the Unix domain socket at path.
+// Any stale socket file at path is removed first; if path exists and is not a socket, RunUnix
+// fails rather than removing it. The socket is removed again when the
+// server stops. The socket file gets DefaultSocketMode permissions.
+func (k *Kudret) RunUnix(path string) error {
+	return k.RunUnixWithContext(context.Background(), path, DefaultSocketMode)
+}
+
+// RunUnixWithContext is like RunUnix but sets the socket file permissions to mode
+// and shuts the server down gracefully once ctx is done.
+func (k *Kudret) RunUnixWithContext(ctx context.Context, path string, mode os.FileMode) error {
+	if err := removeStaleSocket(path); err != nil {
+		return err
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	if err := os.Chmod(path, mode); err != nil {
+		l.Close()
+		return err
+	}
+	return k.serve(ctx, &http.Server{Addr: path, Handler: k}, l)
+}
+
+// removeStaleSocket removes the socket file left at path by an earlier server. Anything else at
+// path is left alone and reported as an error, so a mistyped path can't delete a regular file.
+func removeStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("kudret: %s exists and is not a socket", path)
+	}
+	return os.Remove(path)
+}
+
+// serve runs server on l until it fails or ctx is done, in which case the server
+// is shut down gracefully.
+func (k *Kudret) serve(ctx context.Context, server *http.Server, l net.Listener) error {
+	k.setServer(server)
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- server.Serve(l)
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		if err := server.Shutdown(context.Background()); err != nil {
+			return err
+		}
+		if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
+
+// Server returns the *http.Server constructed by the most recent call to one of the
+// Run methods. It returns nil if the stack has not been run yet.
+func (k *Kudret) Server() *http.Server {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.server
+}
 
+func (k *Kudret) setServer(server *http.Server) {
+	k.mu.Lock()
+	k.server = server
+	k.mu.Unlock()
 }
 
 func detectAddress(addr ...string) string {
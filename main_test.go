@@ -0,0 +1,172 @@
+package y_middleware
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// unixClient returns a client sending every request to the socket at path.
+func unixClient(path string) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}}
+}
+
+// waitForSocket waits until something listens on the socket at path.
+func waitForSocket(t *testing.T, path string) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("nothing listening on %s", path)
+}
+
+func skipWithoutUnixSockets(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets are not supported")
+	}
+}
+
+func TestRunUnixReplacesStaleSocket(t *testing.T) {
+	skipWithoutUnixSockets(t)
+	path := filepath.Join(t.TempDir(), "kudret.sock")
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	k := New()
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		io.WriteString(rw, "ok")
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- k.RunUnixWithContext(ctx, path, 0600) }()
+	waitForSocket(t, path)
+
+	res, err := unixClient(path).Get("http://kudret/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if string(body) != "ok" {
+		t.Errorf("body = %q, want ok", body)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("socket mode = %v, %v; want 0600", fi.Mode().Perm(), err)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("RunUnixWithContext = %v", err)
+	}
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Error("socket file left behind")
+	}
+}
+
+func TestRunUnixRefusesToRemoveRegularFile(t *testing.T) {
+	skipWithoutUnixSockets(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("keep me"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := New().RunUnix(path); err == nil {
+		t.Fatal("RunUnix succeeded on a regular file")
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "keep me" {
+		t.Errorf("regular file was touched: %q, %v", data, err)
+	}
+}
+
+// freeAddr returns a local TCP address nothing listens on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func TestRunWithContextShutsDownGracefully(t *testing.T) {
+	addr := freeAddr(t)
+	release := make(chan struct{})
+	k := New()
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		<-release
+		io.WriteString(rw, "ok")
+	})
+	if k.Server() != nil {
+		t.Error("Server() is not nil before the stack was run")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- k.RunWithContext(ctx, addr) }()
+
+	var conn net.Conn
+	for i := 0; i < 100; i++ {
+		var err error
+		if conn, err = net.Dial("tcp", addr); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if conn == nil {
+		t.Fatalf("nothing listening on %s", addr)
+	}
+	conn.Close()
+	if s := k.Server(); s == nil || s.Addr != addr {
+		t.Fatalf("Server() = %v, want the running server on %s", s, addr)
+	}
+
+	// A request in flight when ctx is done still completes.
+	body := make(chan string, 1)
+	go func() {
+		res, err := http.Get("http://" + addr + "/")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		b, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		body <- string(b)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	if got := <-body; got != "ok" {
+		t.Errorf("in-flight request got %q, want ok", got)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("RunWithContext = %v, want nil after shutdown", err)
+	}
+}
+
+func TestRunWithContextListenError(t *testing.T) {
+	if err := New().RunWithContext(context.Background(), "invalid address"); err == nil {
+		t.Error("RunWithContext succeeded with an invalid address")
+	}
+}
@@ -0,0 +1,93 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultMemoryGuardInterval is how often MemoryGuard samples memory statistics by default.
+const DefaultMemoryGuardInterval = time.Second
+
+// MemoryGuard is a middleware handler that sheds load while the process is under memory
+// pressure. When the heap in use exceeds MaxHeap bytes, new requests get a 503 and are not
+// served. Requests to Exempt paths, such as health checks, are always served.
+//
+// Reading memory statistics is too expensive to do per request, so MemoryGuard works from the
+// sample taken by the most recent call to Sample; Start samples on a background ticker. Until
+// the first sample all requests are served.
+type MemoryGuard struct {
+	MaxHeap uint64
+	// Exempt lists path prefixes that are never shed.
+	Exempt []string
+	// ReadMemStats reads the memory statistics. Defaults to runtime.ReadMemStats.
+	ReadMemStats func(*runtime.MemStats)
+
+	heap atomic.Uint64
+}
+
+// NewMemoryGuard returns a new MemoryGuard instance shedding requests above maxHeap bytes of heap.
+func NewMemoryGuard(maxHeap uint64) *MemoryGuard {
+	return &MemoryGuard{
+		MaxHeap:      maxHeap,
+		ReadMemStats: runtime.ReadMemStats,
+	}
+}
+
+// Start samples memory statistics every interval, or DefaultMemoryGuardInterval if interval is
+// not positive, until ctx is done. The first sample is taken before Start returns.
+func (g *MemoryGuard) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultMemoryGuardInterval
+	}
+	g.Sample()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				g.Sample()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Sample reads the memory statistics and records the heap in use.
+func (g *MemoryGuard) Sample() {
+	var stats runtime.MemStats
+	if g.ReadMemStats != nil {
+		g.ReadMemStats(&stats)
+	} else {
+		runtime.ReadMemStats(&stats)
+	}
+	g.heap.Store(stats.HeapInuse)
+}
+
+// HeapInUse returns the heap in use, in bytes, as of the most recent sample.
+func (g *MemoryGuard) HeapInUse() uint64 {
+	return g.heap.Load()
+}
+
+func (g *MemoryGuard) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if g.MaxHeap > 0 && g.heap.Load() > g.MaxHeap && !g.exempt(r.URL.Path) {
+		rw.Header().Set("Retry-After", "1")
+		http.Error(rw, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+	next(rw, r)
+}
+
+func (g *MemoryGuard) exempt(path string) bool {
+	for _, prefix := range g.Exempt {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryGuard(t *testing.T) {
+	var heap atomic.Uint64
+	g := NewMemoryGuard(100)
+	g.Exempt = []string{"/health"}
+	g.ReadMemStats = func(s *runtime.MemStats) { s.HeapInuse = heap.Load() }
+	k := New(g)
+	k.UseHandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	heap.Store(500)
+	if got := status(k, "/work"); got != http.StatusOK {
+		t.Errorf("before the first sample: status = %d, want %d", got, http.StatusOK)
+	}
+
+	g.Sample()
+	if g.HeapInUse() != 500 {
+		t.Errorf("HeapInUse = %d, want 500", g.HeapInUse())
+	}
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/work", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "1" {
+		t.Errorf("under pressure: got %d with Retry-After %q, want 503 with 1", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if got := status(k, "/health"); got != http.StatusOK {
+		t.Errorf("exempt path under pressure: status = %d, want %d", got, http.StatusOK)
+	}
+
+	heap.Store(50)
+	g.Sample()
+	if got := status(k, "/work"); got != http.StatusOK {
+		t.Errorf("after recovering: status = %d, want %d", got, http.StatusOK)
+	}
+}
+
+func TestMemoryGuardStart(t *testing.T) {
+	var heap atomic.Uint64
+	heap.Store(500)
+	g := NewMemoryGuard(100)
+	g.ReadMemStats = func(s *runtime.MemStats) { s.HeapInuse = heap.Load() }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	g.Start(ctx, time.Millisecond)
+	if g.HeapInUse() != 500 {
+		t.Fatalf("HeapInUse = %d right after Start, want the first sample", g.HeapInUse())
+	}
+
+	heap.Store(50)
+	deadline := time.Now().Add(5 * time.Second)
+	for g.HeapInUse() != 50 {
+		if time.Now().After(deadline) {
+			t.Fatal("the background sampler never picked up the new heap size")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,68 @@
+package y_middleware
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+)
+
+const (
+	// DefaultMultipartMaxMemory is how much of a multipart form is kept in memory by default,
+	// with the rest of the files stored on disk.
+	DefaultMultipartMaxMemory = 32 << 20
+	// DefaultMultipartMaxSize is the largest multipart request body allowed by default.
+	DefaultMultipartMaxSize = 64 << 20
+	// DefaultMultipartMaxFiles is the number of files a multipart form may hold by default.
+	DefaultMultipartMaxFiles = 10
+)
+
+// MultipartLimit is a middleware handler that parses multipart/form-data request bodies before
+// the rest of the chain runs, so handlers find the form in r.MultipartForm. Bodies larger than
+// MaxSize get a 413, forms with more than MaxFiles files and malformed forms get a 400, and the
+// rest of the chain is skipped. Other requests pass through untouched.
+type MultipartLimit struct {
+	MaxMemory int64
+	MaxSize   int64
+	MaxFiles  int
+}
+
+// NewMultipartLimit returns a new MultipartLimit instance with the default limits.
+func NewMultipartLimit() *MultipartLimit {
+	return &MultipartLimit{
+		MaxMemory: DefaultMultipartMaxMemory,
+		MaxSize:   DefaultMultipartMaxSize,
+		MaxFiles:  DefaultMultipartMaxFiles,
+	}
+}
+
+func (m *MultipartLimit) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" {
+		next(rw, r)
+		return
+	}
+
+	if m.MaxSize > 0 {
+		r.Body = http.MaxBytesReader(rw, r.Body, m.MaxSize)
+	}
+	if err := r.ParseMultipartForm(m.MaxMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(rw, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	files := 0
+	for _, headers := range r.MultipartForm.File {
+		files += len(headers)
+	}
+	if m.MaxFiles > 0 && files > m.MaxFiles {
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	next(rw, r)
+}
@@ -0,0 +1,85 @@
+package y_middleware
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// multipartRequest builds a multipart/form-data request with a "name" field and n files of size bytes each.
+func multipartRequest(t *testing.T, n, size int) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("name", "report")
+	for i := 0; i < n; i++ {
+		fw, err := mw.CreateFormFile("file", "f"+strconv.Itoa(i)+".txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write(bytes.Repeat([]byte("x"), size))
+	}
+	mw.Close()
+	r := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+func serveMultipart(m *MultipartLimit, r *http.Request) (int, *multipart.Form) {
+	var form *multipart.Form
+	k := New(m)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		form = r.MultipartForm
+	})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, r)
+	return rec.Code, form
+}
+
+func TestMultipartLimitParsesForm(t *testing.T) {
+	status, form := serveMultipart(NewMultipartLimit(), multipartRequest(t, 2, 10))
+	if status != http.StatusOK || form == nil {
+		t.Fatalf("status = %d, form = %v", status, form)
+	}
+	if len(form.File["file"]) != 2 || form.Value["name"][0] != "report" {
+		t.Errorf("form = %+v, want 2 files and the name field", form)
+	}
+}
+
+func TestMultipartLimitRejects(t *testing.T) {
+	small := NewMultipartLimit()
+	small.MaxSize = 1 << 10
+	few := NewMultipartLimit()
+	few.MaxFiles = 2
+
+	malformed := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("garbage"))
+	malformed.Header.Set("Content-Type", "multipart/form-data; boundary=xyz")
+
+	tests := []struct {
+		name   string
+		m      *MultipartLimit
+		r      *http.Request
+		status int
+	}{
+		{"too large", small, multipartRequest(t, 1, 4<<10), http.StatusRequestEntityTooLarge},
+		{"too many files", few, multipartRequest(t, 3, 10), http.StatusBadRequest},
+		{"malformed", NewMultipartLimit(), malformed, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if status, form := serveMultipart(tt.m, tt.r); status != tt.status || form != nil {
+			t.Errorf("%s: status = %d, handler ran = %v, want %d", tt.name, status, form != nil, tt.status)
+		}
+	}
+}
+
+func TestMultipartLimitPassesThrough(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"a":1}`))
+	r.Header.Set("Content-Type", "application/json")
+	if status, form := serveMultipart(NewMultipartLimit(), r); status != http.StatusOK || form != nil {
+		t.Errorf("JSON request: status = %d, form = %v, want it untouched", status, form)
+	}
+}
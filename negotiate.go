@@ -0,0 +1,144 @@
+package y_middleware
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Encoder writes response values in one media type.
+type Encoder interface {
+	// ContentType returns the media type the encoder produces, e.g. "application/json".
+	ContentType() string
+	Encode(w io.Writer, v any) error
+}
+
+// JSONEncoder is an Encoder producing application/json with encoding/json. It also decodes
+// request bodies, keeping numbers as json.Number when UseNumber is set; Negotiate sets it from
+// the request's JSONNumberMode.
+type JSONEncoder struct {
+	UseNumber bool
+}
+
+func (JSONEncoder) ContentType() string { return "application/json" }
+
+func (JSONEncoder) Encode(w io.Writer, v any) error { return json.NewEncoder(w).Encode(v) }
+
+// Decode decodes the JSON value in r into v.
+func (e JSONEncoder) Decode(r io.Reader, v any) error {
+	dec := json.NewDecoder(r)
+	if e.UseNumber {
+		dec.UseNumber()
+	}
+	return dec.Decode(v)
+}
+
+// XMLEncoder is an Encoder producing application/xml with encoding/xml.
+type XMLEncoder struct{}
+
+func (XMLEncoder) ContentType() string { return "application/xml" }
+
+func (XMLEncoder) Encode(w io.Writer, v any) error { return xml.NewEncoder(w).Encode(v) }
+
+type encoderKey struct{}
+
+// Negotiate is a middleware handler that picks the registered Encoder that best matches the
+// request's Accept header and stores it in the request context, where handlers read it with
+// EncoderFrom instead of hardcoding a format. Requests without an Accept header get the first
+// encoder; requests accepting none of them get a 406 and are not served. Ties go to the encoder
+// registered first.
+type Negotiate struct {
+	Encoders []Encoder
+}
+
+// NewNegotiate returns a new Negotiate instance choosing between encoders.
+func NewNegotiate(encoders ...Encoder) *Negotiate {
+	return &Negotiate{Encoders: encoders}
+}
+
+func (n *Negotiate) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	addVary(rw.Header(), "Accept")
+
+	enc := n.choose(r.Header.Get("Accept"))
+	if enc == nil {
+		http.Error(rw, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+		return
+	}
+	switch je := enc.(type) {
+	case JSONEncoder:
+		je.UseNumber = UseJSONNumber(r.Context())
+		enc = je
+	case *JSONEncoder:
+		// Copy so the encoder registered with Negotiate is not shared between requests.
+		c := *je
+		c.UseNumber = UseJSONNumber(r.Context())
+		enc = &c
+	}
+	next(rw, r.WithContext(context.WithValue(r.Context(), encoderKey{}, enc)))
+}
+
+func (n *Negotiate) choose(accept string) Encoder {
+	if len(n.Encoders) == 0 {
+		return nil
+	}
+	if strings.TrimSpace(accept) == "" {
+		return n.Encoders[0]
+	}
+
+	var best Encoder
+	bestQ := 0.0
+	for _, enc := range n.Encoders {
+		if q := mediaTypeQuality(accept, enc.ContentType()); q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+// mediaTypeQuality returns the quality accept gives mediaType, taken from the most specific
+// matching media range, or zero if none matches.
+func mediaTypeQuality(accept, mediaType string) float64 {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	quality, specificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		s := -1
+		switch name {
+		case mediaType:
+			s = 2
+		case mainType + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		}
+		if s <= specificity {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		quality, specificity = q, s
+	}
+	return quality
+}
+
+// EncoderFrom returns the Encoder Negotiate chose for the request, or a JSONEncoder without a
+// Negotiate in the chain.
+func EncoderFrom(ctx context.Context) Encoder {
+	if enc, ok := ctx.Value(encoderKey{}).(Encoder); ok {
+		return enc
+	}
+	return JSONEncoder{UseNumber: UseJSONNumber(ctx)}
+}
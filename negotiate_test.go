@@ -0,0 +1,118 @@
+package y_middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func negotiated(t *testing.T, k *Kudret, accept string) (Encoder, *httptest.ResponseRecorder) {
+	t.Helper()
+	var got Encoder
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		got = EncoderFrom(r.Context())
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, req)
+	return got, rec
+}
+
+func TestNegotiateChoosesEncoder(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", "application/json"},
+		{"application/xml", "application/xml"},
+		{"application/json;q=0.5, application/xml", "application/xml"},
+		{"text/html, */*;q=0.1", "application/json"},
+		{"application/*", "application/json"},
+	}
+	for _, tt := range tests {
+		enc, _ := negotiated(t, New(NewNegotiate(JSONEncoder{}, XMLEncoder{})), tt.accept)
+		if enc == nil || enc.ContentType() != tt.want {
+			t.Errorf("Accept %q chose %v, want %s", tt.accept, enc, tt.want)
+		}
+	}
+}
+
+func TestNegotiateNotAcceptable(t *testing.T) {
+	enc, rec := negotiated(t, New(NewNegotiate(JSONEncoder{})), "text/html")
+	if rec.Code != http.StatusNotAcceptable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotAcceptable)
+	}
+	if enc != nil {
+		t.Error("handler was served without an acceptable encoder")
+	}
+	if got := rec.Header().Get("Vary"); got != "Accept" {
+		t.Errorf("Vary = %q, want Accept", got)
+	}
+}
+
+func TestNegotiateJSONNumberMode(t *testing.T) {
+	enc, _ := negotiated(t, New(NewJSONNumberMode(true), NewNegotiate(JSONEncoder{})), "")
+	if je, ok := enc.(JSONEncoder); !ok || !je.UseNumber {
+		t.Errorf("encoder = %#v, want JSONEncoder with UseNumber", enc)
+	}
+
+	shared := &JSONEncoder{}
+	enc, _ = negotiated(t, New(NewJSONNumberMode(true), NewNegotiate(shared)), "")
+	if je, ok := enc.(*JSONEncoder); !ok || !je.UseNumber {
+		t.Errorf("encoder = %#v, want *JSONEncoder with UseNumber", enc)
+	}
+	if shared.UseNumber {
+		t.Error("Negotiate modified the registered *JSONEncoder")
+	}
+}
+
+func TestMediaTypeQuality(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   float64
+	}{
+		{"application/json", 1},
+		{"APPLICATION/JSON;q=0.4", 0.4},
+		{"*/*;q=0.2, application/*;q=0.5", 0.5},
+		{"application/json;q=0, */*", 0},
+		{"application/json;q=bad", 1},
+		{"text/html", 0},
+	}
+	for _, tt := range tests {
+		if got := mediaTypeQuality(tt.accept, "application/json"); got != tt.want {
+			t.Errorf("mediaTypeQuality(%q) = %v, want %v", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestNegotiateExcludesZeroQuality(t *testing.T) {
+	enc, _ := negotiated(t, New(NewNegotiate(JSONEncoder{}, XMLEncoder{})), "application/json;q=0, */*;q=0.1")
+	if enc == nil || enc.ContentType() != "application/xml" {
+		t.Errorf("chose %v, want application/xml", enc)
+	}
+	if _, rec := negotiated(t, New(NewNegotiate()), ""); rec.Code != http.StatusNotAcceptable {
+		t.Errorf("no encoders: status = %d, want %d", rec.Code, http.StatusNotAcceptable)
+	}
+}
+
+func TestEncoderFromDefault(t *testing.T) {
+	var buf bytes.Buffer
+	enc := EncoderFrom(context.Background())
+	if err := enc.Encode(&buf, map[string]int{"a": 1}); err != nil || buf.String() != "{\"a\":1}\n" {
+		t.Errorf("default encoder wrote %q, %v, want JSON", buf.String(), err)
+	}
+
+	buf.Reset()
+	type item struct {
+		Name string `xml:"name"`
+	}
+	if err := (XMLEncoder{}).Encode(&buf, item{"a"}); err != nil || buf.String() != "<item><name>a</name></item>" {
+		t.Errorf("XMLEncoder wrote %q, %v", buf.String(), err)
+	}
+}
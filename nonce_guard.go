@@ -0,0 +1,138 @@
+package y_middleware
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultNonceTTL is how long NonceGuard remembers a nonce by default. It should be at least
+	// as long as the window signed requests are accepted in, see HMACVerify.MaxSkew.
+	DefaultNonceTTL = 10 * time.Minute
+	// DefaultNonceMaxLength is the longest nonce NonceGuard accepts by default.
+	DefaultNonceMaxLength = 128
+	// DefaultNonceStoreMaxEntries is how many nonces the in-memory NonceStore holds by default.
+	DefaultNonceStoreMaxEntries = 100000
+)
+
+// ErrNonceStoreFull is returned by a NonceStore that cannot record any more nonces.
+var ErrNonceStoreFull = errors.New("nonce store full")
+
+// NonceStore records the nonces NonceGuard has seen. Implementations must be safe for
+// concurrent use.
+type NonceStore interface {
+	// Add records nonce for the duration of ttl, now being the current time by the clock of the
+	// NonceGuard; stores that expire entries themselves may ignore it. It reports false if
+	// nonce was already recorded and has not expired yet.
+	Add(nonce string, now time.Time, ttl time.Duration) (bool, error)
+}
+
+// NonceGuard is a middleware handler that protects against replayed requests. Every request
+// must carry a unique nonce in Header, at most MaxLength bytes long; requests without a valid
+// one get a 400 and requests reusing a nonce seen within TTL get a 409, and the rest of the
+// chain is skipped. When the store is full, requests get a 503 until nonces expire. Use it
+// after HMACVerify so the nonce is covered by the signature and only authenticated clients can
+// fill the store.
+type NonceGuard struct {
+	Header    string
+	TTL       time.Duration
+	MaxLength int
+	Store     NonceStore
+
+	clock Clock
+}
+
+// NewNonceGuard returns a new NonceGuard instance recording nonces in store. A nil store uses
+// an in-memory one holding up to DefaultNonceStoreMaxEntries nonces.
+func NewNonceGuard(store NonceStore) *NonceGuard {
+	if store == nil {
+		store = NewNonceMemoryStore(DefaultNonceStoreMaxEntries)
+	}
+	return &NonceGuard{
+		Header:    "X-Nonce",
+		TTL:       DefaultNonceTTL,
+		MaxLength: DefaultNonceMaxLength,
+		Store:     store,
+	}
+}
+
+// WithClock makes the NonceGuard read the time from c instead of the system clock.
+func (g *NonceGuard) WithClock(c Clock) *NonceGuard {
+	g.clock = c
+	return g
+}
+
+func (g *NonceGuard) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	nonce := r.Header.Get(g.Header)
+	if nonce == "" || g.MaxLength > 0 && len(nonce) > g.MaxLength {
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	fresh, err := g.Store.Add(nonce, clockOrDefault(g.clock).Now(), g.TTL)
+	if err != nil {
+		http.Error(rw, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+	if !fresh {
+		http.Error(rw, http.StatusText(http.StatusConflict), http.StatusConflict)
+		return
+	}
+	next(rw, r)
+}
+
+type nonceMemoryStore struct {
+	mu         sync.Mutex
+	expires    map[string]time.Time
+	queue      []nonceEntry
+	maxEntries int
+}
+
+// nonceEntry is a recorded nonce in the order it was added, which is the order nonces expire
+// in for a fixed TTL.
+type nonceEntry struct {
+	nonce   string
+	expires time.Time
+}
+
+// NewNonceMemoryStore returns a NonceStore that keeps up to maxEntries nonces in memory, or any
+// number if maxEntries is zero. Expired nonces are dropped as new ones are added; while the
+// store is full, Add fails with ErrNonceStoreFull. Nonces are never evicted early, as that
+// would let them be replayed.
+func NewNonceMemoryStore(maxEntries int) NonceStore {
+	return &nonceMemoryStore{
+		expires:    make(map[string]time.Time),
+		maxEntries: maxEntries,
+	}
+}
+
+func (s *nonceMemoryStore) Add(nonce string, now time.Time, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire(now)
+	if exp, ok := s.expires[nonce]; ok && now.Before(exp) {
+		return false, nil
+	}
+	if s.maxEntries > 0 && len(s.expires) >= s.maxEntries {
+		return false, ErrNonceStoreFull
+	}
+	e := nonceEntry{nonce: nonce, expires: now.Add(ttl)}
+	s.expires[nonce] = e.expires
+	s.queue = append(s.queue, e)
+	return true, nil
+}
+
+// expire drops the nonces at the front of the queue that expired by now.
+func (s *nonceMemoryStore) expire(now time.Time) {
+	i := 0
+	for ; i < len(s.queue) && !now.Before(s.queue[i].expires); i++ {
+		e := s.queue[i]
+		// The nonce may have been added again since.
+		if exp, ok := s.expires[e.nonce]; ok && exp.Equal(e.expires) {
+			delete(s.expires, e.nonce)
+		}
+	}
+	s.queue = s.queue[i:]
+}
@@ -0,0 +1,88 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func nonceStatus(g *NonceGuard, nonce string) int {
+	r := httptest.NewRequest(http.MethodPost, "/transfer", nil)
+	if nonce != "" {
+		r.Header.Set("X-Nonce", nonce)
+	}
+	rec := httptest.NewRecorder()
+	g.ServeHTTP(rec, r, func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusAccepted)
+	})
+	return rec.Code
+}
+
+func TestNonceGuardAcceptsFirstUse(t *testing.T) {
+	g := NewNonceGuard(nil)
+	if code := nonceStatus(g, "n1"); code != http.StatusAccepted {
+		t.Errorf("first use got %d, want 202", code)
+	}
+	if code := nonceStatus(g, "n2"); code != http.StatusAccepted {
+		t.Errorf("other nonce got %d, want 202", code)
+	}
+}
+
+func TestNonceGuardRejectsReplay(t *testing.T) {
+	g := NewNonceGuard(nil)
+	nonceStatus(g, "n1")
+	if code := nonceStatus(g, "n1"); code != http.StatusConflict {
+		t.Errorf("replay got %d, want 409", code)
+	}
+}
+
+func TestNonceGuardRejectsMissingAndOverlongNonces(t *testing.T) {
+	g := NewNonceGuard(nil)
+	if code := nonceStatus(g, ""); code != http.StatusBadRequest {
+		t.Errorf("missing nonce got %d, want 400", code)
+	}
+	if code := nonceStatus(g, strings.Repeat("n", DefaultNonceMaxLength+1)); code != http.StatusBadRequest {
+		t.Errorf("overlong nonce got %d, want 400", code)
+	}
+}
+
+func TestNonceGuardForgetsExpiredNonces(t *testing.T) {
+	clock := newFakeClock()
+	g := NewNonceGuard(nil).WithClock(clock)
+	nonceStatus(g, "n1")
+
+	clock.Advance(DefaultNonceTTL - time.Second)
+	if code := nonceStatus(g, "n1"); code != http.StatusConflict {
+		t.Errorf("replay within the TTL got %d, want 409", code)
+	}
+	clock.Advance(time.Second)
+	if code := nonceStatus(g, "n1"); code != http.StatusAccepted {
+		t.Errorf("reuse after the TTL got %d, want 202", code)
+	}
+}
+
+func TestNonceMemoryStoreIsBounded(t *testing.T) {
+	clock := newFakeClock()
+	g := NewNonceGuard(NewNonceMemoryStore(3)).WithClock(clock)
+	for i := 0; i < 3; i++ {
+		nonceStatus(g, "n"+strconv.Itoa(i))
+	}
+	if code := nonceStatus(g, "n3"); code != http.StatusServiceUnavailable {
+		t.Errorf("nonce beyond capacity got %d, want 503", code)
+	}
+	if code := nonceStatus(g, "n0"); code != http.StatusConflict {
+		t.Errorf("replay while full got %d, want 409", code)
+	}
+
+	clock.Advance(DefaultNonceTTL)
+	if code := nonceStatus(g, "n3"); code != http.StatusAccepted {
+		t.Errorf("nonce after the others expired got %d, want 202", code)
+	}
+	store := g.Store.(*nonceMemoryStore)
+	if len(store.expires) != 1 || len(store.queue) != 1 {
+		t.Errorf("store holds %d nonces in a queue of %d, want the expired ones dropped", len(store.expires), len(store.queue))
+	}
+}
@@ -0,0 +1,112 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// DedupeMode selects what NormalizeQuery does with repeated query parameters.
+type DedupeMode int
+
+const (
+	// DedupeNone keeps every occurrence of a parameter.
+	DedupeNone DedupeMode = iota
+	// DedupeKeepFirst keeps only the first occurrence of a parameter.
+	DedupeKeepFirst
+	// DedupeKeepLast keeps only the last occurrence of a parameter.
+	DedupeKeepLast
+)
+
+// NormalizeQuery is a middleware handler that rewrites r.URL.RawQuery into a canonical form
+// so cache keys and signatures computed downstream are stable. Each step is opt-in.
+// Values are kept exactly as they were encoded.
+type NormalizeQuery struct {
+	// LowercaseKeys lowercases parameter names.
+	LowercaseKeys bool
+	// Dedupe drops repeated parameters.
+	Dedupe DedupeMode
+	// Sort orders parameters by name. Repeated parameters keep their relative order.
+	Sort bool
+}
+
+// NewNormalizeQuery returns a new NormalizeQuery instance that changes nothing until configured.
+func NewNormalizeQuery() *NormalizeQuery {
+	return &NormalizeQuery{}
+}
+
+type queryParam struct {
+	key string
+	raw string
+}
+
+func (n *NormalizeQuery) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.URL.RawQuery != "" {
+		r.URL.RawQuery = n.normalize(r.URL.RawQuery)
+	}
+	next(rw, r)
+}
+
+func (n *NormalizeQuery) normalize(rawQuery string) string {
+	var params []queryParam
+	for _, raw := range strings.Split(rawQuery, "&") {
+		if raw == "" {
+			continue
+		}
+		rawKey, value, hasValue := strings.Cut(raw, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+		if n.LowercaseKeys {
+			key = strings.ToLower(key)
+			rawKey = url.QueryEscape(key)
+			raw = rawKey
+			if hasValue {
+				raw += "=" + value
+			}
+		}
+		params = append(params, queryParam{key: key, raw: raw})
+	}
+
+	switch n.Dedupe {
+	case DedupeKeepFirst:
+		params = dedupeParams(params)
+	case DedupeKeepLast:
+		reverseParams(params)
+		params = dedupeParams(params)
+		reverseParams(params)
+	}
+
+	if n.Sort {
+		sort.SliceStable(params, func(i, j int) bool {
+			return params[i].key < params[j].key
+		})
+	}
+
+	raws := make([]string, len(params))
+	for i, p := range params {
+		raws[i] = p.raw
+	}
+	return strings.Join(raws, "&")
+}
+
+// dedupeParams keeps the first occurrence of each key.
+func dedupeParams(params []queryParam) []queryParam {
+	seen := make(map[string]bool, len(params))
+	kept := params[:0]
+	for _, p := range params {
+		if !seen[p.key] {
+			seen[p.key] = true
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+func reverseParams(params []queryParam) {
+	for i, j := 0, len(params)-1; i < j; i, j = i+1, j-1 {
+		params[i], params[j] = params[j], params[i]
+	}
+}
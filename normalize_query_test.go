@@ -0,0 +1,36 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNormalizeQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		n     NormalizeQuery
+		query string
+		want  string
+	}{
+		{"unconfigured", NormalizeQuery{}, "b=2&A=1&&b=3", "b=2&A=1&b=3"},
+		{"lowercase keys", NormalizeQuery{LowercaseKeys: true}, "Q=Hello%20World&FLAG", "q=Hello%20World&flag"},
+		{"keep first", NormalizeQuery{Dedupe: DedupeKeepFirst}, "a=1&b=2&a=3", "a=1&b=2"},
+		{"keep last", NormalizeQuery{Dedupe: DedupeKeepLast}, "a=1&b=2&a=3", "b=2&a=3"},
+		{"sort", NormalizeQuery{Sort: true}, "c=1&a=2&b=3&a=1", "a=2&a=1&b=3&c=1"},
+		{"encoded keys", NormalizeQuery{Sort: true, Dedupe: DedupeKeepFirst}, "b=1&%61=2&a=3", "%61=2&b=1"},
+		{"everything", NormalizeQuery{LowercaseKeys: true, Dedupe: DedupeKeepLast, Sort: true}, "B=1&a=2&b=3", "a=2&b=3"},
+	}
+	for _, tt := range tests {
+		n := tt.n
+		var got string
+		k := New(&n)
+		k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			got = r.URL.RawQuery
+		})
+		k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil))
+		if got != tt.want {
+			t.Errorf("%s: query = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
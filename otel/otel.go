@@ -0,0 +1,94 @@
+// Package otel exports the requests served by a y_middleware stack as OpenTelemetry spans. It
+// is its own module so that only programs using it depend on OpenTelemetry.
+package otel
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	y_middleware "github.com/YusufSert/y_middleware"
+)
+
+const instrumentationName = "github.com/YusufSert/y_middleware/otel"
+
+// Option configures an OTelMiddleware.
+type Option func(*OTelMiddleware)
+
+// WithTracerProvider makes the OTelMiddleware start its spans with tp instead of the global
+// tracer provider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(m *OTelMiddleware) {
+		m.provider = tp
+	}
+}
+
+// OTelMiddleware is a middleware handler that starts a server span for every request and ends
+// it once the rest of the chain returns. When a y_middleware.TraceContext handler runs before
+// it, the span continues the caller's trace. The span is named after the route template stored
+// by a y_middleware.RouteLabel running before it, falling back to the request method, and
+// records the response status; 5xx responses and the errors recorded with
+// y_middleware.AddError mark it as failed.
+type OTelMiddleware struct {
+	provider trace.TracerProvider
+	tracer   trace.Tracer
+}
+
+// New returns a new OTelMiddleware instance.
+func New(opts ...Option) *OTelMiddleware {
+	m := &OTelMiddleware{}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.provider == nil {
+		m.provider = otel.GetTracerProvider()
+	}
+	m.tracer = m.provider.Tracer(instrumentationName)
+	return m
+}
+
+func (m *OTelMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	ctx := r.Context()
+	if tp, ok := y_middleware.TraceParentFrom(ctx); ok {
+		ctx = trace.ContextWithRemoteSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID(tp.TraceID),
+			SpanID:     trace.SpanID(tp.ParentID),
+			TraceFlags: trace.TraceFlags(tp.Flags),
+			Remote:     true,
+		}))
+	}
+
+	name := r.Method
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", r.Method),
+		attribute.String("url.path", r.URL.Path),
+	}
+	if route := y_middleware.RouteTemplate(ctx); route != "" {
+		name += " " + route
+		attrs = append(attrs, attribute.String("http.route", route))
+	}
+	ctx, span := m.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+	defer span.End()
+
+	res, ok := rw.(y_middleware.ResponseWriter)
+	if !ok {
+		res = y_middleware.NewResponseWriter(rw)
+	}
+	r = r.WithContext(ctx)
+	next(res, r)
+
+	status := res.Status()
+	span.SetAttributes(attribute.Int("http.response.status_code", status))
+	errs := y_middleware.Errors(ctx)
+	for _, err := range errs {
+		span.RecordError(err)
+	}
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	} else if len(errs) > 0 {
+		span.SetStatus(codes.Error, "request recorded errors")
+	}
+}
@@ -0,0 +1,98 @@
+package otel
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	y_middleware "github.com/YusufSert/y_middleware"
+)
+
+// serveSpan serves r through handlers followed by an OTelMiddleware and h, and returns the
+// single span it recorded.
+func serveSpan(t *testing.T, r *http.Request, h http.HandlerFunc, handlers ...y_middleware.Handler) sdktrace.ReadOnlySpan {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer tp.Shutdown(r.Context())
+
+	k := y_middleware.New(append(handlers, New(WithTracerProvider(tp)))...)
+	k.UseHandlerFunc(h)
+	k.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := exporter.GetSpans().Snapshots()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	return spans[0]
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestSpanForRoute(t *testing.T) {
+	var inHandler trace.SpanContext
+	span := serveSpan(t, httptest.NewRequest(http.MethodGet, "/users/42", nil), func(rw http.ResponseWriter, r *http.Request) {
+		inHandler = trace.SpanContextFromContext(r.Context())
+		rw.WriteHeader(http.StatusCreated)
+	}, y_middleware.NewRouteLabel("/users/:id"))
+
+	if span.Name() != "GET /users/:id" || span.SpanKind() != trace.SpanKindServer {
+		t.Errorf("span %q of kind %v, want server span GET /users/:id", span.Name(), span.SpanKind())
+	}
+	attrs := attributes(span)
+	if attrs["http.route"].AsString() != "/users/:id" || attrs["url.path"].AsString() != "/users/42" ||
+		attrs["http.response.status_code"].AsInt64() != http.StatusCreated {
+		t.Errorf("attributes = %v", attrs)
+	}
+	if span.Status().Code != codes.Unset {
+		t.Errorf("status = %v, want unset for a 201", span.Status())
+	}
+	if inHandler.SpanID() != span.SpanContext().SpanID() {
+		t.Error("the handler did not see the request span in its context")
+	}
+}
+
+func TestSpanContinuesTraceParent(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	span := serveSpan(t, r, func(http.ResponseWriter, *http.Request) {}, y_middleware.NewTraceContext())
+
+	if got := span.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace ID = %s, want the caller's", got)
+	}
+	if got := span.Parent().SpanID().String(); got != "00f067aa0ba902b7" || !span.Parent().IsRemote() {
+		t.Errorf("parent = %s (remote %v), want the caller's span", got, span.Parent().IsRemote())
+	}
+	if span.Name() != "GET" {
+		t.Errorf("span name = %q without a route, want GET", span.Name())
+	}
+}
+
+func TestSpanErrors(t *testing.T) {
+	span := serveSpan(t, httptest.NewRequest(http.MethodGet, "/", nil), func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusBadGateway)
+	})
+	if span.Status().Code != codes.Error || span.Status().Description != "Bad Gateway" {
+		t.Errorf("5xx status = %v, want an error", span.Status())
+	}
+
+	span = serveSpan(t, httptest.NewRequest(http.MethodGet, "/", nil), func(rw http.ResponseWriter, r *http.Request) {
+		y_middleware.AddError(r.Context(), errors.New("cache miss"))
+	}, y_middleware.NewSoftRecovery(nil))
+	if span.Status().Code != codes.Error || len(span.Events()) != 1 || span.Events()[0].Name != "exception" {
+		t.Errorf("status = %v with events %v, want the recorded error", span.Status(), span.Events())
+	}
+}
@@ -0,0 +1,96 @@
+package y_middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// DefaultPaginationLimit is the page size used when a request asks for none.
+	DefaultPaginationLimit = 20
+	// DefaultPaginationMaxLimit is the largest page size allowed by default.
+	DefaultPaginationMaxLimit = 100
+)
+
+type pageKey struct{}
+
+type page struct {
+	offset, limit int
+}
+
+// Pagination is a middleware handler that parses the pagination parameters of list requests and
+// stores them in the request context, where handlers read them with PageFrom. The position is
+// given either as a zero-based "offset" or as a one-based "page", and the page size as "limit".
+// Out of range values are clamped: limit to [MinLimit, MaxLimit], offset and page to their
+// lowest values, and page also to the highest one whose offset fits in an int. Parameters that
+// are not integers get a 400 and the rest of the chain is skipped.
+type Pagination struct {
+	DefaultLimit int
+	MinLimit     int
+	MaxLimit     int
+}
+
+// NewPagination returns a new Pagination instance with the default limits.
+func NewPagination() *Pagination {
+	return &Pagination{
+		DefaultLimit: DefaultPaginationLimit,
+		MinLimit:     1,
+		MaxLimit:     DefaultPaginationMaxLimit,
+	}
+}
+
+func (p *Pagination) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	pg, ok := p.parse(r)
+	if !ok {
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	next(rw, r.WithContext(context.WithValue(r.Context(), pageKey{}, pg)))
+}
+
+func (p *Pagination) parse(r *http.Request) (page, bool) {
+	query := r.URL.Query()
+
+	limit := p.DefaultLimit
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return page{}, false
+		}
+		limit = n
+	}
+	limit = max(limit, p.MinLimit)
+	if p.MaxLimit > 0 {
+		limit = min(limit, p.MaxLimit)
+	}
+
+	offset := 0
+	if v := query.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return page{}, false
+		}
+		offset = max(n, 0)
+	} else if v := query.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return page{}, false
+		}
+		pages := max(n, 1) - 1
+		if limit > 0 {
+			// Clamp so a huge page number can't overflow the offset.
+			pages = min(pages, math.MaxInt/limit)
+		}
+		offset = pages * limit
+	}
+	return page{offset: offset, limit: limit}, true
+}
+
+// PageFrom returns the offset and limit Pagination parsed for the request. Without a Pagination
+// in the chain it returns zero for both.
+func PageFrom(ctx context.Context) (offset, limit int) {
+	pg, _ := ctx.Value(pageKey{}).(page)
+	return pg.offset, pg.limit
+}
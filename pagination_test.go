@@ -0,0 +1,54 @@
+package y_middleware
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPagination(t *testing.T) {
+	tests := []struct {
+		query         string
+		offset, limit int
+	}{
+		{"", 0, DefaultPaginationLimit},
+		{"limit=10&offset=30", 30, 10},
+		{"limit=10&page=4", 30, 10},
+		{"limit=1000", 0, DefaultPaginationMaxLimit},
+		{"limit=0&offset=-5", 0, 1},
+		{"page=0", 0, DefaultPaginationLimit},
+		{"limit=100&page=9223372036854775807", math.MaxInt / 100 * 100, 100},
+	}
+	for _, tt := range tests {
+		var offset, limit int
+		k := New(NewPagination())
+		k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			offset, limit = PageFrom(r.Context())
+		})
+
+		rec := httptest.NewRecorder()
+		k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%q: status = %d, want %d", tt.query, rec.Code, http.StatusOK)
+		}
+		if offset != tt.offset || limit != tt.limit {
+			t.Errorf("%q: offset, limit = %d, %d, want %d, %d", tt.query, offset, limit, tt.offset, tt.limit)
+		}
+	}
+}
+
+func TestPaginationRejectsInvalidParameters(t *testing.T) {
+	for _, query := range []string{"limit=ten", "offset=x", "page=1.5"} {
+		k := New(NewPagination())
+		k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			t.Errorf("%q: handler was served", query)
+		})
+
+		rec := httptest.NewRecorder()
+		k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%q: status = %d, want %d", query, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
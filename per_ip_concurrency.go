@@ -0,0 +1,60 @@
+package y_middleware
+
+import (
+	"net/http"
+	"sync"
+)
+
+// PerIPConcurrency is a middleware handler that limits how many requests from a single client
+// IP may be in flight at once, so one client cannot monopolize the server. Requests over the
+// limit get a 429 and are not served. The client IP is taken from r.RemoteAddr, so handlers
+// that resolve the real client address behind proxies must run before it.
+type PerIPConcurrency struct {
+	max int
+
+	mu       sync.Mutex
+	inFlight map[string]int
+}
+
+// NewPerIPConcurrency returns a new PerIPConcurrency instance allowing max requests per IP at once.
+func NewPerIPConcurrency(max int) *PerIPConcurrency {
+	return &PerIPConcurrency{
+		max:      max,
+		inFlight: make(map[string]int),
+	}
+}
+
+// InFlight returns the number of requests currently in flight from ip.
+func (p *PerIPConcurrency) InFlight(ip string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.inFlight[ip]
+}
+
+func (p *PerIPConcurrency) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	ip := clientIP(r)
+	if !p.acquire(ip) {
+		http.Error(rw, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		return
+	}
+	defer p.release(ip)
+	next(rw, r)
+}
+
+func (p *PerIPConcurrency) acquire(ip string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.inFlight[ip] >= p.max {
+		return false
+	}
+	p.inFlight[ip]++
+	return true
+}
+
+func (p *PerIPConcurrency) release(ip string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.inFlight[ip]--; p.inFlight[ip] <= 0 {
+		delete(p.inFlight, ip)
+	}
+}
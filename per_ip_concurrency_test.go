@@ -0,0 +1,64 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func statusFrom(k *Kudret, remoteAddr string) int {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, r)
+	return rec.Code
+}
+
+func TestPerIPConcurrencyCapsConcurrentRequests(t *testing.T) {
+	p := NewPerIPConcurrency(3)
+	k, started, release := blockingStack(p)
+
+	const n = 10
+	codes := make(chan int, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- statusFrom(k, "10.0.0.1:1234")
+		}()
+	}
+	for i := 0; i < 3; i++ {
+		<-started
+	}
+	for i := 0; i < n-3; i++ {
+		if code := <-codes; code != http.StatusTooManyRequests {
+			t.Errorf("request over the cap: status = %d, want %d", code, http.StatusTooManyRequests)
+		}
+	}
+	if got := p.InFlight("10.0.0.1"); got != 3 {
+		t.Errorf("InFlight = %d, want 3", got)
+	}
+
+	// Other clients are not affected by the first one's requests.
+	other := holdRequests(k, 1, "/", started)
+	if got := p.InFlight("192.0.2.1"); got != 1 {
+		t.Errorf("InFlight of another client = %d, want 1", got)
+	}
+
+	close(release)
+	wg.Wait()
+	other.Wait()
+	for i := 0; i < 3; i++ {
+		if code := <-codes; code != http.StatusOK {
+			t.Errorf("request under the cap: status = %d, want %d", code, http.StatusOK)
+		}
+	}
+	if got := p.InFlight("10.0.0.1"); got != 0 {
+		t.Errorf("InFlight = %d after every request finished, want 0", got)
+	}
+	if len(p.inFlight) != 0 {
+		t.Errorf("%d idle clients still tracked, want none", len(p.inFlight))
+	}
+}
@@ -0,0 +1,62 @@
+package y_middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+)
+
+// DefaultPrettyJSONMaxSize is the largest JSON body PrettyJSON reformats by default.
+const DefaultPrettyJSONMaxSize = 1 << 20
+
+// PrettyJSON is a development middleware handler that indents application/json responses to
+// make manual API debugging easier. It only reformats responses when Always is set or the
+// request carries the Header. Non-JSON responses, and bodies over MaxSize, pass through untouched.
+type PrettyJSON struct {
+	// Always reformats every JSON response.
+	Always bool
+	// Header is the request header that asks for a reformatted response.
+	Header string
+	// Indent is the indentation used for each level.
+	Indent string
+	// MaxSize is the largest body that is reformatted. Zero means no limit.
+	MaxSize int
+}
+
+// NewPrettyJSON returns a new PrettyJSON instance that reformats responses to requests
+// carrying an X-Pretty-JSON header.
+func NewPrettyJSON() *PrettyJSON {
+	return &PrettyJSON{
+		Header:  "X-Pretty-JSON",
+		Indent:  "  ",
+		MaxSize: DefaultPrettyJSONMaxSize,
+	}
+}
+
+func (p *PrettyJSON) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !p.Always && (p.Header == "" || r.Header.Get(p.Header) == "") {
+		next(rw, r)
+		return
+	}
+
+	buf, r := newResponseBuffer(rw, r, p.MaxSize)
+	next(buf, r)
+	if buf.Streamed() {
+		return
+	}
+	if !buf.Written() {
+		buf.copyHeader(rw)
+		return
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(buf.Header().Get("Content-Type")); mediaType == "application/json" {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, buf.Bytes(), "", p.Indent); err == nil {
+			indented.WriteByte('\n')
+			buf.replaceBody(indented.Bytes())
+			buf.Header().Del("Content-Length")
+		}
+	}
+	buf.flush(rw)
+}
@@ -0,0 +1,63 @@
+package y_middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func servePrettyJSON(p *PrettyJSON, header bool, contentType, body string) *httptest.ResponseRecorder {
+	k := New(p)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", contentType)
+		io.WriteString(rw, body)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if header {
+		req.Header.Set("X-Pretty-JSON", "1")
+	}
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestPrettyJSONIndents(t *testing.T) {
+	rec := servePrettyJSON(NewPrettyJSON(), true, "application/json; charset=utf-8", `{"a":[1,2]}`)
+	want := "{\n  \"a\": [\n    1,\n    2\n  ]\n}\n"
+	if rec.Body.String() != want {
+		t.Errorf("body = %q, want %q", rec.Body, want)
+	}
+}
+
+func TestPrettyJSONPassesThrough(t *testing.T) {
+	small := NewPrettyJSON()
+	small.MaxSize = 4
+
+	tests := []struct {
+		name        string
+		p           *PrettyJSON
+		header      bool
+		contentType string
+		body        string
+	}{
+		{"no header", NewPrettyJSON(), false, "application/json", `{"a":1}`},
+		{"not JSON", NewPrettyJSON(), true, "text/plain", `{"a":1}`},
+		{"invalid JSON", NewPrettyJSON(), true, "application/json", `{"a":`},
+		{"over MaxSize", small, true, "application/json", `{"a":1}`},
+	}
+	for _, tt := range tests {
+		if rec := servePrettyJSON(tt.p, tt.header, tt.contentType, tt.body); rec.Body.String() != tt.body {
+			t.Errorf("%s: body = %q, want it untouched", tt.name, rec.Body)
+		}
+	}
+}
+
+func TestPrettyJSONAlways(t *testing.T) {
+	p := NewPrettyJSON()
+	p.Always = true
+	p.Indent = "\t"
+	if rec := servePrettyJSON(p, false, "application/json", `{"a":1}`); rec.Body.String() != "{\n\t\"a\": 1\n}\n" {
+		t.Errorf("body = %q, want it indented with tabs", rec.Body)
+	}
+}
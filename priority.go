@@ -0,0 +1,71 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultPriorityUrgency is the urgency RFC 9218 assigns requests without a Priority header.
+const DefaultPriorityUrgency = 3
+
+type priorityKey struct{}
+
+// RequestPriority is the priority of a request as defined by RFC 9218. Urgency ranges from 0,
+// the most urgent, to 7.
+type RequestPriority struct {
+	Urgency     int
+	Incremental bool
+}
+
+// Priority is a middleware handler that parses the Priority request header (RFC 9218) and stores
+// the result in the request context, where handlers read it with PriorityFrom. Requests without
+// the header, and members of it that are malformed, get DefaultUrgency and a non-incremental
+// priority. LoadShed uses the priority to shed less urgent requests first.
+type Priority struct {
+	DefaultUrgency int
+}
+
+// NewPriority returns a new Priority instance defaulting to the RFC 9218 urgency.
+func NewPriority() *Priority {
+	return &Priority{DefaultUrgency: DefaultPriorityUrgency}
+}
+
+func (p *Priority) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	pr := parsePriority(r.Header.Values("Priority"), p.DefaultUrgency)
+	next(rw, r.WithContext(context.WithValue(r.Context(), priorityKey{}, pr)))
+}
+
+// parsePriority parses the members of a Priority header, a structured field dictionary.
+// Unknown and malformed members are ignored, as RFC 9218 requires.
+func parsePriority(values []string, defaultUrgency int) RequestPriority {
+	pr := RequestPriority{Urgency: defaultUrgency}
+	for _, v := range values {
+		for _, member := range strings.Split(v, ",") {
+			member, _, _ = strings.Cut(member, ";")
+			key, value, hasValue := strings.Cut(strings.TrimSpace(member), "=")
+			switch key {
+			case "u":
+				if u, err := strconv.Atoi(value); err == nil && u >= 0 && u <= 7 {
+					pr.Urgency = u
+				}
+			case "i":
+				switch {
+				case !hasValue || value == "?1":
+					pr.Incremental = true
+				case value == "?0":
+					pr.Incremental = false
+				}
+			}
+		}
+	}
+	return pr
+}
+
+// PriorityFrom returns the priority Priority parsed for the request. It returns false without a
+// Priority in the chain.
+func PriorityFrom(ctx context.Context) (RequestPriority, bool) {
+	pr, ok := ctx.Value(priorityKey{}).(RequestPriority)
+	return pr, ok
+}
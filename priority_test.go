@@ -0,0 +1,54 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   RequestPriority
+	}{
+		{nil, RequestPriority{Urgency: 3}},
+		{[]string{"u=1"}, RequestPriority{Urgency: 1}},
+		{[]string{"u=5, i"}, RequestPriority{Urgency: 5, Incremental: true}},
+		{[]string{"i=?1", "u=0"}, RequestPriority{Urgency: 0, Incremental: true}},
+		{[]string{"i, i=?0"}, RequestPriority{Urgency: 3}},
+		{[]string{"u=9, i=yes"}, RequestPriority{Urgency: 3}},
+		{[]string{"u=2;x=1, foo=bar"}, RequestPriority{Urgency: 2}},
+	}
+	for _, tt := range tests {
+		if got := parsePriority(tt.values, DefaultPriorityUrgency); got != tt.want {
+			t.Errorf("parsePriority(%q) = %+v, want %+v", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestPriority(t *testing.T) {
+	p := NewPriority()
+	p.DefaultUrgency = 4
+	var got RequestPriority
+	var ok bool
+	k := New(p)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		got, ok = PriorityFrom(r.Context())
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	k.ServeHTTP(httptest.NewRecorder(), r)
+	if !ok || got != (RequestPriority{Urgency: 4}) {
+		t.Errorf("PriorityFrom without a header = %+v, %v, want the default urgency", got, ok)
+	}
+	r.Header.Set("Priority", "u=1, i")
+	k.ServeHTTP(httptest.NewRecorder(), r)
+	if got != (RequestPriority{Urgency: 1, Incremental: true}) {
+		t.Errorf("PriorityFrom = %+v, want urgency 1, incremental", got)
+	}
+
+	if _, ok := PriorityFrom(context.Background()); ok {
+		t.Error("PriorityFrom reported a priority without Priority in the chain")
+	}
+}
@@ -0,0 +1,101 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultProfilerPrefix is the path the pprof endpoints are mounted under by default.
+	DefaultProfilerPrefix = "/debug/pprof"
+	// DefaultProfilerMaxDuration is the longest profile or trace a request may ask for by default.
+	DefaultProfilerMaxDuration = time.Minute
+)
+
+// Profiler is a middleware handler that serves the endpoints of net/http/pprof under Prefix and
+// passes every other request to next. Profiling data is sensitive, so requests are only served
+// when Allow returns true for them; others get a 403. Requests asking for a profile or trace
+// longer than MaxDuration in their seconds parameter get a 400.
+//
+// Importing net/http/pprof registers its handlers on http.DefaultServeMux, so programs using
+// Profiler must not serve http.DefaultServeMux to untrusted clients.
+type Profiler struct {
+	// Prefix is the path the endpoints are mounted under. An empty prefix serves none of them.
+	Prefix string
+	// Allow decides whether a request may reach the profiling endpoints, e.g. by checking
+	// the client IP or credentials.
+	Allow func(r *http.Request) bool
+	// MaxDuration caps the seconds parameter of CPU profiles, traces and delta profiles.
+	// Defaults to DefaultProfilerMaxDuration.
+	MaxDuration time.Duration
+}
+
+// NewProfiler returns a new Profiler instance mounted under prefix. It panics if prefix is
+// empty or "/", which would expose the endpoints on every path, or if allow is nil, as the
+// endpoints must never be exposed unguarded.
+func NewProfiler(prefix string, allow func(r *http.Request) bool) *Profiler {
+	if strings.TrimSuffix(prefix, "/") == "" {
+		panic("profiler requires a non-empty prefix")
+	}
+	if allow == nil {
+		panic("profiler requires an allow func")
+	}
+	return &Profiler{
+		Prefix:      prefix,
+		Allow:       allow,
+		MaxDuration: DefaultProfilerMaxDuration,
+	}
+}
+
+func (p *Profiler) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	prefix := strings.TrimSuffix(p.Prefix, "/")
+	if prefix == "" || (r.URL.Path != prefix && !strings.HasPrefix(r.URL.Path, prefix+"/")) {
+		next(rw, r)
+		return
+	}
+	if p.Allow == nil || !p.Allow(r) {
+		http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+	if r.URL.Path == prefix {
+		// The index links are relative to the prefix directory.
+		http.Redirect(rw, r, prefix+"/", http.StatusMovedPermanently)
+		return
+	}
+	if !p.allowedDuration(r) {
+		http.Error(rw, "Bad seconds", http.StatusBadRequest)
+		return
+	}
+
+	switch name := strings.TrimPrefix(r.URL.Path, prefix+"/"); name {
+	case "":
+		pprof.Index(rw, r)
+	case "cmdline":
+		pprof.Cmdline(rw, r)
+	case "profile":
+		pprof.Profile(rw, r)
+	case "symbol":
+		pprof.Symbol(rw, r)
+	case "trace":
+		pprof.Trace(rw, r)
+	default:
+		pprof.Handler(name).ServeHTTP(rw, r)
+	}
+}
+
+// allowedDuration reports whether the seconds parameter of r, if any, is within MaxDuration.
+// Malformed values are left for net/http/pprof to reject.
+func (p *Profiler) allowedDuration(r *http.Request) bool {
+	sec, err := strconv.ParseFloat(r.FormValue("seconds"), 64)
+	if err != nil {
+		return true
+	}
+	max := p.MaxDuration
+	if max <= 0 {
+		max = DefaultProfilerMaxDuration
+	}
+	return sec*float64(time.Second) <= float64(max)
+}
@@ -0,0 +1,88 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func serveProfiler(p *Profiler, path string) (*httptest.ResponseRecorder, bool) {
+	rec := httptest.NewRecorder()
+	called := false
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil), func(http.ResponseWriter, *http.Request) {
+		called = true
+	})
+	return rec, called
+}
+
+func TestProfilerRequiresAllow(t *testing.T) {
+	p := NewProfiler(DefaultProfilerPrefix, func(*http.Request) bool { return false })
+
+	rec, called := serveProfiler(p, "/debug/pprof/heap")
+	if called || rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, next called = %v; want 403 without next", rec.Code, called)
+	}
+	if _, called := serveProfiler(p, "/debug/pprofx"); !called {
+		t.Error("request outside the prefix did not reach next")
+	}
+}
+
+func TestProfilerCustomPrefix(t *testing.T) {
+	p := NewProfiler("/internal/prof/", func(*http.Request) bool { return true })
+
+	rec, _ := serveProfiler(p, "/internal/prof")
+	if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "/internal/prof/" {
+		t.Errorf("status = %d, Location = %q; want a redirect to the index", rec.Code, rec.Header().Get("Location"))
+	}
+
+	rec, _ = serveProfiler(p, "/internal/prof/")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `href='goroutine?debug=1'`) {
+		t.Errorf("index status = %d, body:\n%s", rec.Code, rec.Body.String())
+	}
+
+	rec, _ = serveProfiler(p, "/internal/prof/goroutine?debug=1")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "goroutine profile") {
+		t.Errorf("goroutine status = %d, body:\n%.200s", rec.Code, rec.Body.String())
+	}
+
+	rec, _ = serveProfiler(p, "/internal/prof/nope")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown profile status = %d, want 404", rec.Code)
+	}
+}
+
+func TestProfilerRejectsEmptyPrefix(t *testing.T) {
+	for _, prefix := range []string{"", "/"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("prefix %q did not panic", prefix)
+				}
+			}()
+			NewProfiler(prefix, func(*http.Request) bool { return true })
+		}()
+	}
+
+	p := &Profiler{Allow: func(*http.Request) bool { return true }}
+	if _, called := serveProfiler(p, "/goroutine"); !called {
+		t.Error("Profiler without a prefix served a profile")
+	}
+}
+
+func TestProfilerCapsSeconds(t *testing.T) {
+	p := NewProfiler(DefaultProfilerPrefix, func(*http.Request) bool { return true })
+	p.MaxDuration = time.Second
+
+	for _, path := range []string{"/debug/pprof/profile?seconds=2", "/debug/pprof/trace?seconds=3600", "/debug/pprof/heap?seconds=Inf"} {
+		if rec, _ := serveProfiler(p, path); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", path, rec.Code, http.StatusBadRequest)
+		}
+	}
+	for _, q := range []string{"", "?seconds=1", "?seconds=0.5", "?seconds=x"} {
+		if !p.allowedDuration(httptest.NewRequest(http.MethodGet, "/debug/pprof/profile"+q, nil)) {
+			t.Errorf("%q was rejected", q)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// QueryAllowlist is a middleware handler that only lets through the query parameters in Allowed,
+// preventing parameter pollution and an explosion of cache keys from unknown parameters.
+// Depending on Strip, requests with other parameters either get a 400 and are not served, or
+// have those parameters removed from r.URL.RawQuery. Allowed parameters keep their order and
+// encoding.
+type QueryAllowlist struct {
+	Allowed []string
+	Strip   bool
+}
+
+// NewQueryAllowlist returns a new QueryAllowlist instance rejecting requests with parameters
+// other than allowed.
+func NewQueryAllowlist(allowed ...string) *QueryAllowlist {
+	return &QueryAllowlist{Allowed: allowed}
+}
+
+func (q *QueryAllowlist) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.URL.RawQuery == "" {
+		next(rw, r)
+		return
+	}
+
+	var kept []string
+	stripped := false
+	for _, raw := range strings.Split(r.URL.RawQuery, "&") {
+		if raw == "" {
+			continue
+		}
+		rawKey, _, _ := strings.Cut(raw, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil || !q.allowed(key) {
+			stripped = true
+			continue
+		}
+		kept = append(kept, raw)
+	}
+
+	if stripped {
+		if !q.Strip {
+			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		r.URL.RawQuery = strings.Join(kept, "&")
+	}
+	next(rw, r)
+}
+
+func (q *QueryAllowlist) allowed(key string) bool {
+	for _, name := range q.Allowed {
+		if name == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func serveQueryAllowlist(q *QueryAllowlist, rawQuery string) (int, string) {
+	var seen string
+	k := New(q)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		seen = r.URL.RawQuery
+	})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search?"+rawQuery, nil))
+	return rec.Code, seen
+}
+
+func TestQueryAllowlist(t *testing.T) {
+	reject := NewQueryAllowlist("q", "page size")
+	strip := NewQueryAllowlist("q", "page size")
+	strip.Strip = true
+
+	tests := []struct {
+		name     string
+		q        *QueryAllowlist
+		rawQuery string
+		status   int
+		seen     string
+	}{
+		{"allowed", reject, "q=a%20b&page+size=10", http.StatusOK, "q=a%20b&page+size=10"},
+		{"empty", reject, "", http.StatusOK, ""},
+		{"rejected", reject, "q=a&utm_source=x", http.StatusBadRequest, ""},
+		{"stripped", strip, "utm_source=x&q=a&&fbclid=y&q=b", http.StatusOK, "q=a&q=b"},
+		{"bad escape", strip, "q=a&%zz=1", http.StatusOK, "q=a"},
+	}
+	for _, tt := range tests {
+		status, seen := serveQueryAllowlist(tt.q, tt.rawQuery)
+		if status != tt.status || seen != tt.seen {
+			t.Errorf("%s: got %d with query %q, want %d with %q", tt.name, status, seen, tt.status, tt.seen)
+		}
+	}
+}
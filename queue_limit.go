@@ -0,0 +1,127 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+type queueWaitKey struct{}
+
+// QueueLimit is a middleware handler that admits at most MaxConcurrent requests into the rest
+// of the chain at a time. Requests beyond that wait in a queue of up to MaxQueue requests; when
+// the queue is full, new requests get a 503. Queued requests are admitted in arrival order, and
+// before any request arriving later. The time a request spent queued is available to later
+// handlers through QueueWait.
+type QueueLimit struct {
+	maxConcurrent int
+	maxQueue      int
+	clock         Clock
+
+	mu     sync.Mutex
+	active int
+	// queue holds the waiting requests, oldest first. A request is admitted by closing its
+	// channel, handing it the slot of the request that finished.
+	queue []chan struct{}
+}
+
+// NewQueueLimit returns a new QueueLimit instance running maxConcurrent requests at once and
+// queueing up to maxQueue more. It panics if maxConcurrent is less than one.
+func NewQueueLimit(maxConcurrent, maxQueue int) *QueueLimit {
+	if maxConcurrent < 1 {
+		panic("queue limit requires a positive maxConcurrent")
+	}
+	return &QueueLimit{
+		maxConcurrent: maxConcurrent,
+		maxQueue:      maxQueue,
+	}
+}
+
+// WithClock makes the QueueLimit read the time from c instead of the system clock.
+func (q *QueueLimit) WithClock(c Clock) *QueueLimit {
+	q.clock = c
+	return q
+}
+
+// Waiting returns the number of requests currently queued.
+func (q *QueueLimit) Waiting() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.queue)
+}
+
+func (q *QueueLimit) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	clock := clockOrDefault(q.clock)
+	start := clock.Now()
+
+	admitted, ok := q.acquire()
+	if !ok {
+		http.Error(rw, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+	if admitted != nil {
+		select {
+		case <-admitted:
+		case <-r.Context().Done():
+			q.cancel(admitted)
+			return
+		}
+	}
+	defer q.release()
+
+	ctx := context.WithValue(r.Context(), queueWaitKey{}, clock.Now().Sub(start))
+	next(rw, r.WithContext(ctx))
+}
+
+// acquire takes a slot if one is free and nobody is queued for it, returning a nil channel.
+// Otherwise it queues the request and returns the channel closed once it is admitted, or
+// reports false if the queue is full.
+func (q *QueueLimit) acquire() (chan struct{}, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.active < q.maxConcurrent && len(q.queue) == 0 {
+		q.active++
+		return nil, true
+	}
+	if len(q.queue) >= q.maxQueue {
+		return nil, false
+	}
+	admitted := make(chan struct{})
+	q.queue = append(q.queue, admitted)
+	return admitted, true
+}
+
+// release frees a slot, handing it to the oldest queued request if there is one.
+func (q *QueueLimit) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.queue) > 0 {
+		close(q.queue[0])
+		q.queue = q.queue[1:]
+		return
+	}
+	q.active--
+}
+
+// cancel removes a request that gave up waiting from the queue. If it was admitted in the
+// meantime, the slot it was handed is released again.
+func (q *QueueLimit) cancel(admitted chan struct{}) {
+	q.mu.Lock()
+	if i := slices.Index(q.queue, admitted); i >= 0 {
+		q.queue = slices.Delete(q.queue, i, i+1)
+		q.mu.Unlock()
+		return
+	}
+	q.mu.Unlock()
+	q.release()
+}
+
+// QueueWait returns how long the request waited in a QueueLimit queue before being admitted.
+func QueueWait(ctx context.Context) time.Duration {
+	d, _ := ctx.Value(queueWaitKey{}).(time.Duration)
+	return d
+}
@@ -0,0 +1,165 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func waitForQueue(t *testing.T, q *QueueLimit, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for q.Waiting() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Waiting() = %d, want %d", q.Waiting(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestQueueLimitAdmitsInArrivalOrder(t *testing.T) {
+	q := NewQueueLimit(1, 3)
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	started := make(chan struct{}, 4)
+	release := make(chan struct{})
+	k := New(q)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		order = append(order, r.URL.Path)
+		mu.Unlock()
+		started <- struct{}{}
+		<-release
+	})
+
+	var wg sync.WaitGroup
+	serve := func(path string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		}()
+	}
+	serve("/a")
+	<-started
+	serve("/b")
+	waitForQueue(t, q, 1)
+	serve("/c")
+	waitForQueue(t, q, 2)
+
+	// /a finishing hands its slot to /b; /d arriving afterwards must queue behind /c.
+	release <- struct{}{}
+	<-started
+	waitForQueue(t, q, 1)
+	serve("/d")
+	waitForQueue(t, q, 2)
+	close(release)
+	wg.Wait()
+
+	want := []string{"/a", "/b", "/c", "/d"}
+	if len(order) != len(want) {
+		t.Fatalf("order = %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("order = %v, want %v", order, want)
+		}
+	}
+}
+
+func TestQueueLimitRejectsWhenQueueFull(t *testing.T) {
+	q := NewQueueLimit(1, 1)
+	release := make(chan struct{})
+	k := New(q)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		<-release
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}()
+	}
+	waitForQueue(t, q, 1)
+
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	close(release)
+	wg.Wait()
+}
+
+func TestQueueLimitCanceledWaiterLeavesQueue(t *testing.T) {
+	q := NewQueueLimit(1, 1)
+	release := make(chan struct{})
+	served := make(chan string, 2)
+	k := New(q)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		served <- r.URL.Path
+		<-release
+	})
+
+	go k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/first", nil))
+	<-served
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/canceled", nil).WithContext(ctx))
+	}()
+	waitForQueue(t, q, 1)
+	cancel()
+	<-done
+	waitForQueue(t, q, 0)
+
+	close(release)
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/next", nil))
+	if got := <-served; got != "/next" {
+		t.Errorf("served %s, want /next", got)
+	}
+}
+
+func TestQueueLimitWait(t *testing.T) {
+	clock := newFakeClock()
+	q := NewQueueLimit(1, 1).WithClock(clock)
+	waits := make(chan time.Duration, 2)
+	release := make(chan struct{})
+	k := New(q)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		waits <- QueueWait(r.Context())
+		<-release
+	})
+
+	go k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if wait := <-waits; wait != 0 {
+		t.Errorf("QueueWait = %v for a request admitted at once, want 0", wait)
+	}
+	go k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	waitForQueue(t, q, 1)
+	clock.Advance(2 * time.Second)
+	close(release)
+	if wait := <-waits; wait != 2*time.Second {
+		t.Errorf("QueueWait = %v for a queued request, want 2s", wait)
+	}
+}
+
+func TestNewQueueLimitRejectsZeroConcurrency(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewQueueLimit(0, 1) did not panic")
+		}
+	}()
+	NewQueueLimit(0, 1)
+}
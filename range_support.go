@@ -0,0 +1,55 @@
+package y_middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+type rangeContentKey struct{}
+
+type rangeContent struct {
+	name    string
+	modtime time.Time
+	content io.ReadSeeker
+}
+
+// RangeSupport is a middleware handler that gives custom, non-file content support for Range
+// requests, for resumable downloads and media seeking. Instead of writing the body, a handler
+// passes its content to SetRangeContent; once the handler returns, RangeSupport serves it
+// with http.ServeContent, which answers Range requests with 206 Partial Content or 416 Range
+// Not Satisfiable and sets Accept-Ranges and Content-Range.
+type RangeSupport struct{}
+
+// NewRangeSupport returns a new RangeSupport instance.
+func NewRangeSupport() *RangeSupport {
+	return &RangeSupport{}
+}
+
+func (s *RangeSupport) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	slot := &rangeContent{}
+	r = r.WithContext(context.WithValue(r.Context(), rangeContentKey{}, slot))
+
+	res := wrapResponseWriter(rw)
+	next(res, r)
+
+	if slot.content != nil && !res.Written() {
+		http.ServeContent(res, r, slot.name, slot.modtime, slot.content)
+	}
+}
+
+// SetRangeContent registers content as the response body, to be served by RangeSupport once the
+// handler returns. The name is used to pick a Content-Type when none is set, and modtime, if not
+// zero, for Last-Modified and conditional requests. It reports false if RangeSupport is not
+// running for the request, in which case the handler must write the response itself.
+func SetRangeContent(ctx context.Context, name string, modtime time.Time, content io.ReadSeeker) bool {
+	slot, ok := ctx.Value(rangeContentKey{}).(*rangeContent)
+	if !ok {
+		return false
+	}
+	slot.name = name
+	slot.modtime = modtime
+	slot.content = content
+	return true
+}
@@ -0,0 +1,74 @@
+package y_middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func serveRange(rangeHeader string) *httptest.ResponseRecorder {
+	k := New(NewRangeSupport())
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		SetRangeContent(r.Context(), "report.txt", time.Time{}, strings.NewReader("0123456789"))
+	})
+	r := httptest.NewRequest(http.MethodGet, "/report", nil)
+	if rangeHeader != "" {
+		r.Header.Set("Range", rangeHeader)
+	}
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestRangeSupport(t *testing.T) {
+	tests := []struct {
+		rangeHeader  string
+		status       int
+		body         string
+		contentRange string
+	}{
+		{"", http.StatusOK, "0123456789", ""},
+		{"bytes=2-4", http.StatusPartialContent, "234", "bytes 2-4/10"},
+		{"bytes=-3", http.StatusPartialContent, "789", "bytes 7-9/10"},
+		{"bytes=20-", http.StatusRequestedRangeNotSatisfiable, "", "bytes */10"},
+	}
+	for _, tt := range tests {
+		rec := serveRange(tt.rangeHeader)
+		if rec.Code != tt.status {
+			t.Errorf("%q: status = %d, want %d", tt.rangeHeader, rec.Code, tt.status)
+		}
+		if tt.status != http.StatusRequestedRangeNotSatisfiable && rec.Body.String() != tt.body {
+			t.Errorf("%q: body = %q, want %q", tt.rangeHeader, rec.Body, tt.body)
+		}
+		if got := rec.Header().Get("Content-Range"); got != tt.contentRange {
+			t.Errorf("%q: Content-Range = %q, want %q", tt.rangeHeader, got, tt.contentRange)
+		}
+	}
+	if got := serveRange("").Header().Get("Accept-Ranges"); got != "bytes" {
+		t.Errorf("Accept-Ranges = %q, want bytes", got)
+	}
+}
+
+func TestRangeSupportHandlerWrites(t *testing.T) {
+	k := New(NewRangeSupport())
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		SetRangeContent(r.Context(), "a.txt", time.Time{}, strings.NewReader("ignored"))
+		rw.WriteHeader(http.StatusForbidden)
+		io.WriteString(rw, "denied")
+	})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusForbidden || rec.Body.String() != "denied" {
+		t.Errorf("got %d %q, want the handler's own response", rec.Code, rec.Body)
+	}
+}
+
+func TestSetRangeContentWithoutMiddleware(t *testing.T) {
+	if SetRangeContent(context.Background(), "a.txt", time.Time{}, strings.NewReader("")) {
+		t.Error("SetRangeContent reported true without RangeSupport")
+	}
+}
@@ -0,0 +1,121 @@
+package y_middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimiterSweepInterval is how often RateLimiter drops the buckets of idle keys.
+const rateLimiterSweepInterval = time.Minute
+
+// RateLimiter is a middleware handler that limits how often each client may make requests
+// using a token bucket per key. Requests over the limit get a 429 with a Retry-After header
+// and the rest of the chain is skipped. Buckets that have refilled completely are dropped
+// periodically, so keys that stop sending requests don't use memory.
+type RateLimiter struct {
+	// Rate is the number of requests per second a key may make on average.
+	Rate float64
+	// Burst is the number of requests a key may make at once.
+	Burst int
+	// KeyFunc returns the bucket key for a request, e.g. the authenticated user.
+	// Requests for which it returns an empty key are keyed by client IP.
+	KeyFunc func(r *http.Request) string
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	clock     Clock
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a new RateLimiter instance that keys requests by client IP.
+// It panics if rate or burst is not positive.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if !(rate > 0) || burst <= 0 {
+		panic("rate limiter requires a positive rate and burst")
+	}
+	return &RateLimiter{
+		Rate:    rate,
+		Burst:   burst,
+		KeyFunc: clientIP,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// WithClock makes the RateLimiter read the time from c instead of the system clock.
+func (l *RateLimiter) WithClock(c Clock) *RateLimiter {
+	l.clock = c
+	return l
+}
+
+func (l *RateLimiter) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if ok, wait := l.allow(l.key(r), clockOrDefault(l.clock).Now()); !ok {
+		rw.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		http.Error(rw, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		return
+	}
+	next(rw, r)
+}
+
+func (l *RateLimiter) key(r *http.Request) string {
+	if l.KeyFunc != nil {
+		if key := l.KeyFunc(r); key != "" {
+			return key
+		}
+	}
+	return clientIP(r)
+}
+
+// allow takes a token from the bucket for key. When the bucket is empty it reports how long
+// until the next token is available.
+func (l *RateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.buckets == nil {
+		l.buckets = make(map[string]*tokenBucket)
+	}
+	if now.Sub(l.lastSweep) >= rateLimiterSweepInterval {
+		l.sweep(now)
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(l.Burst), last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(l.Burst), b.tokens+now.Sub(b.last).Seconds()*l.Rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.Rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep drops the buckets that have refilled completely by now; new ones start full anyway.
+func (l *RateLimiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.Rate >= float64(l.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// clientIP returns the host part of the request's remote address.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,91 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func rateLimitStatus(l *RateLimiter, remoteAddr string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	l.ServeHTTP(rec, r, func(http.ResponseWriter, *http.Request) {})
+	return rec
+}
+
+func TestRateLimiterLimitsPerKey(t *testing.T) {
+	l := NewRateLimiter(1, 1).WithClock(newFakeClock())
+
+	if rec := rateLimitStatus(l, "10.0.0.1:1234"); rec.Code != http.StatusOK {
+		t.Fatalf("first request got %d", rec.Code)
+	}
+	rec := rateLimitStatus(l, "10.0.0.1:1234")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" {
+		t.Errorf("second request got %d with Retry-After %q, want 429 with 1", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec := rateLimitStatus(l, "10.0.0.2:1234"); rec.Code != http.StatusOK {
+		t.Errorf("other client got %d", rec.Code)
+	}
+}
+
+func TestRateLimiterKeyFunc(t *testing.T) {
+	l := NewRateLimiter(1, 1).WithClock(newFakeClock())
+	l.KeyFunc = func(r *http.Request) string { return r.Header.Get("X-User") }
+	status := func(user, addr string) int {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = addr
+		r.Header.Set("X-User", user)
+		rec := httptest.NewRecorder()
+		l.ServeHTTP(rec, r, func(http.ResponseWriter, *http.Request) {})
+		return rec.Code
+	}
+
+	if status("alice", "10.0.0.1:1") != http.StatusOK || status("alice", "10.0.0.2:1") != http.StatusTooManyRequests {
+		t.Error("user was not limited across addresses")
+	}
+	if status("bob", "10.0.0.1:1") != http.StatusOK {
+		t.Error("other user was limited")
+	}
+}
+
+func TestRateLimiterEvictsIdleBuckets(t *testing.T) {
+	clock := newFakeClock()
+	l := NewRateLimiter(10, 5).WithClock(clock)
+	for i := 0; i < 100; i++ {
+		rateLimitStatus(l, "10.0.0."+strconv.Itoa(i)+":1")
+	}
+	clock.Advance(rateLimiterSweepInterval)
+	rateLimitStatus(l, "10.0.1.1:1")
+
+	l.mu.Lock()
+	n := len(l.buckets)
+	l.mu.Unlock()
+	if n != 1 {
+		t.Errorf("%d buckets left after the sweep, want only the new one", n)
+	}
+}
+
+func TestRateLimiterKeepsBucketsThatAreNotFull(t *testing.T) {
+	clock := newFakeClock()
+	l := NewRateLimiter(1.0/3600, 1).WithClock(clock)
+	rateLimitStatus(l, "10.0.0.1:1")
+	clock.Advance(rateLimiterSweepInterval)
+	if rec := rateLimitStatus(l, "10.0.0.1:1"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("sweep reset a bucket that was still empty: got %d", rec.Code)
+	}
+}
+
+func TestNewRateLimiterRejectsInvalidRate(t *testing.T) {
+	for _, rate := range []float64{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewRateLimiter(%v, 1) did not panic", rate)
+				}
+			}()
+			NewRateLimiter(rate, 1)
+		}()
+	}
+}
@@ -0,0 +1,101 @@
+package y_middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// ErrBodyReadTimeout is returned from reads of a request body guarded by ReadTimeout that
+// stalled for longer than the timeout.
+var ErrBodyReadTimeout = errors.New("request body read timed out")
+
+// ReadTimeout is a middleware handler that fails request body reads that stall for longer than
+// a timeout, protecting handlers from slowloris-style uploads. Unlike an overall request timeout
+// it only limits how long a single read may wait for data. Where the server supports it the
+// connection read deadline is used; otherwise each read is abandoned once the timeout passes.
+// ConfigOverride can change the timeout of a request with OverrideReadTimeout.
+type ReadTimeout struct {
+	timeout time.Duration
+}
+
+// NewReadTimeout returns a new ReadTimeout instance failing body reads that stall for timeout.
+func NewReadTimeout(timeout time.Duration) *ReadTimeout {
+	return &ReadTimeout{timeout: timeout}
+}
+
+func (t *ReadTimeout) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.Body == nil || r.Body == http.NoBody {
+		next(rw, r)
+		return
+	}
+
+	timeout := overrideDuration(r.Context(), OverrideReadTimeout, t.timeout)
+	rc := http.NewResponseController(rw)
+	if err := rc.SetReadDeadline(time.Time{}); err == nil {
+		r.Body = &deadlineBody{ReadCloser: r.Body, rc: rc, timeout: timeout}
+		defer rc.SetReadDeadline(time.Time{})
+	} else {
+		r.Body = &timeoutBody{body: r.Body, timeout: timeout}
+	}
+	next(rw, r)
+}
+
+// deadlineBody moves the connection read deadline forward before every read.
+type deadlineBody struct {
+	io.ReadCloser
+	rc      *http.ResponseController
+	timeout time.Duration
+}
+
+func (b *deadlineBody) Read(p []byte) (int, error) {
+	b.rc.SetReadDeadline(time.Now().Add(b.timeout))
+	n, err := b.ReadCloser.Read(p)
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		err = ErrBodyReadTimeout
+	}
+	return n, err
+}
+
+type readResult struct {
+	n   int
+	err error
+}
+
+// timeoutBody gives up on reads that take longer than timeout. The abandoned read is
+// left to finish in the background, and every later read fails.
+type timeoutBody struct {
+	body    io.ReadCloser
+	timeout time.Duration
+	err     error
+}
+
+func (b *timeoutBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	buf := make([]byte, len(p))
+	done := make(chan readResult, 1)
+	go func() {
+		n, err := b.body.Read(buf)
+		done <- readResult{n, err}
+	}()
+
+	timer := time.NewTimer(b.timeout)
+	defer timer.Stop()
+	select {
+	case res := <-done:
+		return copy(p, buf[:res.n]), res.err
+	case <-timer.C:
+		b.err = ErrBodyReadTimeout
+		b.body.Close()
+		return 0, b.err
+	}
+}
+
+func (b *timeoutBody) Close() error {
+	return b.body.Close()
+}
@@ -0,0 +1,86 @@
+package y_middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readTimeoutHandler(errs chan<- error) *Kudret {
+	k := New(NewReadTimeout(50 * time.Millisecond))
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		_, err := io.ReadAll(r.Body)
+		errs <- err
+	})
+	return k
+}
+
+func TestReadTimeoutFallbackBody(t *testing.T) {
+	errs := make(chan error, 1)
+	k := readTimeoutHandler(errs)
+
+	k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("quick")))
+	if err := <-errs; err != nil {
+		t.Errorf("quick body: err = %v, want nil", err)
+	}
+
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go pw.Write([]byte("partial"))
+	k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", pr))
+	if err := <-errs; !errors.Is(err, ErrBodyReadTimeout) {
+		t.Errorf("stalled body: err = %v, want %v", err, ErrBodyReadTimeout)
+	}
+}
+
+func TestReadTimeoutConnectionDeadline(t *testing.T) {
+	errs := make(chan error, 1)
+	srv := httptest.NewServer(readTimeoutHandler(errs))
+	defer srv.Close()
+
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go pw.Write([]byte("partial"))
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, pr)
+	go func() {
+		if res, err := srv.Client().Do(req); err == nil {
+			res.Body.Close()
+		}
+	}()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrBodyReadTimeout) {
+			t.Errorf("stalled upload: err = %v, want %v", err, ErrBodyReadTimeout)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stalled upload was never timed out")
+	}
+}
+
+func TestReadTimeoutOverride(t *testing.T) {
+	errs := make(chan error, 1)
+	k := New(NewConfigOverride(netip.MustParsePrefix("192.0.2.0/24")), NewReadTimeout(50*time.Millisecond))
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		_, err := io.ReadAll(r.Body)
+		errs <- err
+	})
+
+	pr, pw := io.Pipe()
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		pw.Write([]byte("late"))
+		pw.Close()
+	}()
+	r := httptest.NewRequest(http.MethodPost, "/", pr)
+	r.Header.Set("X-Override-Read-Timeout", "5s")
+	k.ServeHTTP(httptest.NewRecorder(), r)
+	if err := <-errs; err != nil {
+		t.Errorf("slow body with a longer timeout: err = %v, want nil", err)
+	}
+}
@@ -0,0 +1,90 @@
+package y_middleware
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"runtime"
+)
+
+// Recovery is a middleware that recovers from any panics and writes a 500 if there was one.
+// Panics matched by a function registered with Map get the status and message that function
+// returns instead. The panic and its stack are logged, but only sent to the client when
+// PrintStack is set.
+type Recovery struct {
+	Logger ALogger
+	// PrintStack writes the panic and its stack into the response. Only enable it during
+	// development, since stack traces reveal internals to clients.
+	PrintStack bool
+	LogStack   bool
+	StackAll   bool
+	StackSize  int
+
+	matchers []func(any) (int, string, bool)
+}
+
+// NewRecovery returns a new instance of Recovery
+func NewRecovery() *Recovery {
+	return &Recovery{
+		Logger:     log.New(os.Stdout, "[kudret] ", 0),
+		PrintStack: false,
+		LogStack:   true,
+		StackAll:   false,
+		StackSize:  1024 * 8,
+	}
+}
+
+// Map registers a matcher that translates recovered panic values into a response status and
+// the message sent to the client with it; an empty message sends the status text. Matchers are
+// tried in the order they were registered; unmatched panics get a 500.
+func (rec *Recovery) Map(matcher func(any) (status int, message string, ok bool)) {
+	rec.matchers = append(rec.matchers, matcher)
+}
+
+func (rec *Recovery) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	defer func() {
+		err := recover()
+		if err == nil {
+			return
+		}
+		if err == http.ErrAbortHandler {
+			// http.ErrAbortHandler is how a handler asks the server to abort the response.
+			panic(err)
+		}
+
+		if status, message, ok := rec.match(err); ok {
+			if message == "" {
+				message = http.StatusText(status)
+			}
+			http.Error(rw, message, status)
+			return
+		}
+
+		stack := make([]byte, rec.StackSize)
+		stack = stack[:runtime.Stack(stack, rec.StackAll)]
+
+		if rec.LogStack {
+			rec.Logger.Printf("PANIC: %s\n%s", err, stack)
+		} else {
+			rec.Logger.Printf("PANIC: %s", err)
+		}
+		if rec.PrintStack {
+			rw.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(rw, "PANIC: %s\n%s", err, stack)
+			return
+		}
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}()
+
+	next(rw, r)
+}
+
+func (rec *Recovery) match(err any) (int, string, bool) {
+	for _, matcher := range rec.matchers {
+		if status, message, ok := matcher(err); ok {
+			return status, message, true
+		}
+	}
+	return 0, "", false
+}
@@ -0,0 +1,80 @@
+package y_middleware
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var errNotFound = errors.New("not found")
+
+func servePanic(rec *Recovery, value any) *httptest.ResponseRecorder {
+	k := New(rec)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		panic(value)
+	})
+	res := httptest.NewRecorder()
+	k.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	return res
+}
+
+func quietRecovery() *Recovery {
+	rec := NewRecovery()
+	rec.Logger = log.New(io.Discard, "", 0)
+	return rec
+}
+
+func TestRecoveryWrites500(t *testing.T) {
+	var logged strings.Builder
+	rec := NewRecovery()
+	rec.Logger = log.New(&logged, "", 0)
+	res := servePanic(rec, "boom")
+	if res.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", res.Code, http.StatusInternalServerError)
+	}
+	if res.Body.String() != "Internal Server Error\n" {
+		t.Errorf("body = %q by default, want only the status text", res.Body)
+	}
+	if !strings.HasPrefix(logged.String(), "PANIC: boom\n") {
+		t.Errorf("logged %q, want the panic and stack", logged.String())
+	}
+
+	rec.PrintStack = true
+	if res := servePanic(rec, "boom"); !strings.HasPrefix(res.Body.String(), "PANIC: boom\n") {
+		t.Errorf("body = %q with PrintStack on, want the panic and stack", res.Body)
+	}
+}
+
+func TestRecoveryMap(t *testing.T) {
+	rec := quietRecovery()
+	rec.Map(func(v any) (int, string, bool) {
+		err, ok := v.(error)
+		if ok && errors.Is(err, errNotFound) {
+			return http.StatusNotFound, "no such order", true
+		}
+		return 0, "", false
+	})
+	rec.Map(func(v any) (int, string, bool) {
+		return http.StatusConflict, "", true
+	})
+
+	if res := servePanic(rec, errNotFound); res.Code != http.StatusNotFound || res.Body.String() != "no such order\n" {
+		t.Errorf("mapped panic got %d %q, want %d with the matcher's message", res.Code, res.Body, http.StatusNotFound)
+	}
+	if res := servePanic(rec, "other"); res.Code != http.StatusConflict || res.Body.String() != "Conflict\n" {
+		t.Errorf("panic matched by the second matcher got %d %q, want %d with the status text", res.Code, res.Body, http.StatusConflict)
+	}
+}
+
+func TestRecoveryRepanicsAbortHandler(t *testing.T) {
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler", v)
+		}
+	}()
+	servePanic(quietRecovery(), http.ErrAbortHandler)
+}
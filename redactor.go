@@ -0,0 +1,79 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RedactedValue replaces the values of redacted headers and query parameters in log output.
+const RedactedValue = "[REDACTED]"
+
+type redactionKey struct{}
+
+type redaction struct {
+	headers     []string
+	queryParams map[string]bool
+}
+
+// Redactor is a middleware handler that marks headers and query parameters as sensitive for
+// the rest of the request. Logging middleware such as Logger mask their values, so secrets
+// like API keys in query strings never reach the logs. Redactor must come before any logging
+// middleware in the stack.
+type Redactor struct {
+	redaction *redaction
+}
+
+// NewRedactor returns a new Redactor instance masking the given header names and query parameter keys.
+func NewRedactor(headers []string, queryParams []string) *Redactor {
+	rd := &redaction{queryParams: make(map[string]bool)}
+	for _, h := range headers {
+		rd.headers = append(rd.headers, http.CanonicalHeaderKey(h))
+	}
+	for _, q := range queryParams {
+		rd.queryParams[q] = true
+	}
+	return &Redactor{redaction: rd}
+}
+
+func (rd *Redactor) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	next(rw, r.WithContext(context.WithValue(r.Context(), redactionKey{}, rd.redaction)))
+}
+
+// RedactRequest returns a copy of r with the headers and query parameters marked by Redactor
+// masked, for use in log output. It returns r itself when nothing needs masking.
+func RedactRequest(r *http.Request) *http.Request {
+	rd, ok := r.Context().Value(redactionKey{}).(*redaction)
+	if !ok {
+		return r
+	}
+
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.Header = r.Header.Clone()
+	for _, h := range rd.headers {
+		if _, ok := r2.Header[h]; ok {
+			r2.Header[h] = []string{RedactedValue}
+		}
+	}
+	if r.URL != nil && r.URL.RawQuery != "" {
+		u := *r.URL
+		u.RawQuery = redactQuery(u.RawQuery, rd.queryParams)
+		r2.URL = &u
+		r2.RequestURI = u.RequestURI()
+	}
+	return r2
+}
+
+// redactQuery masks the values of keys in rawQuery, keeping the parameters in their original order.
+func redactQuery(rawQuery string, keys map[string]bool) string {
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if k, err := url.QueryUnescape(key); err == nil && keys[k] {
+			params[i] = key + "=" + RedactedValue
+		}
+	}
+	return strings.Join(params, "&")
+}
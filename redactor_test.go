@@ -0,0 +1,68 @@
+package y_middleware
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRedactRequest(t *testing.T) {
+	var redacted, original *http.Request
+	k := New(NewRedactor([]string{"authorization", "X-Api-Key"}, []string{"token", "api key"}))
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		original = r
+		redacted = RedactRequest(r)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/items?b=2&token=secret&api%20key=k&a=1", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Accept", "application/json")
+	k.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := redacted.Header.Get("Authorization"); got != RedactedValue {
+		t.Errorf("Authorization = %q, want %q", got, RedactedValue)
+	}
+	if _, ok := redacted.Header["X-Api-Key"]; ok {
+		t.Error("absent redacted header was added")
+	}
+	if got := redacted.Header.Get("Accept"); got != "application/json" {
+		t.Errorf("Accept = %q, want it unchanged", got)
+	}
+	if got, want := redacted.URL.RawQuery, "b=2&token=[REDACTED]&api%20key=[REDACTED]&a=1"; got != want {
+		t.Errorf("query = %q, want %q", got, want)
+	}
+	if !strings.HasPrefix(redacted.RequestURI, "/items?b=2&token=[REDACTED]") {
+		t.Errorf("RequestURI = %q, want the redacted query", redacted.RequestURI)
+	}
+
+	if original.Header.Get("Authorization") != "Bearer secret" || original.URL.Query().Get("token") != "secret" {
+		t.Error("RedactRequest modified the original request")
+	}
+}
+
+func TestRedactRequestWithoutRedactor(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/?token=secret", nil)
+	if RedactRequest(req) != req {
+		t.Error("RedactRequest copied a request without a Redactor")
+	}
+}
+
+func TestLoggerRedactsRequest(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger()
+	l.ALogger = log.New(&buf, "", 0)
+	l.SetFormat("{{.Request.URL.RawQuery}} {{.Request.Header.Get \"Authorization\"}}")
+
+	k := New(NewRedactor([]string{"Authorization"}, []string{"token"}), l)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {})
+	req := httptest.NewRequest(http.MethodGet, "/?token=secret", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	k.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := buf.String(); strings.Contains(got, "secret") {
+		t.Errorf("logged %q, want the secrets masked", got)
+	}
+}
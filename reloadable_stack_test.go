@@ -0,0 +1,75 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// headerHandler sets X-Version to v and yields.
+func headerHandler(v string) Handler {
+	return HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		rw.Header().Add("X-Version", v)
+		next(rw, r)
+	})
+}
+
+func TestReloadableStackSwapsHandlers(t *testing.T) {
+	s := NewReloadableStack(headerHandler("1"), headerHandler("1b"))
+	serve := func() ([]string, bool) {
+		rec := httptest.NewRecorder()
+		called := false
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil), func(http.ResponseWriter, *http.Request) { called = true })
+		return rec.Header().Values("X-Version"), called
+	}
+
+	if got, called := serve(); len(got) != 2 || got[0] != "1" || got[1] != "1b" || !called {
+		t.Errorf("before reload: X-Version = %v, next called = %v", got, called)
+	}
+	s.Reload(headerHandler("2"))
+	if got, called := serve(); len(got) != 1 || got[0] != "2" || !called {
+		t.Errorf("after reload: X-Version = %v, next called = %v", got, called)
+	}
+	if n := len(s.Handlers()); n != 1 {
+		t.Errorf("Handlers() has %d handlers, want 1", n)
+	}
+}
+
+func TestReloadableStackZeroValue(t *testing.T) {
+	var s ReloadableStack
+	called := false
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), func(http.ResponseWriter, *http.Request) { called = true })
+	if !called {
+		t.Error("zero value did not call next")
+	}
+	if h := s.Handlers(); h != nil {
+		t.Errorf("Handlers() = %v, want nil", h)
+	}
+}
+
+func TestReloadableStackConcurrentReload(t *testing.T) {
+	s := NewReloadableStack(headerHandler("0"))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				s.Reload(headerHandler(strconv.Itoa(i)), headerHandler(strconv.Itoa(j)))
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				rec := httptest.NewRecorder()
+				s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil), func(http.ResponseWriter, *http.Request) {})
+				if n := len(rec.Header().Values("X-Version")); n != 1 && n != 2 {
+					t.Errorf("request ran %d handlers, a mix of two reloads", n)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
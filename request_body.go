@@ -0,0 +1,37 @@
+package y_middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// errBodyTooLarge is returned by bufferBody when the request body exceeds its limit.
+var errBodyTooLarge = errors.New("request body too large")
+
+// bufferBody reads the whole request body and replaces it with an in-memory copy so it can
+// still be read by handlers further down the chain. A max greater than zero limits how many
+// bytes are read; larger bodies return errBodyTooLarge and are left readable in full, so the
+// request can still be passed on.
+func bufferBody(r *http.Request, max int64) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+
+	var reader io.Reader = r.Body
+	if max > 0 {
+		reader = io.LimitReader(r.Body, max+1)
+	}
+	body, err := io.ReadAll(reader)
+	if err == nil && max > 0 && int64(len(body)) > max {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		return nil, errBodyTooLarge
+	}
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, err
+}
@@ -0,0 +1,37 @@
+package y_middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBufferBody(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("payload"))
+	body, err := bufferBody(r, 7)
+	if err != nil || string(body) != "payload" {
+		t.Fatalf("bufferBody = %q, %v, want the body", body, err)
+	}
+	if again, _ := io.ReadAll(r.Body); string(again) != "payload" {
+		t.Errorf("body read after buffering = %q, want it readable again", again)
+	}
+}
+
+func TestBufferBodyTooLarge(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("payload"))
+	if body, err := bufferBody(r, 4); err != errBodyTooLarge || body != nil {
+		t.Fatalf("bufferBody = %q, %v, want %v", body, err, errBodyTooLarge)
+	}
+	if rest, _ := io.ReadAll(r.Body); string(rest) != "payload" {
+		t.Errorf("body read after the limit = %q, want it readable in full", rest)
+	}
+}
+
+func TestBufferBodyWithoutBody(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if body, err := bufferBody(r, 0); body != nil || err != nil {
+		t.Errorf("bufferBody without a body = %q, %v, want nil, nil", body, err)
+	}
+}
@@ -0,0 +1,45 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+type requestErrorsKey struct{}
+
+// requestErrors collects the errors recorded for a request.
+type requestErrors struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+// withRequestErrors makes sure r carries an error collection, returning r unchanged if it
+// already has one.
+func withRequestErrors(r *http.Request) *http.Request {
+	if _, ok := r.Context().Value(requestErrorsKey{}).(*requestErrors); ok {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), requestErrorsKey{}, &requestErrors{}))
+}
+
+// AddError records err against the request, for middleware further up the chain to report. It
+// does nothing when no middleware in the chain collects request errors.
+func AddError(ctx context.Context, err error) {
+	if errs, ok := ctx.Value(requestErrorsKey{}).(*requestErrors); ok && err != nil {
+		errs.mu.Lock()
+		errs.errs = append(errs.errs, err)
+		errs.mu.Unlock()
+	}
+}
+
+// Errors returns the errors recorded against the request with AddError.
+func Errors(ctx context.Context) []error {
+	errs, ok := ctx.Value(requestErrorsKey{}).(*requestErrors)
+	if !ok {
+		return nil
+	}
+	errs.mu.Lock()
+	defer errs.mu.Unlock()
+	return append([]error(nil), errs.errs...)
+}
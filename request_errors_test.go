@@ -0,0 +1,38 @@
+package y_middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestErrors(t *testing.T) {
+	r := withRequestErrors(httptest.NewRequest(http.MethodGet, "/", nil))
+	if again := withRequestErrors(r); again != r {
+		t.Error("withRequestErrors replaced an existing collection")
+	}
+
+	errA, errB := errors.New("a"), errors.New("b")
+	AddError(r.Context(), errA)
+	AddError(r.Context(), nil)
+	AddError(r.Context(), errB)
+	errs := Errors(r.Context())
+	if len(errs) != 2 || errs[0] != errA || errs[1] != errB {
+		t.Errorf("Errors = %v, want [a b]", errs)
+	}
+
+	errs[0] = nil
+	if Errors(r.Context())[0] != errA {
+		t.Error("Errors returned the collection itself instead of a copy")
+	}
+}
+
+func TestRequestErrorsWithoutCollection(t *testing.T) {
+	ctx := context.Background()
+	AddError(ctx, errors.New("dropped"))
+	if errs := Errors(ctx); errs != nil {
+		t.Errorf("Errors = %v without a collection, want nil", errs)
+	}
+}
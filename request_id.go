@@ -0,0 +1,63 @@
+package y_middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// DefaultRequestIDHeader is the header RequestID reads and sets by default.
+const DefaultRequestIDHeader = "X-Request-Id"
+
+// maxRequestIDLength caps the incoming request IDs RequestID accepts.
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestID is a middleware handler that gives every request an ID, stored in the request
+// context where handlers read it with RequestIDFrom, and echoed in the response Header. An ID
+// sent by the client in the Header is kept if it is printable and not too long; otherwise a
+// random one is generated.
+type RequestID struct {
+	Header string
+}
+
+// NewRequestID returns a new RequestID instance using the X-Request-Id header.
+func NewRequestID() *RequestID {
+	return &RequestID{Header: DefaultRequestIDHeader}
+}
+
+func (rid *RequestID) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	id := r.Header.Get(rid.Header)
+	if !validRequestID(id) {
+		id = newRequestID()
+		r.Header.Set(rid.Header, id)
+	}
+	rw.Header().Set(rid.Header, id)
+	next(rw, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+}
+
+// RequestIDFrom returns the ID RequestID assigned to the request, or an empty string.
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
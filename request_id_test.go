@@ -0,0 +1,44 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serveRequestID(id string) (string, string) {
+	var seen string
+	k := New(NewRequestID())
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFrom(r.Context())
+		if got := r.Header.Get(DefaultRequestIDHeader); got != seen {
+			panic("request header " + got + " differs from the context ID " + seen)
+		}
+	})
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if id != "" {
+		r.Header.Set(DefaultRequestIDHeader, id)
+	}
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, r)
+	return seen, rec.Header().Get(DefaultRequestIDHeader)
+}
+
+func TestRequestIDKeepsValidIDs(t *testing.T) {
+	if seen, echoed := serveRequestID("abc-123"); seen != "abc-123" || echoed != "abc-123" {
+		t.Errorf("got %q, echoed %q, want the client ID kept", seen, echoed)
+	}
+}
+
+func TestRequestIDReplacesInvalidIDs(t *testing.T) {
+	for _, id := range []string{"", "has space", strings.Repeat("a", maxRequestIDLength+1), "caf\xc3\xa9"} {
+		seen, echoed := serveRequestID(id)
+		if seen == id || len(seen) != 32 || echoed != seen {
+			t.Errorf("%q: got %q, echoed %q, want a fresh 32 character ID", id, seen, echoed)
+		}
+	}
+	if a, _ := serveRequestID(""); a == newRequestID() {
+		t.Error("generated IDs repeat")
+	}
+}
@@ -0,0 +1,67 @@
+package y_middleware
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// RequireBody is a middleware handler that rejects requests arriving without a body where one
+// is required with a 400, skipping the rest of the chain, before handlers try to decode empty
+// input. A body is required for the request Methods, on paths starting with one of PathPrefixes.
+// Requests of unknown length are checked by peeking at the first byte of the body.
+type RequireBody struct {
+	Methods []string
+	// PathPrefixes limits the check to paths with one of these prefixes. An empty list checks
+	// every path.
+	PathPrefixes []string
+}
+
+// NewRequireBody returns a new RequireBody instance requiring a body on POST, PUT and PATCH requests.
+func NewRequireBody() *RequireBody {
+	return &RequireBody{
+		Methods: []string{http.MethodPost, http.MethodPut, http.MethodPatch},
+	}
+}
+
+func (b *RequireBody) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !b.applies(r) || b.hasBody(r) {
+		next(rw, r)
+		return
+	}
+	http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+}
+
+func (b *RequireBody) applies(r *http.Request) bool {
+	if !containsMethod(b.Methods, r.Method) {
+		return false
+	}
+	if len(b.PathPrefixes) == 0 {
+		return true
+	}
+	for _, prefix := range b.PathPrefixes {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasBody reports whether r has a non-empty body, leaving the body readable from the start.
+func (b *RequireBody) hasBody(r *http.Request) bool {
+	if r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+		return false
+	}
+	if r.ContentLength > 0 {
+		return true
+	}
+
+	br := bufio.NewReaderSize(r.Body, 16)
+	_, err := br.Peek(1)
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{br, r.Body}
+	return err == nil
+}
@@ -0,0 +1,60 @@
+package y_middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serveRequireBody(b *RequireBody, r *http.Request) (int, string) {
+	var body string
+	k := New(b)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, r)
+	return rec.Code, body
+}
+
+// unknownLength returns a request whose body length is not known up front, like a chunked upload.
+func unknownLength(method, path, body string) *http.Request {
+	r := httptest.NewRequest(method, path, io.NopCloser(strings.NewReader(body)))
+	r.ContentLength = -1
+	return r
+}
+
+func TestRequireBody(t *testing.T) {
+	b := NewRequireBody()
+	tests := []struct {
+		name   string
+		r      *http.Request
+		status int
+		body   string
+	}{
+		{"with body", httptest.NewRequest(http.MethodPost, "/", strings.NewReader("data")), http.StatusOK, "data"},
+		{"empty body", httptest.NewRequest(http.MethodPost, "/", nil), http.StatusBadRequest, ""},
+		{"unknown length", unknownLength(http.MethodPut, "/", "chunked"), http.StatusOK, "chunked"},
+		{"unknown length, empty", unknownLength(http.MethodPatch, "/", ""), http.StatusBadRequest, ""},
+		{"GET", httptest.NewRequest(http.MethodGet, "/", nil), http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		if status, body := serveRequireBody(b, tt.r); status != tt.status || body != tt.body {
+			t.Errorf("%s: got %d %q, want %d %q", tt.name, status, body, tt.status, tt.body)
+		}
+	}
+}
+
+func TestRequireBodyPathPrefixes(t *testing.T) {
+	b := NewRequireBody()
+	b.PathPrefixes = []string{"/api/"}
+	if status, _ := serveRequireBody(b, httptest.NewRequest(http.MethodPost, "/api/orders", nil)); status != http.StatusBadRequest {
+		t.Errorf("empty POST under /api/: status = %d, want %d", status, http.StatusBadRequest)
+	}
+	if status, _ := serveRequireBody(b, httptest.NewRequest(http.MethodPost, "/logout", nil)); status != http.StatusOK {
+		t.Errorf("empty POST elsewhere: status = %d, want %d", status, http.StatusOK)
+	}
+}
@@ -0,0 +1,53 @@
+package y_middleware
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// RequireContentType is a middleware handler that rejects POST, PUT and PATCH requests whose
+// media type is not in an allowlist with a 415 Unsupported Media Type, skipping the rest of the
+// chain. Parameters such as charset are ignored when comparing.
+type RequireContentType struct {
+	types []string
+}
+
+// NewRequireContentType returns a new RequireContentType instance allowing the given media types.
+func NewRequireContentType(types ...string) *RequireContentType {
+	allowed := make([]string, len(types))
+	for i, t := range types {
+		allowed[i] = strings.ToLower(t)
+	}
+	return &RequireContentType{types: allowed}
+}
+
+func (c *RequireContentType) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !methodHasBody(r.Method) || c.allowed(r.Header.Get("Content-Type")) {
+		next(rw, r)
+		return
+	}
+	http.Error(rw, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+}
+
+func (c *RequireContentType) allowed(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range c.types {
+		if t == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// methodHasBody reports whether requests with method are expected to carry a body.
+func methodHasBody(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireContentType(t *testing.T) {
+	c := NewRequireContentType("application/JSON", "application/x-www-form-urlencoded")
+	tests := []struct {
+		method, contentType string
+		want                int
+	}{
+		{http.MethodPost, "application/json", http.StatusOK},
+		{http.MethodPut, "Application/Json; charset=utf-8", http.StatusOK},
+		{http.MethodPatch, "application/x-www-form-urlencoded", http.StatusOK},
+		{http.MethodPost, "text/plain", http.StatusUnsupportedMediaType},
+		{http.MethodPost, "", http.StatusUnsupportedMediaType},
+		{http.MethodPost, "application/json; =", http.StatusUnsupportedMediaType},
+		{http.MethodGet, "text/plain", http.StatusOK},
+		{http.MethodDelete, "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		k := New(c)
+		k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {})
+		req := httptest.NewRequest(tt.method, "/", nil)
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		rec := httptest.NewRecorder()
+		k.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s with %q: status = %d, want %d", tt.method, tt.contentType, rec.Code, tt.want)
+		}
+	}
+}
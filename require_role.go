@@ -0,0 +1,90 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+)
+
+// RoleMode tells RequireRole how many of its roles a principal needs.
+type RoleMode int
+
+const (
+	// AnyRole requires at least one of the roles.
+	AnyRole RoleMode = iota
+	// AllRoles requires every one of the roles.
+	AllRoles
+)
+
+// RolePrincipal is implemented by principals that have roles.
+type RolePrincipal interface {
+	Roles() []string
+}
+
+// RequireRole is a middleware handler that only serves requests whose authenticated principal
+// has the required Roles, any or all of them depending on Mode. It goes after AuthChain or JWT:
+// requests without a principal get a 401, and requests whose principal lacks the roles get a
+// 403, and the rest of the chain is skipped.
+//
+// By default the roles are those of a RolePrincipal, or the "roles" claim of JWT Claims;
+// PrincipalRoles replaces the lookup.
+type RequireRole struct {
+	Roles []string
+	Mode  RoleMode
+	// PrincipalRoles returns the roles of the request's principal, and whether there is one.
+	PrincipalRoles func(ctx context.Context) ([]string, bool)
+}
+
+// NewRequireRole returns a new RequireRole instance requiring any of roles.
+func NewRequireRole(roles ...string) *RequireRole {
+	return &RequireRole{
+		Roles:          roles,
+		PrincipalRoles: principalRoles,
+	}
+}
+
+func (rr *RequireRole) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	lookup := rr.PrincipalRoles
+	if lookup == nil {
+		lookup = principalRoles
+	}
+	roles, ok := lookup(r.Context())
+	if !ok {
+		http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	if !rr.authorized(roles) {
+		http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+	next(rw, r)
+}
+
+func (rr *RequireRole) authorized(roles []string) bool {
+	has := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		has[role] = true
+	}
+	for _, role := range rr.Roles {
+		if has[role] && rr.Mode == AnyRole {
+			return true
+		}
+		if !has[role] && rr.Mode == AllRoles {
+			return false
+		}
+	}
+	return rr.Mode == AllRoles || len(rr.Roles) == 0
+}
+
+func principalRoles(ctx context.Context) ([]string, bool) {
+	if claims, ok := ClaimsFrom(ctx); ok {
+		return claimStrings(claims, "roles"), true
+	}
+	principal, ok := PrincipalFrom(ctx)
+	if !ok {
+		return nil, false
+	}
+	if p, ok := principal.(RolePrincipal); ok {
+		return p.Roles(), true
+	}
+	return nil, true
+}
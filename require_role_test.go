@@ -0,0 +1,93 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type rolesUser []string
+
+func (u rolesUser) Roles() []string { return u }
+
+// serveWithPrincipal serves rr behind an AuthChain authenticating every request as principal,
+// or behind nothing if principal is nil.
+func serveWithPrincipal(rr *RequireRole, principal any) int {
+	var k *Kudret
+	if principal == nil {
+		k = New(rr)
+	} else {
+		k = New(NewAuthChain(func(r *http.Request) (any, bool, error) {
+			return principal, true, nil
+		}), rr)
+	}
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	return rec.Code
+}
+
+func TestRequireRoleAnyRole(t *testing.T) {
+	rr := NewRequireRole("admin", "editor")
+	tests := []struct {
+		name      string
+		principal any
+		want      int
+	}{
+		{"has one role", rolesUser{"viewer", "editor"}, http.StatusOK},
+		{"missing roles", rolesUser{"viewer"}, http.StatusForbidden},
+		{"claims", Claims{"roles": []any{"admin"}}, http.StatusOK},
+		{"claims without roles", Claims{"sub": "alice"}, http.StatusForbidden},
+		{"principal without roles", "alice", http.StatusForbidden},
+		{"no principal", nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if got := serveWithPrincipal(rr, tt.principal); got != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRequireRoleAllRoles(t *testing.T) {
+	rr := NewRequireRole("admin", "editor")
+	rr.Mode = AllRoles
+	if got := serveWithPrincipal(rr, rolesUser{"editor", "admin"}); got != http.StatusOK {
+		t.Errorf("status = %d with every role, want %d", got, http.StatusOK)
+	}
+	if got := serveWithPrincipal(rr, rolesUser{"admin"}); got != http.StatusForbidden {
+		t.Errorf("status = %d with one role, want %d", got, http.StatusForbidden)
+	}
+}
+
+func TestRequireRoleJWTClaims(t *testing.T) {
+	j := NewJWT(NewJWTKeyVerifier(JWTKey(jwtSecret)))
+	k := New(j, NewRequireRole("admin"))
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {})
+
+	for _, tt := range []struct {
+		roles []any
+		want  int
+	}{
+		{[]any{"admin"}, http.StatusOK},
+		{[]any{"viewer"}, http.StatusForbidden},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+signJWT(t, "HS256", "", jwtSecret, Claims{"roles": tt.roles}))
+		rec := httptest.NewRecorder()
+		k.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("roles %v: status = %d, want %d", tt.roles, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestRequireRoleCustomLookup(t *testing.T) {
+	rr := NewRequireRole("admin")
+	rr.PrincipalRoles = func(ctx context.Context) ([]string, bool) {
+		return []string{"admin"}, true
+	}
+	if got := serveWithPrincipal(rr, nil); got != http.StatusOK {
+		t.Errorf("status = %d, want %d", got, http.StatusOK)
+	}
+}
@@ -0,0 +1,153 @@
+package y_middleware
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// responseBuffer is a http.ResponseWriter that holds on to the status, headers and
+// body written by a handler so they can be inspected, rewritten or replayed later. It is the
+// buffering machinery shared by middleware that need to see a response before sending it.
+//
+// It gives up buffering for responses detected as streams, see isStreamingResponse, for
+// handlers that called DisableBuffering or flush, and for bodies growing past max bytes: from
+// then on the response is written straight to the http.ResponseWriter it was created for, and
+// Streamed reports true.
+type responseBuffer struct {
+	header    http.Header
+	status    int
+	body      bytes.Buffer
+	rw        http.ResponseWriter
+	max       int
+	flag      *bufferingFlag
+	streaming bool
+}
+
+// newResponseBuffer returns a responseBuffer streaming to rw when it stops buffering, and r
+// with a context that lets downstream handlers call DisableBuffering. A max of zero or less
+// buffers bodies of any size.
+func newResponseBuffer(rw http.ResponseWriter, r *http.Request, max int) (*responseBuffer, *http.Request) {
+	flag, r := bufferingFlagFor(r)
+	return &responseBuffer{header: make(http.Header), rw: rw, max: max, flag: flag}, r
+}
+
+func (b *responseBuffer) Header() http.Header {
+	if b.streaming {
+		return b.rw.Header()
+	}
+	return b.header
+}
+
+func (b *responseBuffer) WriteHeader(code int) {
+	if b.streaming {
+		b.rw.WriteHeader(code)
+		return
+	}
+	if b.status == 0 {
+		b.status = code
+	}
+	if b.shouldStream() {
+		b.stream()
+	}
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	if b.streaming {
+		return b.rw.Write(p)
+	}
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	if b.max > 0 && b.body.Len()+len(p) > b.max || b.shouldStream() {
+		if err := b.stream(); err != nil {
+			return 0, err
+		}
+		return b.rw.Write(p)
+	}
+	return b.body.Write(p)
+}
+
+func (b *responseBuffer) Flush() {
+	b.stream()
+	if flusher, ok := b.rw.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the http.ResponseWriter the buffer streams to, so http.ResponseController
+// can reach it.
+func (b *responseBuffer) Unwrap() http.ResponseWriter {
+	return b.rw
+}
+
+// Status returns the buffered status code, defaulting to 200.
+func (b *responseBuffer) Status() int {
+	if b.status == 0 {
+		return http.StatusOK
+	}
+	return b.status
+}
+
+// Len returns the number of buffered body bytes.
+func (b *responseBuffer) Len() int {
+	return b.body.Len()
+}
+
+// Bytes returns the buffered body.
+func (b *responseBuffer) Bytes() []byte {
+	return b.body.Bytes()
+}
+
+// replaceBody replaces the buffered body with p, for middleware rewriting the response
+// before it is sent.
+func (b *responseBuffer) replaceBody(p []byte) {
+	b.body.Reset()
+	b.body.Write(p)
+}
+
+// Written reports whether the handler wrote a status or body.
+func (b *responseBuffer) Written() bool {
+	return b.status != 0 || b.streaming
+}
+
+// Streamed reports whether the buffer gave up buffering and wrote the response through.
+func (b *responseBuffer) Streamed() bool {
+	return b.streaming
+}
+
+func (b *responseBuffer) shouldStream() bool {
+	return isStreamingResponse(b.header) || b.flag.disabled()
+}
+
+// stream sends the buffered headers, status and body to the http.ResponseWriter and makes
+// later writes go straight to it.
+func (b *responseBuffer) stream() error {
+	if b.streaming {
+		return nil
+	}
+	b.streaming = true
+	err := b.flush(b.rw)
+	b.body.Reset()
+	return err
+}
+
+// flush copies the buffered response onto rw. The buffer itself is left untouched so
+// the same response can be flushed more than once.
+func (b *responseBuffer) flush(rw http.ResponseWriter) error {
+	b.copyHeader(rw)
+	rw.WriteHeader(b.Status())
+	if b.body.Len() == 0 {
+		return nil
+	}
+	_, err := rw.Write(b.body.Bytes())
+	return err
+}
+
+// copyHeader copies the buffered headers onto rw without writing a status, for responses the
+// handler wrote nothing for.
+func (b *responseBuffer) copyHeader(rw http.ResponseWriter) {
+	h := rw.Header()
+	for k, v := range b.header {
+		h[k] = append([]string(nil), v...)
+	}
+}
@@ -0,0 +1,69 @@
+package y_middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// streamingHandler writes one event and reports whether it reached rec before returning.
+func streamingHandler(rec *httptest.ResponseRecorder, streamed *bool, disable bool) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if disable {
+			DisableBuffering(r.Context())
+		} else {
+			rw.Header().Set("Content-Type", "text/event-stream")
+		}
+		io.WriteString(rw, "data: hello\n\n")
+		*streamed = rec.Body.String() == "data: hello\n\n"
+	}
+}
+
+func TestBufferingMiddlewareStreams(t *testing.T) {
+	cases := []struct {
+		name    string
+		handler Handler
+		req     func() *http.Request
+	}{
+		{"Retry", NewRetry(2), func() *http.Request { return httptest.NewRequest(http.MethodGet, "/", nil) }},
+		{"BufferResponse", NewBufferResponse(DefaultBufferResponseMaxSize), func() *http.Request { return httptest.NewRequest(http.MethodGet, "/", nil) }},
+		{"PrettyJSON", &PrettyJSON{Always: true}, func() *http.Request { return httptest.NewRequest(http.MethodGet, "/", nil) }},
+		{"SingleFlight", NewSingleFlight(), func() *http.Request { return httptest.NewRequest(http.MethodGet, "/", nil) }},
+		{"Idempotency", NewIdempotency(nil), func() *http.Request {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			r.Header.Set("Idempotency-Key", "k")
+			return r
+		}},
+	}
+	for _, c := range cases {
+		for _, disable := range []bool{false, true} {
+			rec := httptest.NewRecorder()
+			var streamed bool
+			c.handler.ServeHTTP(rec, c.req(), streamingHandler(rec, &streamed, disable))
+			if !streamed {
+				t.Errorf("%s (DisableBuffering %v): response was held back", c.name, disable)
+			}
+			if rec.Body.String() != "data: hello\n\n" {
+				t.Errorf("%s (DisableBuffering %v): body = %q", c.name, disable, rec.Body.String())
+			}
+		}
+	}
+}
+
+func TestResponseBufferStreamsPastMax(t *testing.T) {
+	rec := httptest.NewRecorder()
+	buf, _ := newResponseBuffer(rec, httptest.NewRequest(http.MethodGet, "/", nil), 4)
+	buf.Header().Set("X-Test", "1")
+	io.WriteString(buf, "abc")
+	if buf.Streamed() || rec.Body.Len() != 0 {
+		t.Fatal("buffer streamed before reaching max")
+	}
+	io.WriteString(buf, "defg")
+	if !buf.Streamed() {
+		t.Fatal("buffer did not stream past max")
+	}
+	if rec.Body.String() != "abcdefg" || rec.Header().Get("X-Test") != "1" {
+		t.Errorf("body = %q, header = %v", rec.Body.String(), rec.Header())
+	}
+}
@@ -0,0 +1,62 @@
+package y_middleware
+
+import "net/http"
+
+// ResponseSizeLimit is a middleware handler that stops runaway responses. Once a handler has
+// written MaxSize body bytes, the rest of the body is either dropped silently or, with Abort
+// set, the connection is closed by aborting the handler with http.ErrAbortHandler. The first
+// overrun of each response is logged when Logger is set.
+type ResponseSizeLimit struct {
+	MaxSize int64
+	Abort   bool
+	Logger  ALogger
+}
+
+// NewResponseSizeLimit returns a new ResponseSizeLimit instance truncating bodies at maxSize bytes.
+func NewResponseSizeLimit(maxSize int64) *ResponseSizeLimit {
+	return &ResponseSizeLimit{MaxSize: maxSize}
+}
+
+func (l *ResponseSizeLimit) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	next(&limitedResponseWriter{ResponseWriter: wrapResponseWriter(rw), limit: l, r: r}, r)
+}
+
+// limitedResponseWriter passes on at most limit.MaxSize body bytes.
+type limitedResponseWriter struct {
+	ResponseWriter
+	limit    *ResponseSizeLimit
+	r        *http.Request
+	n        int64
+	exceeded bool
+}
+
+func (w *limitedResponseWriter) Write(p []byte) (int, error) {
+	room := w.limit.MaxSize - w.n
+	if int64(len(p)) <= room {
+		n, err := w.ResponseWriter.Write(p)
+		w.n += int64(n)
+		return n, err
+	}
+
+	if room > 0 {
+		n, err := w.ResponseWriter.Write(p[:room])
+		w.n += int64(n)
+		if err != nil {
+			return n, err
+		}
+	}
+	if !w.exceeded {
+		w.exceeded = true
+		if w.limit.Logger != nil {
+			w.limit.Logger.Printf("%s %s: response body exceeded %d bytes", w.r.Method, w.r.URL.Path, w.limit.MaxSize)
+		}
+	}
+	if w.limit.Abort {
+		panic(http.ErrAbortHandler)
+	}
+	return len(p), nil
+}
+
+func (w *limitedResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
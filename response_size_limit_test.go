@@ -0,0 +1,54 @@
+package y_middleware
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func serveSizeLimited(l *ResponseSizeLimit) *httptest.ResponseRecorder {
+	k := New(l)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 3; i++ {
+			if n, err := io.WriteString(rw, "0123"); n != 4 || err != nil {
+				panic("write reported a short write")
+			}
+		}
+	})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/export", nil))
+	return rec
+}
+
+func TestResponseSizeLimitTruncates(t *testing.T) {
+	var logged bytes.Buffer
+	l := NewResponseSizeLimit(6)
+	l.Logger = log.New(&logged, "", 0)
+
+	rec := serveSizeLimited(l)
+	if rec.Body.String() != "012301" {
+		t.Errorf("body = %q, want it cut at 6 bytes", rec.Body)
+	}
+	if want := "GET /export: response body exceeded 6 bytes\n"; logged.String() != want {
+		t.Errorf("log = %q, want one %q", logged.String(), want)
+	}
+
+	if rec := serveSizeLimited(NewResponseSizeLimit(12)); rec.Body.String() != "012301230123" {
+		t.Errorf("body within the limit = %q, want it whole", rec.Body)
+	}
+}
+
+func TestResponseSizeLimitAborts(t *testing.T) {
+	l := NewResponseSizeLimit(6)
+	l.Abort = true
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler", v)
+		}
+	}()
+	serveSizeLimited(l)
+	t.Error("handler was not aborted")
+}
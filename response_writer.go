@@ -0,0 +1,138 @@
+package y_middleware
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// ResponseWriter is a wrapper around http.ResponseWriter that provides extra information about
+// the response. Kudret wraps every response in one, so middleware can type assert to it
+// when it needs the status or size of what downstream handlers wrote.
+type ResponseWriter interface {
+	http.ResponseWriter
+	http.Flusher
+	// Status returns the status code of the response or 0 if the response has not been written.
+	Status() int
+	// Written returns whether or not the ResponseWriter has been written.
+	Written() bool
+	// Size returns the size of the response body.
+	Size() int
+	// Before allows for a function to be called before the ResponseWriter has been written to. This is
+	// useful for setting headers or any other operations that must happen before a response has been written.
+	Before(func(ResponseWriter))
+}
+
+type beforeFunc func(ResponseWriter)
+
+// NewResponseWriter creates a ResponseWriter that wraps a http.ResponseWriter.
+func NewResponseWriter(rw http.ResponseWriter) ResponseWriter {
+	return &responseWriter{
+		ResponseWriter: rw,
+	}
+}
+
+// wrapResponseWriter returns rw as a ResponseWriter, wrapping it only if it isn't one already.
+// It lets middleware rely on the instrumented writer even when used outside a Kudret stack.
+func wrapResponseWriter(rw http.ResponseWriter) ResponseWriter {
+	if res, ok := rw.(ResponseWriter); ok {
+		return res
+	}
+	return NewResponseWriter(rw)
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	pendingStatus int
+	status        int
+	size          int
+	beforeFuncs   []beforeFunc
+	callingBefore bool
+}
+
+func (rw *responseWriter) WriteHeader(s int) {
+	if rw.Written() {
+		return
+	}
+
+	rw.pendingStatus = s
+	rw.callBefore()
+
+	// Any of the rw.beforeFuncs may have written a header,
+	// so check again to see if any work is necessary.
+	if rw.Written() {
+		return
+	}
+
+	rw.status = s
+	rw.ResponseWriter.WriteHeader(s)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if !rw.Written() {
+		// The status will be StatusOK if WriteHeader has not been called yet
+		rw.WriteHeader(http.StatusOK)
+	}
+	size, err := rw.ResponseWriter.Write(b)
+	rw.size += size
+	return size, err
+}
+
+func (rw *responseWriter) Status() int {
+	if rw.Written() {
+		return rw.status
+	}
+	return rw.pendingStatus
+}
+
+func (rw *responseWriter) Size() int {
+	return rw.size
+}
+
+func (rw *responseWriter) Written() bool {
+	return rw.status != 0
+}
+
+func (rw *responseWriter) Before(before func(ResponseWriter)) {
+	rw.beforeFuncs = append(rw.beforeFuncs, before)
+}
+
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("the ResponseWriter doesn't support the Hijacker interface")
+	}
+	return hijacker.Hijack()
+}
+
+func (rw *responseWriter) Flush() {
+	flusher, ok := rw.ResponseWriter.(http.Flusher)
+	if ok {
+		if !rw.Written() {
+			// The status will be StatusOK if WriteHeader has not been called yet
+			rw.WriteHeader(http.StatusOK)
+		}
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the wrapped http.ResponseWriter so http.ResponseController can reach it.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+func (rw *responseWriter) callBefore() {
+	// Don't recursively call before() functions, to avoid infinite looping if
+	// one of them calls rw.WriteHeader again.
+	if rw.callingBefore {
+		return
+	}
+
+	rw.callingBefore = true
+	defer func() { rw.callingBefore = false }()
+
+	for i := len(rw.beforeFuncs) - 1; i >= 0; i-- {
+		rw.beforeFuncs[i](rw)
+	}
+}
@@ -0,0 +1,87 @@
+package y_middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseWriterRecordsStatusAndSize(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := NewResponseWriter(rec)
+	if rw.Written() || rw.Status() != 0 {
+		t.Errorf("new ResponseWriter: Written = %v, Status = %d; want false, 0", rw.Written(), rw.Status())
+	}
+
+	rw.WriteHeader(http.StatusCreated)
+	rw.WriteHeader(http.StatusInternalServerError)
+	io.WriteString(rw, "hello")
+	io.WriteString(rw, " world")
+
+	if rw.Status() != http.StatusCreated || rec.Code != http.StatusCreated {
+		t.Errorf("status = %d (sent %d), want %d", rw.Status(), rec.Code, http.StatusCreated)
+	}
+	if rw.Size() != 11 {
+		t.Errorf("Size = %d, want 11", rw.Size())
+	}
+}
+
+func TestResponseWriterWriteImpliesOK(t *testing.T) {
+	rw := NewResponseWriter(httptest.NewRecorder())
+	io.WriteString(rw, "x")
+	if !rw.Written() || rw.Status() != http.StatusOK {
+		t.Errorf("Written = %v, Status = %d; want true, 200", rw.Written(), rw.Status())
+	}
+}
+
+func TestResponseWriterBefore(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := NewResponseWriter(rec)
+	var order []string
+	rw.Before(func(w ResponseWriter) {
+		order = append(order, "first")
+	})
+	rw.Before(func(w ResponseWriter) {
+		order = append(order, "second")
+		if w.Status() != http.StatusAccepted {
+			t.Errorf("pending status = %d in Before, want %d", w.Status(), http.StatusAccepted)
+		}
+		w.Header().Set("X-Before", "1")
+	})
+
+	rw.WriteHeader(http.StatusAccepted)
+	if rec.Header().Get("X-Before") != "1" {
+		t.Error("header set in Before was not sent")
+	}
+	if len(order) != 2 || order[0] != "second" || order[1] != "first" {
+		t.Errorf("Before funcs ran in order %v, want [second first]", order)
+	}
+}
+
+func TestResponseWriterBeforeCanReplaceStatus(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := NewResponseWriter(rec)
+	rw.Before(func(w ResponseWriter) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	rw.WriteHeader(http.StatusOK)
+	if rec.Code != http.StatusTeapot || rw.Status() != http.StatusTeapot {
+		t.Errorf("status = %d (sent %d), want %d", rw.Status(), rec.Code, http.StatusTeapot)
+	}
+}
+
+func TestResponseWriterFlushAndUnwrap(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := NewResponseWriter(rec)
+	rw.Flush()
+	if !rec.Flushed || rw.Status() != http.StatusOK {
+		t.Errorf("Flushed = %v, Status = %d; want true, 200", rec.Flushed, rw.Status())
+	}
+	if err := http.NewResponseController(rw).Flush(); err != nil {
+		t.Errorf("ResponseController.Flush = %v", err)
+	}
+	if wrapResponseWriter(rw) != rw {
+		t.Error("wrapResponseWriter wrapped a ResponseWriter again")
+	}
+}
@@ -0,0 +1,173 @@
+package y_middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type attemptKey struct{}
+
+const (
+	// DefaultRetryBackoff is the wait before the first retry; it doubles with every further attempt.
+	DefaultRetryBackoff = 100 * time.Millisecond
+	// DefaultRetryMaxRetryAfter is the longest Retry-After wait honored by default.
+	DefaultRetryMaxRetryAfter = 10 * time.Second
+	// DefaultRetryMaxBodySize is the largest request body Retry buffers for replay by default.
+	DefaultRetryMaxBodySize = 1 << 20
+	// DefaultRetryMaxResponseSize is the largest response body Retry buffers by default.
+	DefaultRetryMaxResponseSize = 1 << 20
+)
+
+// Retry is a middleware handler that re-runs the rest of the chain when it responds with a
+// retryable status, for stacks that proxy to upstreams with transient failures. Each attempt's
+// response is buffered and only the last one is sent to the client. Only idempotent requests
+// are retried. When a retryable response carries a Retry-After header, its delay is waited
+// instead of the backoff, capped at MaxRetryAfter.
+//
+// Requests with bodies larger than MaxBodySize are passed on without retries. Attempts whose
+// response grows past MaxResponseSize, or that stream their response, see DisableBuffering, are
+// sent as they are and never retried.
+type Retry struct {
+	// MaxRetries is how many times the chain is re-run after the first attempt.
+	MaxRetries int
+	// Statuses are the response statuses that are retried.
+	Statuses []int
+	// Backoff is the wait before the first retry. It doubles with every further attempt.
+	Backoff time.Duration
+	// MaxRetryAfter caps the wait a Retry-After header can ask for. Zero ignores Retry-After.
+	MaxRetryAfter time.Duration
+	// MaxBodySize is the largest request body buffered so it can be sent again. Zero means no limit.
+	MaxBodySize int64
+	// MaxResponseSize is the largest response body buffered before it is sent. Zero means no limit.
+	MaxResponseSize int
+}
+
+// NewRetry returns a new Retry instance that retries 502, 503 and 504 responses up to maxRetries times.
+func NewRetry(maxRetries int) *Retry {
+	return &Retry{
+		MaxRetries:      maxRetries,
+		Statuses:        []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+		Backoff:         DefaultRetryBackoff,
+		MaxRetryAfter:   DefaultRetryMaxRetryAfter,
+		MaxBodySize:     DefaultRetryMaxBodySize,
+		MaxResponseSize: DefaultRetryMaxResponseSize,
+	}
+}
+
+func (rt *Retry) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !isIdempotent(r.Method) {
+		next(rw, r)
+		return
+	}
+
+	if rt.MaxBodySize > 0 && r.ContentLength > rt.MaxBodySize {
+		next(rw, r)
+		return
+	}
+	body, err := bufferBody(r, rt.MaxBodySize)
+	if errors.Is(err, errBodyTooLarge) {
+		next(rw, r)
+		return
+	}
+	if err != nil {
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	backoff := rt.Backoff
+	for attempt := 0; ; attempt++ {
+		ar := r.WithContext(context.WithValue(r.Context(), attemptKey{}, attempt+1))
+		ar.Body = io.NopCloser(bytes.NewReader(body))
+		buf, ar := newResponseBuffer(rw, ar, rt.MaxResponseSize)
+		next(buf, ar)
+
+		if buf.Streamed() {
+			// The response is already on its way to the client.
+			return
+		}
+		if attempt >= rt.MaxRetries || !rt.retryable(buf.Status()) || !sleepContext(r, rt.wait(buf.Header(), backoff)) {
+			buf.flush(rw)
+			return
+		}
+		backoff *= 2
+	}
+}
+
+// wait returns how long to wait before the next attempt: the Retry-After delay of the response
+// if it has a valid one, backoff otherwise.
+func (rt *Retry) wait(h http.Header, backoff time.Duration) time.Duration {
+	if rt.MaxRetryAfter <= 0 {
+		return backoff
+	}
+	d, ok := parseRetryAfter(h.Get("Retry-After"))
+	if !ok {
+		return backoff
+	}
+	return min(d, rt.MaxRetryAfter)
+}
+
+// parseRetryAfter parses a Retry-After value given either in seconds or as an HTTP date.
+func parseRetryAfter(v string) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(v, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		// Cap before converting so huge values don't overflow into a negative wait.
+		return time.Duration(min(seconds, int64(math.MaxInt64/time.Second))) * time.Second, true
+	}
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	return max(time.Until(t), 0), true
+}
+
+func (rt *Retry) retryable(status int) bool {
+	for _, s := range rt.Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Attempt returns which attempt at serving the request Retry is on, starting at 1, or 0 without
+// a Retry in the chain. Logger and AccessLog include it in their lines.
+func Attempt(ctx context.Context) int {
+	attempt, _ := ctx.Value(attemptKey{}).(int)
+	return attempt
+}
+
+// sleepContext waits for d, returning false if the request context is done first.
+func sleepContext(r *http.Request, d time.Duration) bool {
+	if d <= 0 {
+		return r.Context().Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package y_middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryRetriesUntilSuccess(t *testing.T) {
+	rt := NewRetry(3)
+	rt.Backoff = 0
+	var attempts []int
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader("payload"))
+	rt.ServeHTTP(rec, req, func(rw http.ResponseWriter, r *http.Request) {
+		attempts = append(attempts, Attempt(r.Context()))
+		if body, _ := io.ReadAll(r.Body); string(body) != "payload" {
+			t.Errorf("attempt %d body = %q", len(attempts), body)
+		}
+		if len(attempts) < 3 {
+			rw.Header().Set("X-Failed", "1")
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			io.WriteString(rw, "unavailable")
+			return
+		}
+		io.WriteString(rw, "ok")
+	})
+
+	if len(attempts) != 3 || attempts[0] != 1 || attempts[2] != 3 {
+		t.Errorf("attempts = %v, want [1 2 3]", attempts)
+	}
+	if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+		t.Errorf("response = %d %q, want 200 ok", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("X-Failed") != "" {
+		t.Error("headers of a failed attempt leaked into the response")
+	}
+}
+
+func TestRetryDoesNotRetryPost(t *testing.T) {
+	rt := NewRetry(3)
+	rt.Backoff = 0
+	n := 0
+	rec := httptest.NewRecorder()
+	rt.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil), func(rw http.ResponseWriter, r *http.Request) {
+		n++
+		rw.WriteHeader(http.StatusBadGateway)
+	})
+	if n != 1 || rec.Code != http.StatusBadGateway {
+		t.Errorf("ran %d times with status %d, want once with 502", n, rec.Code)
+	}
+}
+
+func TestRetryPassesLargeBodiesThrough(t *testing.T) {
+	rt := NewRetry(3)
+	rt.Backoff = 0
+	rt.MaxBodySize = 4
+
+	for _, contentLength := range []int64{10, -1} {
+		n := 0
+		req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader("0123456789"))
+		req.ContentLength = contentLength
+		rec := httptest.NewRecorder()
+		rt.ServeHTTP(rec, req, func(rw http.ResponseWriter, r *http.Request) {
+			n++
+			if body, _ := io.ReadAll(r.Body); string(body) != "0123456789" {
+				t.Errorf("body = %q, want it in full", body)
+			}
+			rw.WriteHeader(http.StatusServiceUnavailable)
+		})
+		if n != 1 || rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("ContentLength %d: ran %d times with status %d, want once with 503", contentLength, n, rec.Code)
+		}
+	}
+}
+
+func TestRetryDoesNotRetryLargeResponses(t *testing.T) {
+	rt := NewRetry(3)
+	rt.Backoff = 0
+	rt.MaxResponseSize = 4
+	n := 0
+	rec := httptest.NewRecorder()
+	rt.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil), func(rw http.ResponseWriter, r *http.Request) {
+		n++
+		rw.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(rw, "a long error page")
+	})
+	if n != 1 || rec.Code != http.StatusServiceUnavailable || rec.Body.String() != "a long error page" {
+		t.Errorf("ran %d times, response = %d %q", n, rec.Code, rec.Body.String())
+	}
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	rt := NewRetry(1)
+	rt.Backoff = time.Hour
+	rt.MaxRetryAfter = time.Millisecond
+	n := 0
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	rt.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil), func(rw http.ResponseWriter, r *http.Request) {
+		n++
+		if n == 1 {
+			rw.Header().Set("Retry-After", "120")
+			rw.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+	if n != 2 || rec.Code != http.StatusOK {
+		t.Errorf("ran %d times with status %d, want twice ending in 200", n, rec.Code)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Retry-After was not capped at MaxRetryAfter")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	for _, c := range []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"5", 5 * time.Second, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{"", 0, false},
+		{"99999999999999999", time.Duration(1<<63-1) / time.Second * time.Second, true},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, true},
+	} {
+		got, ok := parseRetryAfter(c.in)
+		if got != c.want || ok != c.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", c.in, got, ok, c.want, c.ok)
+		}
+		if got < 0 {
+			t.Errorf("parseRetryAfter(%q) is negative", c.in)
+		}
+	}
+}
+
+func TestRetryWait(t *testing.T) {
+	rt := NewRetry(1)
+	for _, c := range []struct {
+		retryAfter    string
+		maxRetryAfter time.Duration
+		want          time.Duration
+	}{
+		{"", DefaultRetryMaxRetryAfter, time.Second},
+		{"soon", DefaultRetryMaxRetryAfter, time.Second},
+		{"3", DefaultRetryMaxRetryAfter, 3 * time.Second},
+		{"3", 0, time.Second},
+		{"60", DefaultRetryMaxRetryAfter, DefaultRetryMaxRetryAfter},
+	} {
+		rt.MaxRetryAfter = c.maxRetryAfter
+		h := http.Header{}
+		h.Set("Retry-After", c.retryAfter)
+		if got := rt.wait(h, time.Second); got != c.want {
+			t.Errorf("wait(Retry-After %q, MaxRetryAfter %v) = %v, want %v", c.retryAfter, c.maxRetryAfter, got, c.want)
+		}
+	}
+}
+
+func TestRetryLabelsLogLines(t *testing.T) {
+	var accessBuf, loggerBuf bytes.Buffer
+	l, _ := bufferedLogger(&loggerBuf, LoggerDefaultFormat)
+	l.SetFormat("{{.Status}}{{if .Attempt}} attempt {{.Attempt}}{{end}}")
+	rt := NewRetry(1)
+	rt.Backoff = 0
+
+	k := New(rt, NewAccessLog(&accessBuf, CommonLogFormat).WithClock(newFakeClock()), l)
+	n := 0
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if n++; n == 1 {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(rw, "ok")
+	})
+	k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	access := strings.Split(strings.TrimSpace(accessBuf.String()), "\n")
+	if len(access) != 2 || !strings.HasSuffix(access[0], " 503 - attempt=1") || !strings.HasSuffix(access[1], " 200 2 attempt=2") {
+		t.Errorf("access log = %q, want both attempts labelled", access)
+	}
+	if got, want := strings.Fields(loggerBuf.String()), []string{"503", "attempt", "1", "200", "attempt", "2"}; strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("logger = %q, want %q", loggerBuf.String(), want)
+	}
+
+	accessBuf.Reset()
+	k = New(NewAccessLog(&accessBuf, CommonLogFormat).WithClock(newFakeClock()))
+	k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if strings.Contains(accessBuf.String(), "attempt=") {
+		t.Errorf("access log = %q without Retry, want no attempt field", accessBuf.String())
+	}
+}
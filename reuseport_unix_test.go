@@ -0,0 +1,50 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package y_middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWithReusePortSharesAddress(t *testing.T) {
+	addr := freeAddr(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		k := New().WithReusePort()
+		go func() { done <- k.RunWithContext(ctx, addr) }()
+	}
+
+	// Both servers keep running until ctx is done.
+	select {
+	case err := <-done:
+		t.Fatalf("RunWithContext returned %v while sharing %s", err, addr)
+	case <-time.After(200 * time.Millisecond):
+	}
+	cancel()
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Errorf("RunWithContext = %v", err)
+		}
+	}
+}
+
+func TestWithoutReusePortAddressInUse(t *testing.T) {
+	addr := freeAddr(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first := make(chan error, 1)
+	go func() { first <- New().RunWithContext(ctx, addr) }()
+	time.Sleep(100 * time.Millisecond)
+
+	if err := New().RunWithContext(ctx, addr); err == nil {
+		t.Error("second RunWithContext without SO_REUSEPORT succeeded")
+	}
+	cancel()
+	<-first
+}
@@ -0,0 +1,57 @@
+package y_middleware
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// RNG is the source of randomness for probabilistic middleware. Middleware default to the
+// global math/rand source; tests can substitute a seeded one through WithRNG to get
+// reproducible results. Implementations must be safe for concurrent use.
+type RNG interface {
+	// Float64 returns a number in [0.0, 1.0).
+	Float64() float64
+	// Int63n returns a number in [0, n).
+	Int63n(n int64) int64
+}
+
+// NewSeededRNG returns an RNG producing the same sequence for the same seed.
+func NewSeededRNG(seed int64) RNG {
+	return &lockedRNG{r: rand.New(rand.NewSource(seed))}
+}
+
+type globalRNG struct{}
+
+func (globalRNG) Float64() float64 {
+	return rand.Float64()
+}
+
+func (globalRNG) Int63n(n int64) int64 {
+	return rand.Int63n(n)
+}
+
+// lockedRNG makes a *rand.Rand safe for concurrent use.
+type lockedRNG struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func (l *lockedRNG) Float64() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Float64()
+}
+
+func (l *lockedRNG) Int63n(n int64) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Int63n(n)
+}
+
+// rngOrDefault returns rng, or the global source if rng is nil.
+func rngOrDefault(rng RNG) RNG {
+	if rng == nil {
+		return globalRNG{}
+	}
+	return rng
+}
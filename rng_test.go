@@ -0,0 +1,25 @@
+package y_middleware
+
+import "testing"
+
+func TestSeededRNGIsReproducible(t *testing.T) {
+	a, b := NewSeededRNG(42), NewSeededRNG(42)
+	for i := 0; i < 10; i++ {
+		if x, y := a.Float64(), b.Float64(); x != y {
+			t.Fatalf("Float64 #%d = %v and %v for the same seed", i, x, y)
+		}
+		if x, y := a.Int63n(100), b.Int63n(100); x != y {
+			t.Fatalf("Int63n #%d = %v and %v for the same seed", i, x, y)
+		}
+	}
+}
+
+func TestRNGOrDefault(t *testing.T) {
+	if _, ok := rngOrDefault(nil).(globalRNG); !ok {
+		t.Error("rngOrDefault(nil) is not the global source")
+	}
+	rng := NewSeededRNG(1)
+	if rngOrDefault(rng) != rng {
+		t.Error("rngOrDefault replaced a configured RNG")
+	}
+}
@@ -0,0 +1,117 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+type (
+	routeTemplateKey struct{}
+	routeParamsKey   struct{}
+)
+
+// RouteLabel is a middleware handler that matches the request path against a set of route
+// templates and stores the matching template in the request context, where metrics, logging
+// and rate limiting middleware read it with RouteTemplate. Templates are slash separated;
+// a ":name" segment matches any single segment and a trailing "*" matches the rest of the path.
+// The first registered template that matches wins. The values of the ":name" segments are
+// stored too, and read with RouteParams.
+type RouteLabel struct {
+	templates []routeTemplate
+}
+
+type routeTemplate struct {
+	raw      string
+	segments []string
+}
+
+// NewRouteLabel returns a new RouteLabel instance matching the given templates, e.g. "/users/:id".
+func NewRouteLabel(templates ...string) *RouteLabel {
+	l := &RouteLabel{}
+	for _, t := range templates {
+		l.Add(t)
+	}
+	return l
+}
+
+// Add registers another template. It must not be called while requests are being served.
+func (l *RouteLabel) Add(template string) {
+	l.templates = append(l.templates, routeTemplate{
+		raw:      template,
+		segments: splitPath(template),
+	})
+}
+
+// Match returns the first template matching path.
+func (l *RouteLabel) Match(path string) (string, bool) {
+	template, _, ok := l.MatchParams(path)
+	return template, ok
+}
+
+// MatchParams returns the first template matching path along with the values of its ":name"
+// segments.
+func (l *RouteLabel) MatchParams(path string) (string, map[string]string, bool) {
+	segments := splitPath(path)
+	for _, t := range l.templates {
+		if params, ok := t.match(segments); ok {
+			return t.raw, params, true
+		}
+	}
+	return "", nil, false
+}
+
+func (l *RouteLabel) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if template, params, ok := l.MatchParams(r.URL.Path); ok {
+		ctx := context.WithValue(r.Context(), routeTemplateKey{}, template)
+		r = r.WithContext(context.WithValue(ctx, routeParamsKey{}, params))
+	}
+	next(rw, r)
+}
+
+// RouteTemplate returns the route template RouteLabel matched, or an empty string if none did.
+func RouteTemplate(ctx context.Context) string {
+	template, _ := ctx.Value(routeTemplateKey{}).(string)
+	return template
+}
+
+// RouteParams returns the values of the ":name" segments of the route template RouteLabel
+// matched, keyed by name without the colon. It returns nil if no template matched.
+func RouteParams(ctx context.Context) map[string]string {
+	params, _ := ctx.Value(routeParamsKey{}).(map[string]string)
+	return params
+}
+
+func (t routeTemplate) match(segments []string) (map[string]string, bool) {
+	params := map[string]string{}
+	for i, s := range t.segments {
+		if s == "*" && i == len(t.segments)-1 {
+			return params, true
+		}
+		if i >= len(segments) {
+			return nil, false
+		}
+		if name, ok := strings.CutPrefix(s, ":"); ok {
+			if segments[i] == "" {
+				return nil, false
+			}
+			params[name] = segments[i]
+			continue
+		}
+		if s != segments[i] {
+			return nil, false
+		}
+	}
+	if len(segments) != len(t.segments) {
+		return nil, false
+	}
+	return params, true
+}
+
+func splitPath(p string) []string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
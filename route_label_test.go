@@ -0,0 +1,78 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRouteLabelMatch(t *testing.T) {
+	l := NewRouteLabel("/users/:id", "/users/me", "/static/*", "/")
+	tests := []struct {
+		path, template string
+		ok             bool
+	}{
+		{"/users/42", "/users/:id", true},
+		{"/users/me", "/users/:id", true},
+		{"/users/42/", "/users/:id", true},
+		{"/users/", "", false},
+		{"/users/42/posts", "", false},
+		{"/static/css/app.css", "/static/*", true},
+		{"/static", "/static/*", true},
+		{"/", "/", true},
+		{"/other", "", false},
+	}
+	for _, tt := range tests {
+		template, ok := l.Match(tt.path)
+		if template != tt.template || ok != tt.ok {
+			t.Errorf("Match(%q) = %q, %v, want %q, %v", tt.path, template, ok, tt.template, tt.ok)
+		}
+	}
+}
+
+func TestRouteLabelStoresTemplate(t *testing.T) {
+	k := New(NewRouteLabel("/users/:id"))
+	var got []string
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		got = append(got, RouteTemplate(r.Context()))
+	})
+	for _, path := range []string{"/users/42", "/orders/7"} {
+		k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if len(got) != 2 || got[0] != "/users/:id" || got[1] != "" {
+		t.Errorf("templates = %q, want [/users/:id \"\"]", got)
+	}
+}
+
+func TestRouteLabelParams(t *testing.T) {
+	l := NewRouteLabel("/users/:id/posts/:post", "/files/:name/*")
+	tests := []struct {
+		path   string
+		params map[string]string
+	}{
+		{"/users/42/posts/7", map[string]string{"id": "42", "post": "7"}},
+		{"/files/report/2024/q1.pdf", map[string]string{"name": "report"}},
+		{"/users/42", nil},
+	}
+	for _, tt := range tests {
+		_, params, _ := l.MatchParams(tt.path)
+		if !reflect.DeepEqual(params, tt.params) {
+			t.Errorf("MatchParams(%q) params = %v, want %v", tt.path, params, tt.params)
+		}
+	}
+
+	var got map[string]string
+	k := New(l)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		got = RouteParams(r.Context())
+	})
+	k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1/posts/2", nil))
+	if got["id"] != "1" || got["post"] != "2" {
+		t.Errorf("RouteParams = %v, want id 1 and post 2", got)
+	}
+	k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/other", nil))
+	if got != nil {
+		t.Errorf("RouteParams without a match = %v, want nil", got)
+	}
+}
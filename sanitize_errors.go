@@ -0,0 +1,88 @@
+package y_middleware
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// DefaultSanitizeMaxLogged is how much of an original 5xx body SanitizeErrors logs by default.
+const DefaultSanitizeMaxLogged = 4 << 10
+
+// SanitizeErrors is a middleware handler that keeps internal error details, such as stack
+// traces and error messages, from leaking to clients. While Production is set, the body of every
+// 5xx response is replaced with the status text, and the original body is logged instead. Other
+// responses pass through untouched.
+type SanitizeErrors struct {
+	Production bool
+	// Logger receives the original bodies. When nil, the request's LoggerFrom logger is used.
+	Logger ALogger
+	// MaxLogged caps how many bytes of an original body are logged.
+	MaxLogged int
+}
+
+// NewSanitizeErrors returns a new SanitizeErrors instance that sanitizes while production is set.
+func NewSanitizeErrors(production bool) *SanitizeErrors {
+	return &SanitizeErrors{
+		Production: production,
+		MaxLogged:  DefaultSanitizeMaxLogged,
+	}
+}
+
+func (s *SanitizeErrors) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !s.Production {
+		next(rw, r)
+		return
+	}
+
+	sw := &sanitizingWriter{ResponseWriter: wrapResponseWriter(rw), max: s.MaxLogged}
+	next(sw, r)
+	if !sw.sanitized {
+		return
+	}
+
+	sw.ResponseWriter.Write([]byte(http.StatusText(sw.Status()) + "\n"))
+	if sw.original.Len() > 0 {
+		logger := s.Logger
+		if logger == nil {
+			logger = LoggerFrom(r.Context())
+		}
+		logger.Printf("%s %s: %d response body replaced: %s", r.Method, r.URL.Path, sw.Status(), sw.original.Bytes())
+	}
+}
+
+// sanitizingWriter swallows the body of 5xx responses, keeping up to max bytes of it.
+type sanitizingWriter struct {
+	ResponseWriter
+	max       int
+	sanitized bool
+	original  bytes.Buffer
+}
+
+func (w *sanitizingWriter) WriteHeader(code int) {
+	if !w.Written() && code >= http.StatusInternalServerError {
+		w.sanitized = true
+		h := w.Header()
+		h.Del("Content-Length")
+		h.Del("Content-Encoding")
+		h.Set("Content-Type", "text/plain; charset=utf-8")
+		h.Set("X-Content-Type-Options", "nosniff")
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *sanitizingWriter) Write(p []byte) (int, error) {
+	if !w.Written() {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.sanitized {
+		return w.ResponseWriter.Write(p)
+	}
+	if room := w.max - w.original.Len(); room > 0 {
+		w.original.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
+
+func (w *sanitizingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,82 @@
+package y_middleware
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func serveSanitized(s *SanitizeErrors, status int, body string) *httptest.ResponseRecorder {
+	k := New(s)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Header().Set("Content-Length", "99")
+		rw.WriteHeader(status)
+		io.WriteString(rw, body)
+	})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders", nil))
+	return rec
+}
+
+func TestSanitizeErrorsReplacesServerErrors(t *testing.T) {
+	var logged bytes.Buffer
+	s := NewSanitizeErrors(true)
+	s.Logger = log.New(&logged, "", 0)
+
+	rec := serveSanitized(s, http.StatusInternalServerError, `{"error":"pq: relation users does not exist"}`)
+	if rec.Code != http.StatusInternalServerError || rec.Body.String() != "Internal Server Error\n" {
+		t.Errorf("got %d %q, want the status text", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want text/plain", got)
+	}
+	if got := rec.Header().Get("Content-Length"); got != "" {
+		t.Errorf("Content-Length = %q, want it removed", got)
+	}
+	want := "GET /orders: 500 response body replaced: {\"error\":\"pq: relation users does not exist\"}\n"
+	if logged.String() != want {
+		t.Errorf("log = %q, want %q", logged.String(), want)
+	}
+}
+
+func TestSanitizeErrorsMaxLogged(t *testing.T) {
+	var logged bytes.Buffer
+	s := NewSanitizeErrors(true)
+	s.Logger = log.New(&logged, "", 0)
+	s.MaxLogged = 5
+	serveSanitized(s, http.StatusBadGateway, "upstream exploded")
+	if want := "GET /orders: 502 response body replaced: upstr\n"; logged.String() != want {
+		t.Errorf("log = %q, want %q", logged.String(), want)
+	}
+}
+
+func TestSanitizeErrorsPassesThrough(t *testing.T) {
+	var logged bytes.Buffer
+	prod := NewSanitizeErrors(true)
+	prod.Logger = log.New(&logged, "", 0)
+	dev := NewSanitizeErrors(false)
+	dev.Logger = prod.Logger
+
+	tests := []struct {
+		name   string
+		s      *SanitizeErrors
+		status int
+	}{
+		{"client error", prod, http.StatusNotFound},
+		{"success", prod, http.StatusOK},
+		{"development", dev, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		rec := serveSanitized(tt.s, tt.status, "details")
+		if rec.Code != tt.status || rec.Body.String() != "details" {
+			t.Errorf("%s: got %d %q, want the original response", tt.name, rec.Code, rec.Body)
+		}
+	}
+	if logged.Len() != 0 {
+		t.Errorf("log = %q, want nothing logged", logged.String())
+	}
+}
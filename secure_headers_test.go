@@ -0,0 +1,88 @@
+package y_middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serveSecureHeaders(s *SecureHeaders, r *http.Request) (*httptest.ResponseRecorder, string) {
+	var nonce string
+	k := New(s)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		nonce = CSPNonce(r.Context())
+	})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, r)
+	return rec, nonce
+}
+
+func TestSecureHeadersDefaults(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec, nonce := serveSecureHeaders(NewSecureHeaders(), r)
+	want := map[string]string{
+		"X-Frame-Options":           "DENY",
+		"Referrer-Policy":           "strict-origin-when-cross-origin",
+		"X-Content-Type-Options":    "nosniff",
+		"Content-Security-Policy":   "",
+		"Strict-Transport-Security": "",
+	}
+	for name, value := range want {
+		if got := rec.Header().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+	if nonce != "" {
+		t.Errorf("nonce = %q without CSPNonce", nonce)
+	}
+}
+
+func TestSecureHeadersSTSOnTLSOnly(t *testing.T) {
+	s := NewSecureHeaders()
+	s.STSIncludeSubdomains = true
+	r := httptest.NewRequest(http.MethodGet, "https://example.com/", nil)
+	r.TLS = &tls.ConnectionState{}
+	rec, _ := serveSecureHeaders(s, r)
+	if got, want := rec.Header().Get("Strict-Transport-Security"), "max-age=31536000; includeSubDomains"; got != want {
+		t.Errorf("Strict-Transport-Security = %q, want %q", got, want)
+	}
+}
+
+func TestSecureHeadersCSPNonce(t *testing.T) {
+	s := NewSecureHeaders()
+	s.ContentSecurityPolicy = "default-src 'self'; script-src 'self'"
+	s.CSPNonce = true
+
+	rec, nonce := serveSecureHeaders(s, httptest.NewRequest(http.MethodGet, "/", nil))
+	if nonce == "" {
+		t.Fatal("no nonce in the request context")
+	}
+	source := "'nonce-" + nonce + "'"
+	want := "default-src 'self'; script-src 'self' " + source + "; style-src " + source
+	if got := rec.Header().Get("Content-Security-Policy"); got != want {
+		t.Errorf("Content-Security-Policy = %q, want %q", got, want)
+	}
+
+	_, other := serveSecureHeaders(s, httptest.NewRequest(http.MethodGet, "/", nil))
+	if other == nonce {
+		t.Error("two requests got the same nonce")
+	}
+}
+
+func TestAddCSPNonce(t *testing.T) {
+	tests := []struct{ policy, want string }{
+		{"", "script-src 'nonce-n'; style-src 'nonce-n'"},
+		{"STYLE-SRC 'self';", "STYLE-SRC 'self' 'nonce-n'; script-src 'nonce-n'"},
+		{"img-src *", "img-src *; script-src 'nonce-n'; style-src 'nonce-n'"},
+	}
+	for _, tt := range tests {
+		if got := addCSPNonce(tt.policy, "n"); got != tt.want {
+			t.Errorf("addCSPNonce(%q) = %q, want %q", tt.policy, got, tt.want)
+		}
+	}
+	if got := addCSPNonce("default-src 'self'", "n"); strings.Contains(got, "default-src 'self' 'nonce") {
+		t.Errorf("addCSPNonce added the nonce to default-src: %q", got)
+	}
+}
@@ -0,0 +1,99 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func issueSession(s *SessionAge) *http.Cookie {
+	rec := httptest.NewRecorder()
+	s.Issue(rec)
+	return rec.Result().Cookies()[0]
+}
+
+// serveSession serves a request carrying cookie and returns the status and the re-issued cookie.
+func serveSession(s *SessionAge, cookie *http.Cookie) (int, *http.Cookie) {
+	k := New(s)
+	k.UseHandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, r)
+	if cookies := rec.Result().Cookies(); len(cookies) > 0 {
+		return rec.Code, cookies[0]
+	}
+	return rec.Code, nil
+}
+
+func TestSessionAgeIdleTimeout(t *testing.T) {
+	clock := newFakeClock()
+	s := NewSessionAge([]byte("secret")).WithClock(clock)
+	cookie := issueSession(s)
+	if !cookie.Secure || !cookie.HttpOnly || cookie.Name != "session_age" {
+		t.Errorf("issued cookie = %+v, want the template attributes", cookie)
+	}
+
+	clock.Advance(20 * time.Minute)
+	status, refreshed := serveSession(s, cookie)
+	if status != http.StatusOK || refreshed == nil {
+		t.Fatalf("active session: status = %d, cookie = %v", status, refreshed)
+	}
+
+	clock.Advance(20 * time.Minute)
+	if status, _ := serveSession(s, cookie); status != http.StatusUnauthorized {
+		t.Errorf("old cookie idle for 40m: status = %d, want %d", status, http.StatusUnauthorized)
+	}
+	if status, _ := serveSession(s, refreshed); status != http.StatusOK {
+		t.Errorf("refreshed cookie idle for 20m: status = %d, want %d", status, http.StatusOK)
+	}
+}
+
+func TestSessionAgeMaxAge(t *testing.T) {
+	clock := newFakeClock()
+	s := NewSessionAge([]byte("secret")).WithClock(clock)
+	s.MaxAge = time.Hour
+	cookie := issueSession(s)
+	if want := clock.Now().Add(time.Hour); !cookie.Expires.Equal(want) {
+		t.Errorf("Expires = %v, want %v", cookie.Expires, want)
+	}
+
+	for i := 0; i < 3; i++ {
+		clock.Advance(20 * time.Minute)
+		status, refreshed := serveSession(s, cookie)
+		if status != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want %d", i, status, http.StatusOK)
+		}
+		cookie = refreshed
+	}
+	clock.Advance(time.Minute)
+	if status, _ := serveSession(s, cookie); status != http.StatusUnauthorized {
+		t.Errorf("session past MaxAge: status = %d, want %d", status, http.StatusUnauthorized)
+	}
+}
+
+func TestSessionAgeRejectsBadCookies(t *testing.T) {
+	clock := newFakeClock()
+	s := NewSessionAge([]byte("secret")).WithClock(clock)
+	good := issueSession(s)
+	payload, sig, _ := strings.Cut(good.Value, ".")
+
+	tests := []struct {
+		name   string
+		cookie *http.Cookie
+	}{
+		{"missing", nil},
+		{"unsigned", &http.Cookie{Name: "session_age", Value: payload}},
+		{"tampered", &http.Cookie{Name: "session_age", Value: "1-" + payload[strings.Index(payload, "-")+1:] + "." + sig}},
+		{"other secret", issueSession(NewSessionAge([]byte("other")).WithClock(clock))},
+	}
+	for _, tt := range tests {
+		if status, cookie := serveSession(s, tt.cookie); status != http.StatusUnauthorized || cookie != nil {
+			t.Errorf("%s: status = %d, cookie = %v, want %d and no cookie", tt.name, status, cookie, http.StatusUnauthorized)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package y_middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultShadowMaxConcurrent is how many mirrored requests may be in flight by default.
+	DefaultShadowMaxConcurrent = 16
+	// DefaultShadowMaxBodySize is the largest request body Shadow mirrors by default.
+	DefaultShadowMaxBodySize = 1 << 20
+	// DefaultShadowTimeout is how long a mirrored request may take by default.
+	DefaultShadowTimeout = 10 * time.Second
+)
+
+// Shadow is a middleware handler that mirrors a fraction of requests to a shadow backend, for
+// trying a new backend under real traffic. Mirrored requests are sent in the background once
+// the rest of the chain has returned, so the client response is never affected, and their
+// responses are discarded. Requests whose body is larger than MaxBodySize, or of unknown length,
+// are not mirrored, and neither are requests arriving while MaxConcurrent mirrored requests are
+// in flight.
+type Shadow struct {
+	// Target is the shadow backend. The request path is appended to its path.
+	Target        *url.URL
+	Fraction      float64
+	MaxConcurrent int
+	MaxBodySize   int64
+	// Timeout bounds each mirrored request; zero means DefaultShadowTimeout.
+	Timeout time.Duration
+	Client  *http.Client
+	// Logger, if set, receives the errors of mirrored requests.
+	Logger ALogger
+
+	inFlight atomic.Int64
+	rng      RNG
+}
+
+// NewShadow returns a new Shadow instance mirroring fraction of all requests to target.
+func NewShadow(target *url.URL, fraction float64) *Shadow {
+	return &Shadow{
+		Target:        target,
+		Fraction:      fraction,
+		MaxConcurrent: DefaultShadowMaxConcurrent,
+		MaxBodySize:   DefaultShadowMaxBodySize,
+		Timeout:       DefaultShadowTimeout,
+		Client:        http.DefaultClient,
+	}
+}
+
+// WithRNG makes the Shadow draw its randomness from rng instead of the global source.
+func (s *Shadow) WithRNG(rng RNG) *Shadow {
+	s.rng = rng
+	return s
+}
+
+func (s *Shadow) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.ContentLength < 0 || r.ContentLength > s.MaxBodySize || rngOrDefault(s.rng).Float64() >= s.Fraction {
+		next(rw, r)
+		return
+	}
+
+	body, err := bufferBody(r, s.MaxBodySize)
+	if err != nil {
+		next(rw, r)
+		return
+	}
+	mirror, err := s.mirror(r, body)
+	next(rw, r)
+	if err != nil {
+		if s.Logger != nil {
+			s.Logger.Printf("shadow %s %s: %v", r.Method, r.URL, err)
+		}
+		return
+	}
+
+	if s.inFlight.Add(1) > int64(s.MaxConcurrent) {
+		s.inFlight.Add(-1)
+		return
+	}
+	go func() {
+		defer s.inFlight.Add(-1)
+		s.send(mirror)
+	}()
+}
+
+// mirror copies r, before the rest of the chain can modify it, as a request to the Target.
+func (s *Shadow) mirror(r *http.Request, body []byte) (*http.Request, error) {
+	u := *s.Target
+	u.Path = strings.TrimSuffix(u.Path, "/") + r.URL.Path
+	u.RawPath = ""
+	u.RawQuery = r.URL.RawQuery
+
+	out, err := http.NewRequest(r.Method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	out.Header = r.Header.Clone()
+	removeHopByHopHeaders(out.Header)
+	out.Header.Set("X-Shadow-Request", "1")
+	out.Host = r.Host
+	return out, nil
+}
+
+func (s *Shadow) send(out *http.Request) {
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = DefaultShadowTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(out.WithContext(ctx))
+	if err != nil {
+		if s.Logger != nil {
+			s.Logger.Printf("shadow %s %s: %v", out.Method, out.URL, err)
+		}
+		return
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+}
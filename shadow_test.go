@@ -0,0 +1,89 @@
+package y_middleware
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// chanLogger is an ALogger sending every line to a channel.
+type chanLogger chan string
+
+func (l chanLogger) Println(v ...interface{}) { l <- fmt.Sprintln(v...) }
+
+func (l chanLogger) Printf(format string, v ...interface{}) { l <- fmt.Sprintf(format, v...) }
+
+type mirrored struct {
+	path, query, body, header string
+}
+
+func TestShadowMirrorsRequest(t *testing.T) {
+	got := make(chan mirrored, 1)
+	backend := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- mirrored{r.URL.Path, r.URL.RawQuery, string(body), r.Header.Get("X-Shadow-Request")}
+	}))
+	defer backend.Close()
+
+	target, _ := url.Parse(backend.URL + "/shadow/")
+	s := NewShadow(target, 1)
+	s.Timeout = 0
+	logs := make(chanLogger, 1)
+	s.Logger = logs
+
+	k := New(s)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		rw.WriteHeader(http.StatusCreated)
+	})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/items?x=1", strings.NewReader("payload")))
+	if rec.Code != http.StatusCreated {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+
+	want := mirrored{"/shadow/items", "x=1", "payload", "1"}
+	select {
+	case m := <-got:
+		if m != want {
+			t.Errorf("mirrored request = %+v, want %+v", m, want)
+		}
+	case line := <-logs:
+		t.Fatalf("mirrored request failed with a zero Timeout: %s", line)
+	case <-time.After(5 * time.Second):
+		t.Fatal("request was not mirrored")
+	}
+}
+
+func TestShadowSkipsUnmirrorableRequest(t *testing.T) {
+	target, _ := url.Parse("http://shadow.invalid")
+	s := NewShadow(target, 1)
+	logs := make(chanLogger, 1)
+	s.Logger = logs
+
+	served := false
+	k := New(s)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		served = true
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Method = "BAD METHOD"
+	k.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !served {
+		t.Error("handler was not served")
+	}
+	select {
+	case line := <-logs:
+		if !strings.Contains(line, "invalid method") {
+			t.Errorf("logged %q, want the request error", line)
+		}
+	default:
+		t.Error("the request error was not logged")
+	}
+}
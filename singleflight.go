@@ -0,0 +1,74 @@
+package y_middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultSingleFlightMaxSize is the largest response body, in bytes, SingleFlight
+// will share between coalesced requests.
+const DefaultSingleFlightMaxSize = 1 << 20
+
+// SingleFlight is a middleware handler that coalesces concurrent identical GET and HEAD
+// requests so that only one of them runs the rest of the chain. Every caller waiting on
+// the same key receives a copy of the buffered response, unless it was streamed, see
+// DisableBuffering, in which case the waiting callers run the chain themselves.
+type SingleFlight struct {
+	// KeyFunc returns the key identical requests are grouped by. Defaults to method, URL and
+	// the Authorization and Cookie headers, so personalized responses are only shared between
+	// requests with the same credentials. Custom keys must do likewise.
+	KeyFunc func(r *http.Request) string
+	// MaxSize is the largest response body that is shared. The request running the chain stops
+	// buffering a larger response and streams it, and the callers waiting on it run the chain
+	// themselves. Zero means no limit.
+	MaxSize int
+
+	group singleflight.Group
+}
+
+// NewSingleFlight returns a new SingleFlight instance keyed by method, URL and credentials.
+func NewSingleFlight() *SingleFlight {
+	return &SingleFlight{
+		KeyFunc: singleFlightKey,
+		MaxSize: DefaultSingleFlightMaxSize,
+	}
+}
+
+func (s *SingleFlight) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		next(rw, r)
+		return
+	}
+
+	keyFunc := s.KeyFunc
+	if keyFunc == nil {
+		keyFunc = singleFlightKey
+	}
+
+	leader := false
+	v, _, _ := s.group.Do(keyFunc(r), func() (interface{}, error) {
+		leader = true
+		buf, br := newResponseBuffer(rw, r, s.MaxSize)
+		next(buf, br)
+		return buf, nil
+	})
+
+	buf := v.(*responseBuffer)
+	if leader {
+		if !buf.Streamed() {
+			buf.flush(rw)
+		}
+		return
+	}
+	if buf.Streamed() {
+		next(rw, r)
+		return
+	}
+	buf.flush(rw)
+}
+
+func singleFlightKey(r *http.Request) string {
+	return r.Method + " " + r.URL.String() + "\n" + r.Header.Get("Authorization") + "\n" + strings.Join(r.Header.Values("Cookie"), "; ")
+}
@@ -0,0 +1,96 @@
+package y_middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// runConcurrently serves reqs through s at the same time with a handler that blocks until
+// they all had time to join a flight, and returns the responses and how often it ran.
+func runConcurrently(s *SingleFlight, body string, reqs ...*http.Request) ([]*httptest.ResponseRecorder, int32) {
+	var calls int32
+	release := make(chan struct{})
+	handler := func(rw http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		io.WriteString(rw, body)
+	}
+
+	recs := make([]*httptest.ResponseRecorder, len(reqs))
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		recs[i] = httptest.NewRecorder()
+		wg.Add(1)
+		go func(rec *httptest.ResponseRecorder, req *http.Request) {
+			defer wg.Done()
+			s.ServeHTTP(rec, req, handler)
+		}(recs[i], req)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	return recs, atomic.LoadInt32(&calls)
+}
+
+func TestSingleFlightCoalescesIdenticalRequests(t *testing.T) {
+	var reqs []*http.Request
+	for i := 0; i < 5; i++ {
+		reqs = append(reqs, httptest.NewRequest(http.MethodGet, "/report", nil))
+	}
+	recs, calls := runConcurrently(NewSingleFlight(), "report", reqs...)
+
+	if calls != 1 {
+		t.Errorf("chain ran %d times, want once", calls)
+	}
+	for i, rec := range recs {
+		if rec.Body.String() != "report" {
+			t.Errorf("response %d = %q", i, rec.Body.String())
+		}
+	}
+}
+
+func TestSingleFlightKeepsCredentialsApart(t *testing.T) {
+	alice := httptest.NewRequest(http.MethodGet, "/me", nil)
+	alice.Header.Set("Authorization", "Bearer alice")
+	bob := httptest.NewRequest(http.MethodGet, "/me", nil)
+	bob.Header.Set("Authorization", "Bearer bob")
+	carol := httptest.NewRequest(http.MethodGet, "/me", nil)
+	carol.Header.Set("Cookie", "session=carol")
+
+	if _, calls := runConcurrently(NewSingleFlight(), "me", alice, bob, carol); calls != 3 {
+		t.Errorf("chain ran %d times for three users, want three", calls)
+	}
+}
+
+func TestSingleFlightStreamsLargeResponses(t *testing.T) {
+	s := NewSingleFlight()
+	s.MaxSize = 4
+	body := strings.Repeat("x", 10)
+	recs, calls := runConcurrently(s, body,
+		httptest.NewRequest(http.MethodGet, "/big", nil),
+		httptest.NewRequest(http.MethodGet, "/big", nil))
+
+	if calls != 2 {
+		t.Errorf("chain ran %d times, want every caller to run it for a large response", calls)
+	}
+	for i, rec := range recs {
+		if rec.Body.String() != body {
+			t.Errorf("response %d = %q", i, rec.Body.String())
+		}
+	}
+}
+
+func TestSingleFlightIgnoresUnsafeMethods(t *testing.T) {
+	_, calls := runConcurrently(NewSingleFlight(), "ok",
+		httptest.NewRequest(http.MethodPost, "/", nil),
+		httptest.NewRequest(http.MethodPost, "/", nil))
+	if calls != 2 {
+		t.Errorf("chain ran %d times for two POSTs, want twice", calls)
+	}
+}
@@ -0,0 +1,60 @@
+package y_middleware
+
+import (
+	"io"
+	"net/http"
+)
+
+// SizeRecorder receives the request and response body sizes observed by SizeMetrics, e.g. to
+// feed them into histograms. Implementations must be safe for concurrent use.
+type SizeRecorder interface {
+	RecordSizes(r *http.Request, requestSize, responseSize int64)
+}
+
+// SizeRecorderFunc is an adapter to allow the use of ordinary functions as SizeRecorder.
+type SizeRecorderFunc func(r *http.Request, requestSize, responseSize int64)
+
+func (f SizeRecorderFunc) RecordSizes(r *http.Request, requestSize, responseSize int64) {
+	f(r, requestSize, responseSize)
+}
+
+// SizeMetrics is a middleware handler that records the size of each request and response body.
+// The request size comes from Content-Length when it is known; for chunked requests it is the
+// number of body bytes the handlers read.
+type SizeMetrics struct {
+	recorder SizeRecorder
+}
+
+// NewSizeMetrics returns a new SizeMetrics instance reporting to recorder.
+func NewSizeMetrics(recorder SizeRecorder) *SizeMetrics {
+	return &SizeMetrics{recorder: recorder}
+}
+
+func (s *SizeMetrics) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	var counter *countingReadCloser
+	if r.ContentLength < 0 && r.Body != nil && r.Body != http.NoBody {
+		counter = &countingReadCloser{ReadCloser: r.Body}
+		r.Body = counter
+	}
+
+	res := wrapResponseWriter(rw)
+	next(res, r)
+
+	requestSize := r.ContentLength
+	if counter != nil {
+		requestSize = counter.n
+	}
+	s.recorder.RecordSizes(r, requestSize, int64(res.Size()))
+}
+
+// countingReadCloser counts the bytes read through it.
+type countingReadCloser struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,39 @@
+package y_middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSizeMetrics(t *testing.T) {
+	var reqSize, resSize int64
+	s := NewSizeMetrics(SizeRecorderFunc(func(r *http.Request, requestSize, responseSize int64) {
+		reqSize, resSize = requestSize, responseSize
+	}))
+	k := New(s)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		io.WriteString(rw, "response")
+	})
+
+	k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello")))
+	if reqSize != 5 || resSize != 8 {
+		t.Errorf("sizes = %d, %d, want 5, 8", reqSize, resSize)
+	}
+
+	// Without Content-Length the request size is what the handler read.
+	chunked := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("chunked body"))
+	chunked.ContentLength = -1
+	k.ServeHTTP(httptest.NewRecorder(), chunked)
+	if reqSize != 12 {
+		t.Errorf("chunked request size = %d, want 12", reqSize)
+	}
+
+	k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if reqSize != 0 {
+		t.Errorf("request size = %d without a body, want 0", reqSize)
+	}
+}
@@ -0,0 +1,85 @@
+package y_middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+type (
+	slogLoggerKey struct{}
+	slogAttrsKey  struct{}
+)
+
+// SlogContext is a middleware handler that attaches request attributes (method, path,
+// request_id and remote_ip) to a *slog.Logger stored in the request context, where handlers
+// read it with SlogFrom. For code that logs through slog.InfoContext and friends instead, wrap
+// the logger's handler with NewSlogContextHandler so records pick the attributes up from the
+// context.
+type SlogContext struct {
+	// Logger is the logger the request attributes are attached to. When nil, slog.Default is used.
+	Logger *slog.Logger
+}
+
+// NewSlogContext returns a new SlogContext instance attaching request attributes to logger.
+func NewSlogContext(logger *slog.Logger) *SlogContext {
+	return &SlogContext{Logger: logger}
+}
+
+func (s *SlogContext) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	attrs := []slog.Attr{
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.String("remote_ip", clientIP(r)),
+	}
+	if id := RequestIDFrom(r.Context()); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+
+	logger := s.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	args := make([]any, len(attrs))
+	for i, a := range attrs {
+		args[i] = a
+	}
+
+	ctx := context.WithValue(r.Context(), slogAttrsKey{}, attrs)
+	ctx = context.WithValue(ctx, slogLoggerKey{}, logger.With(args...))
+	next(rw, r.WithContext(ctx))
+}
+
+// SlogFrom returns the logger SlogContext set up for the request, or slog.Default without a
+// SlogContext in the chain.
+func SlogFrom(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(slogLoggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// NewSlogContextHandler returns a slog.Handler that adds the request attributes SlogContext
+// stored in the context of each record to it before passing it on to h.
+func NewSlogContextHandler(h slog.Handler) slog.Handler {
+	return slogContextHandler{h}
+}
+
+type slogContextHandler struct {
+	slog.Handler
+}
+
+func (h slogContextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs, ok := ctx.Value(slogAttrsKey{}).([]slog.Attr); ok {
+		record.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h slogContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return slogContextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h slogContextHandler) WithGroup(name string) slog.Handler {
+	return slogContextHandler{h.Handler.WithGroup(name)}
+}
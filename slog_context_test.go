@@ -0,0 +1,61 @@
+package y_middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// textLogger returns a slog text handler writing to buf without timestamps.
+func textLogger(buf *bytes.Buffer) slog.Handler {
+	return slog.NewTextHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+}
+
+func serveSlog(handlers ...Handler) {
+	k := New(handlers...)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		SlogFrom(r.Context()).Info("from SlogFrom")
+	})
+	r := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	r.Header.Set("X-Request-Id", "req-1")
+	k.ServeHTTP(httptest.NewRecorder(), r)
+}
+
+func TestSlogContext(t *testing.T) {
+	var buf bytes.Buffer
+	serveSlog(NewRequestID(), NewSlogContext(slog.New(textLogger(&buf))))
+	want := "level=INFO msg=\"from SlogFrom\" method=POST path=/orders remote_ip=192.0.2.1 request_id=req-1\n"
+	if buf.String() != want {
+		t.Errorf("log = %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	serveSlog(NewSlogContext(slog.New(textLogger(&buf))))
+	if want := "level=INFO msg=\"from SlogFrom\" method=POST path=/orders remote_ip=192.0.2.1\n"; buf.String() != want {
+		t.Errorf("log without RequestID = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestSlogContextHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewSlogContextHandler(textLogger(&buf))).With("service", "api")
+	k := New(NewSlogContext(nil))
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		logger.InfoContext(r.Context(), "from InfoContext")
+	})
+	k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	want := "level=INFO msg=\"from InfoContext\" service=api method=GET path=/ remote_ip=192.0.2.1\n"
+	if buf.String() != want {
+		t.Errorf("log = %q, want %q", buf.String(), want)
+	}
+}
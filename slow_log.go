@@ -0,0 +1,99 @@
+package y_middleware
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultSlowLogSize is the number of requests a SlowLog keeps by default.
+const DefaultSlowLogSize = 20
+
+// SlowLogEntry describes a single request recorded by SlowLog.
+type SlowLogEntry struct {
+	Method    string
+	Path      string
+	Status    int
+	Duration  time.Duration
+	Timestamp time.Time
+}
+
+// SlowLog is a middleware handler that keeps track of the slowest requests it has seen,
+// as a lightweight way to spot latency outliers. It is safe for concurrent use.
+type SlowLog struct {
+	size    int
+	mu      sync.Mutex
+	entries []SlowLogEntry
+	clock   Clock
+}
+
+// NewSlowLog returns a new SlowLog instance keeping the size slowest requests.
+func NewSlowLog(size int) *SlowLog {
+	return &SlowLog{
+		size:    size,
+		entries: make([]SlowLogEntry, 0, size),
+	}
+}
+
+// WithClock makes the SlowLog read the time from c instead of the system clock.
+func (s *SlowLog) WithClock(c Clock) *SlowLog {
+	s.clock = c
+	return s
+}
+
+func (s *SlowLog) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	clock := clockOrDefault(s.clock)
+	start := clock.Now()
+
+	res := wrapResponseWriter(rw)
+	next(res, r)
+
+	status := res.Status()
+	if status == 0 {
+		status = http.StatusOK
+	}
+	s.record(SlowLogEntry{
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		Status:    status,
+		Duration:  clock.Now().Sub(start),
+		Timestamp: start,
+	})
+}
+
+// record keeps e if it is slower than the fastest request currently kept.
+func (s *SlowLog) record(e SlowLogEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.size <= 0 {
+		return
+	}
+	if len(s.entries) < s.size {
+		s.entries = append(s.entries, e)
+		return
+	}
+
+	fastest := 0
+	for i := range s.entries {
+		if s.entries[i].Duration < s.entries[fastest].Duration {
+			fastest = i
+		}
+	}
+	if e.Duration > s.entries[fastest].Duration {
+		s.entries[fastest] = e
+	}
+}
+
+// Top returns the slowest requests seen so far, slowest first.
+func (s *SlowLog) Top() []SlowLogEntry {
+	s.mu.Lock()
+	top := append([]SlowLogEntry(nil), s.entries...)
+	s.mu.Unlock()
+
+	sort.Slice(top, func(i, j int) bool {
+		return top[i].Duration > top[j].Duration
+	})
+	return top
+}
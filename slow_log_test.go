@@ -0,0 +1,48 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSlowLogKeepsSlowest(t *testing.T) {
+	clock := newFakeClock()
+	s := NewSlowLog(2).WithClock(clock)
+	k := New(s)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		ms, _ := strconv.Atoi(r.URL.Query().Get("ms"))
+		clock.Advance(time.Duration(ms) * time.Millisecond)
+		if r.URL.Path == "/missing" {
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	for _, target := range []string{"/a?ms=30", "/missing?ms=50", "/c?ms=10", "/d?ms=40"} {
+		k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	top := s.Top()
+	if len(top) != 2 {
+		t.Fatalf("Top() has %d entries, want 2", len(top))
+	}
+	if top[0].Path != "/missing" || top[0].Duration != 50*time.Millisecond || top[0].Status != http.StatusNotFound {
+		t.Errorf("slowest = %+v, want /missing with 50ms and status 404", top[0])
+	}
+	if top[1].Path != "/d" || top[1].Duration != 40*time.Millisecond || top[1].Status != http.StatusOK {
+		t.Errorf("second slowest = %+v, want /d with 40ms and status 200", top[1])
+	}
+	if top[1].Method != http.MethodGet || top[1].Timestamp.IsZero() {
+		t.Errorf("entry = %+v, want the method and start time recorded", top[1])
+	}
+}
+
+func TestSlowLogZeroSize(t *testing.T) {
+	s := NewSlowLog(0)
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), func(http.ResponseWriter, *http.Request) {})
+	if len(s.Top()) != 0 {
+		t.Error("SlowLog of size 0 recorded a request")
+	}
+}
@@ -0,0 +1,72 @@
+package y_middleware
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"runtime"
+)
+
+// PanicError is the request error SoftRecovery records for a recovered panic.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// SoftRecovery is a middleware handler that, like Recovery, recovers from panics, but hands the
+// request to a Fallback handler instead of failing it. The panic is recorded as a *PanicError
+// with AddError, so the Fallback can read it through Errors, and it can produce a graceful
+// response such as a cached copy of the page. Without a Fallback, or if the response was already
+// started when the panic happened, the client gets a 500 as far as that is still possible.
+type SoftRecovery struct {
+	Fallback  http.Handler
+	Logger    ALogger
+	StackSize int
+}
+
+// NewSoftRecovery returns a new SoftRecovery instance serving panicking requests with fallback.
+func NewSoftRecovery(fallback http.Handler) *SoftRecovery {
+	return &SoftRecovery{
+		Fallback:  fallback,
+		Logger:    log.New(os.Stdout, "[kudret] ", 0),
+		StackSize: 1024 * 8,
+	}
+}
+
+func (rec *SoftRecovery) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	r = withRequestErrors(r)
+	res := wrapResponseWriter(rw)
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+		if v == http.ErrAbortHandler {
+			// http.ErrAbortHandler is how a handler asks the server to abort the response.
+			panic(v)
+		}
+
+		stack := make([]byte, rec.StackSize)
+		stack = stack[:runtime.Stack(stack, false)]
+		AddError(r.Context(), &PanicError{Value: v, Stack: stack})
+		if rec.Logger != nil {
+			rec.Logger.Printf("PANIC: %v\n%s", v, stack)
+		}
+
+		if res.Written() {
+			return
+		}
+		if rec.Fallback == nil {
+			http.Error(res, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		rec.Fallback.ServeHTTP(res, r)
+	}()
+
+	next(res, r)
+}
@@ -0,0 +1,76 @@
+package y_middleware
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serveSoftRecovery(rec *SoftRecovery, h http.HandlerFunc) *httptest.ResponseRecorder {
+	k := New(rec)
+	k.UseHandlerFunc(h)
+	res := httptest.NewRecorder()
+	k.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	return res
+}
+
+func TestSoftRecoveryServesFallback(t *testing.T) {
+	var pe *PanicError
+	fallback := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		for _, err := range Errors(r.Context()) {
+			errors.As(err, &pe)
+		}
+		io.WriteString(rw, "cached page")
+	})
+	var logged strings.Builder
+	s := NewSoftRecovery(fallback)
+	s.Logger = log.New(&logged, "", 0)
+
+	res := serveSoftRecovery(s, func(http.ResponseWriter, *http.Request) { panic("database down") })
+	if res.Code != http.StatusOK || res.Body.String() != "cached page" {
+		t.Errorf("got %d %q, want the fallback response", res.Code, res.Body)
+	}
+	if pe == nil || pe.Value != "database down" || pe.Error() != "panic: database down" || len(pe.Stack) == 0 {
+		t.Errorf("fallback saw %+v, want the recorded panic", pe)
+	}
+	if !strings.HasPrefix(logged.String(), "PANIC: database down\n") {
+		t.Errorf("log = %q, want the panic logged", logged.String())
+	}
+}
+
+func TestSoftRecoveryWithoutFallback(t *testing.T) {
+	s := NewSoftRecovery(nil)
+	s.Logger = nil
+	res := serveSoftRecovery(s, func(http.ResponseWriter, *http.Request) { panic("boom") })
+	if res.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", res.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestSoftRecoveryAfterResponseStarted(t *testing.T) {
+	called := false
+	s := NewSoftRecovery(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { called = true }))
+	s.Logger = nil
+	res := serveSoftRecovery(s, func(rw http.ResponseWriter, r *http.Request) {
+		io.WriteString(rw, "partial")
+		panic("boom")
+	})
+	if called || res.Code != http.StatusOK || res.Body.String() != "partial" {
+		t.Errorf("got %d %q, fallback called = %v, want the partial response left alone", res.Code, res.Body, called)
+	}
+}
+
+func TestSoftRecoveryRepanicsAbortHandler(t *testing.T) {
+	s := NewSoftRecovery(nil)
+	s.Logger = nil
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler re-panicked", v)
+		}
+	}()
+	serveSoftRecovery(s, func(http.ResponseWriter, *http.Request) { panic(http.ErrAbortHandler) })
+}
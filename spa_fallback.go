@@ -0,0 +1,59 @@
+package y_middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// SPAFallback is a middleware handler that serves a single page application's index file for
+// GET and HEAD requests that reached it, so client-side routes like /settings/profile load the
+// app. Place it after Static, which serves the files that do exist. Requests under one of the
+// Exclude prefixes, such as "/api", and all other methods pass through to next.
+type SPAFallback struct {
+	// Dir is the filesystem the index file is served from.
+	Dir http.FileSystem
+	// IndexFile is the path of the index file within Dir.
+	IndexFile string
+	// Exclude lists path prefixes that never fall back to the index file.
+	Exclude []string
+}
+
+// NewSPAFallback returns a new SPAFallback instance serving /index.html from dir for every path
+// outside the exclude prefixes.
+func NewSPAFallback(dir http.FileSystem, exclude ...string) *SPAFallback {
+	return &SPAFallback{
+		Dir:       dir,
+		IndexFile: "/index.html",
+		Exclude:   exclude,
+	}
+}
+
+func (s *SPAFallback) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if (r.Method != http.MethodGet && r.Method != http.MethodHead) || s.excluded(r.URL.Path) {
+		next(rw, r)
+		return
+	}
+
+	f, err := s.Dir.Open(s.IndexFile)
+	if err != nil {
+		next(rw, r)
+		return
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil || fi.IsDir() {
+		next(rw, r)
+		return
+	}
+	http.ServeContent(rw, r, s.IndexFile, fi.ModTime(), f)
+}
+
+func (s *SPAFallback) excluded(path string) bool {
+	for _, prefix := range s.Exclude {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package y_middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func serveSPA(s *SPAFallback, method, path string) *httptest.ResponseRecorder {
+	k := New(s)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusNotFound)
+		io.WriteString(rw, "next")
+	})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec
+}
+
+func TestSPAFallback(t *testing.T) {
+	dir := http.FS(fstest.MapFS{"index.html": {Data: []byte("<div id=app></div>")}})
+	s := NewSPAFallback(dir, "/api")
+	tests := []struct {
+		name, method, path string
+		status             int
+		body               string
+	}{
+		{"client route", http.MethodGet, "/settings/profile", http.StatusOK, "<div id=app></div>"},
+		{"head", http.MethodHead, "/settings", http.StatusOK, ""},
+		{"excluded", http.MethodGet, "/api/users", http.StatusNotFound, "next"},
+		{"post", http.MethodPost, "/settings", http.StatusNotFound, "next"},
+	}
+	for _, tt := range tests {
+		rec := serveSPA(s, tt.method, tt.path)
+		if rec.Code != tt.status || rec.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q, want %d %q", tt.name, rec.Code, rec.Body, tt.status, tt.body)
+		}
+	}
+
+	rec := serveSPA(s, http.MethodGet, "/settings")
+	if got := rec.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want text/html", got)
+	}
+}
+
+func TestSPAFallbackMissingIndex(t *testing.T) {
+	s := NewSPAFallback(http.FS(fstest.MapFS{"index.html/nested": {}}))
+	if rec := serveSPA(s, http.MethodGet, "/settings"); rec.Code != http.StatusNotFound {
+		t.Errorf("index is a directory: status = %d, want it passed on", rec.Code)
+	}
+	s = NewSPAFallback(http.FS(fstest.MapFS{}))
+	if rec := serveSPA(s, http.MethodGet, "/settings"); rec.Code != http.StatusNotFound {
+		t.Errorf("missing index: status = %d, want it passed on", rec.Code)
+	}
+}
@@ -0,0 +1,167 @@
+package y_middleware
+
+import (
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// precompressedEncodings are the encodings Static looks for, most preferred first,
+// with the file suffix their variants use.
+var precompressedEncodings = []struct {
+	encoding string
+	suffix   string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// Static is a middleware handler that serves static files in the given
+// directory/filesystem. If the file does not exist on the filesystem, it
+// passes along to the next middleware in the chain. If you desire "fileserver"
+// type behavior where it returns a 404 for unfound files, you should consider
+// using http.FileServer from the Go stdlib.
+type Static struct {
+	// Dir is the directory to serve static files from
+	Dir http.FileSystem
+	// Prefix is the optional prefix used to serve the static directory content
+	Prefix string
+	// IndexFile defines which file to serve as index if it exists.
+	IndexFile string
+	// Precompressed serves a file.br or file.gz stored alongside file, with the matching
+	// Content-Encoding, to clients that accept that encoding.
+	Precompressed bool
+}
+
+// NewStatic returns a new instance of Static
+func NewStatic(directory http.FileSystem) *Static {
+	return &Static{
+		Dir:       directory,
+		Prefix:    "",
+		IndexFile: "index.html",
+	}
+}
+
+func (s *Static) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		next(rw, r)
+		return
+	}
+	file := r.URL.Path
+	// if we have a prefix, filter requests by stripping the prefix
+	if s.Prefix != "" {
+		if !strings.HasPrefix(file, s.Prefix) {
+			next(rw, r)
+			return
+		}
+		file = file[len(s.Prefix):]
+		if file != "" && file[0] != '/' {
+			next(rw, r)
+			return
+		}
+	}
+	f, err := s.Dir.Open(file)
+	if err != nil {
+		// discard the error?
+		next(rw, r)
+		return
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		next(rw, r)
+		return
+	}
+
+	// try to serve index file
+	if fi.IsDir() {
+		// redirect if missing trailing slash
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			http.Redirect(rw, r, r.URL.Path+"/", http.StatusFound)
+			return
+		}
+
+		file = path.Join(file, s.IndexFile)
+		f, err = s.Dir.Open(file)
+		if err != nil {
+			next(rw, r)
+			return
+		}
+		defer f.Close()
+
+		fi, err = f.Stat()
+		if err != nil || fi.IsDir() {
+			next(rw, r)
+			return
+		}
+	}
+
+	if s.Precompressed && s.servePrecompressed(rw, r, file) {
+		return
+	}
+	http.ServeContent(rw, r, file, fi.ModTime(), f)
+}
+
+// servePrecompressed serves the most preferred precompressed variant of file the client
+// accepts. It reports false if there is none, leaving the response untouched apart from Vary.
+func (s *Static) servePrecompressed(rw http.ResponseWriter, r *http.Request, file string) bool {
+	addVary(rw.Header(), "Accept-Encoding")
+
+	accept := r.Header.Get("Accept-Encoding")
+	for _, pc := range precompressedEncodings {
+		if !acceptsEncoding(accept, pc.encoding) {
+			continue
+		}
+		f, err := s.Dir.Open(file + pc.suffix)
+		if err != nil {
+			continue
+		}
+		defer f.Close()
+
+		fi, err := f.Stat()
+		if err != nil || fi.IsDir() {
+			continue
+		}
+
+		if ctype := mime.TypeByExtension(path.Ext(file)); ctype != "" {
+			rw.Header().Set("Content-Type", ctype)
+		}
+		rw.Header().Set("Content-Encoding", pc.encoding)
+		http.ServeContent(rw, r, file, fi.ModTime(), f)
+		return true
+	}
+	return false
+}
+
+// acceptsEncoding reports whether an Accept-Encoding header value allows encoding,
+// either by name or through "*", with a non-zero qvalue.
+func acceptsEncoding(accept, encoding string) bool {
+	q, ok := encodingQuality(accept, encoding)
+	return ok && q > 0
+}
+
+// encodingQuality returns the qvalue an Accept-Encoding header value gives encoding, and
+// whether the header mentions it at all. An explicit entry takes precedence over "*".
+func encodingQuality(accept, encoding string) (float64, bool) {
+	wildcard, hasWildcard := 0.0, false
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		switch name {
+		case encoding:
+			return q, true
+		case "*":
+			wildcard, hasWildcard = q, true
+		}
+	}
+	return wildcard, hasWildcard
+}
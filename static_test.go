@@ -0,0 +1,142 @@
+package y_middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func staticDir(t *testing.T) http.FileSystem {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"app.js":           "console.log(1)",
+		"app.js.br":        "brotli",
+		"app.js.gz":        "gzip",
+		"style.css":        "body{}",
+		"style.css.gz":     "gzip css",
+		"docs/index.html":  "<h1>docs</h1>",
+		"empty/readme.txt": "no index",
+	}
+	for name, data := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return http.Dir(dir)
+}
+
+func serveStatic(s *Static, method, target, acceptEncoding string) *httptest.ResponseRecorder {
+	k := New(s)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusTeapot)
+		io.WriteString(rw, "next")
+	})
+	req := httptest.NewRequest(method, target, nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestStaticServesFiles(t *testing.T) {
+	fs := staticDir(t)
+	tests := []struct {
+		name, method, target string
+		status               int
+		body                 string
+	}{
+		{"file", http.MethodGet, "/style.css", http.StatusOK, "body{}"},
+		{"index", http.MethodGet, "/docs/", http.StatusOK, "<h1>docs</h1>"},
+		{"missing", http.MethodGet, "/missing.txt", http.StatusTeapot, "next"},
+		{"directory without index", http.MethodGet, "/empty/", http.StatusTeapot, "next"},
+		{"post", http.MethodPost, "/style.css", http.StatusTeapot, "next"},
+	}
+	for _, tt := range tests {
+		rec := serveStatic(NewStatic(fs), tt.method, tt.target, "")
+		if rec.Code != tt.status || rec.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q, want %d %q", tt.name, rec.Code, rec.Body, tt.status, tt.body)
+		}
+	}
+
+	rec := serveStatic(NewStatic(fs), http.MethodGet, "/docs", "")
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/docs/" {
+		t.Errorf("directory without slash: got %d to %q, want a redirect to /docs/", rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+func TestStaticPrefix(t *testing.T) {
+	fs := staticDir(t)
+	s := NewStatic(fs)
+	s.Prefix = "/assets"
+	if rec := serveStatic(s, http.MethodGet, "/assets/style.css", ""); rec.Body.String() != "body{}" {
+		t.Errorf("prefixed file: body = %q", rec.Body)
+	}
+	for _, target := range []string{"/style.css", "/assetsstyle.css"} {
+		if rec := serveStatic(s, http.MethodGet, target, ""); rec.Code != http.StatusTeapot {
+			t.Errorf("%s: status = %d, want it passed on", target, rec.Code)
+		}
+	}
+}
+
+func TestStaticPrecompressed(t *testing.T) {
+	fs := staticDir(t)
+	s := NewStatic(fs)
+	s.Precompressed = true
+	tests := []struct {
+		target, accept   string
+		encoding, body   string
+		wantContentTypeJ bool
+	}{
+		{"/app.js", "gzip, br", "br", "brotli", true},
+		{"/app.js", "gzip, br;q=0", "gzip", "gzip", true},
+		{"/app.js", "*", "br", "brotli", true},
+		{"/app.js", "identity", "", "console.log(1)", true},
+		{"/style.css", "br, gzip", "gzip", "gzip css", false},
+	}
+	for _, tt := range tests {
+		rec := serveStatic(s, http.MethodGet, tt.target, tt.accept)
+		if got := rec.Header().Get("Content-Encoding"); got != tt.encoding {
+			t.Errorf("%s with %q: Content-Encoding = %q, want %q", tt.target, tt.accept, got, tt.encoding)
+		}
+		if rec.Body.String() != tt.body {
+			t.Errorf("%s with %q: body = %q, want %q", tt.target, tt.accept, rec.Body, tt.body)
+		}
+		if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+			t.Errorf("%s with %q: Vary = %q, want Accept-Encoding", tt.target, tt.accept, got)
+		}
+	}
+
+	rec := serveStatic(s, http.MethodGet, "/app.js", "br")
+	if got := rec.Header().Get("Content-Type"); got != "text/javascript; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want the type of the uncompressed file", got)
+	}
+}
+
+func TestEncodingQuality(t *testing.T) {
+	tests := []struct {
+		accept, encoding string
+		q                float64
+		ok               bool
+	}{
+		{"gzip", "gzip", 1, true},
+		{"GZIP;q=0.5", "gzip", 0.5, true},
+		{"*;q=0.2, gzip;q=0", "gzip", 0, true},
+		{"*;q=0.2", "br", 0.2, true},
+		{"deflate", "gzip", 0, false},
+	}
+	for _, tt := range tests {
+		if q, ok := encodingQuality(tt.accept, tt.encoding); q != tt.q || ok != tt.ok {
+			t.Errorf("encodingQuality(%q, %q) = %v, %v, want %v, %v", tt.accept, tt.encoding, q, ok, tt.q, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package y_middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+// StatusCounter is a middleware handler that counts responses per status code and per status
+// class, as a lightweight source for a built-in dashboard. Responses the handler did not set a
+// status for count as 200. It is safe for concurrent use.
+type StatusCounter struct {
+	codes   [600]int64
+	classes [6]int64
+}
+
+// NewStatusCounter returns a new StatusCounter instance.
+func NewStatusCounter() *StatusCounter {
+	return &StatusCounter{}
+}
+
+func (c *StatusCounter) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	res := wrapResponseWriter(rw)
+	next(res, r)
+
+	status := res.Status()
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if status < 100 || status >= len(c.codes) {
+		return
+	}
+	atomic.AddInt64(&c.codes[status], 1)
+	atomic.AddInt64(&c.classes[status/100], 1)
+}
+
+// Snapshot returns the count of every status code seen since the last Reset.
+func (c *StatusCounter) Snapshot() map[int]int64 {
+	snapshot := make(map[int]int64)
+	for code := range c.codes {
+		if n := atomic.LoadInt64(&c.codes[code]); n > 0 {
+			snapshot[code] = n
+		}
+	}
+	return snapshot
+}
+
+// Classes returns the count of every status class seen since the last Reset, keyed "1xx" to "5xx".
+func (c *StatusCounter) Classes() map[string]int64 {
+	classes := make(map[string]int64)
+	for class := 1; class < len(c.classes); class++ {
+		if n := atomic.LoadInt64(&c.classes[class]); n > 0 {
+			classes[strconv.Itoa(class)+"xx"] = n
+		}
+	}
+	return classes
+}
+
+// Reset sets all counts back to zero.
+func (c *StatusCounter) Reset() {
+	for code := range c.codes {
+		atomic.StoreInt64(&c.codes[code], 0)
+	}
+	for class := range c.classes {
+		atomic.StoreInt64(&c.classes[class], 0)
+	}
+}
@@ -0,0 +1,46 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestStatusCounter(t *testing.T) {
+	c := NewStatusCounter()
+	k := New(c)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if code := r.URL.Query().Get("code"); code != "" {
+			n, _ := strconv.Atoi(code)
+			rw.WriteHeader(n)
+		}
+	})
+
+	var wg sync.WaitGroup
+	for _, code := range []string{"", "200", "404", "404", "500", "503"} {
+		wg.Add(1)
+		go func(code string) {
+			defer wg.Done()
+			k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/?code="+code, nil))
+		}(code)
+	}
+	wg.Wait()
+
+	if got, want := c.Snapshot(), map[int]int64{200: 2, 404: 2, 500: 1, 503: 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Snapshot() = %v, want %v", got, want)
+	}
+	if got, want := c.Classes(), map[string]int64{"2xx": 2, "4xx": 2, "5xx": 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Classes() = %v, want %v", got, want)
+	}
+
+	c.Reset()
+	if got := c.Snapshot(); len(got) != 0 {
+		t.Errorf("Snapshot() after Reset = %v, want empty", got)
+	}
+	if got := c.Classes(); len(got) != 0 {
+		t.Errorf("Classes() after Reset = %v, want empty", got)
+	}
+}
@@ -0,0 +1,94 @@
+package y_middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultTeeMaxSize is the largest part of a response body Tee copies by default.
+const DefaultTeeMaxSize = 64 << 10
+
+// Tee is a middleware handler that copies response bodies to a writer, e.g. an audit log sink,
+// while they are written to the client as usual. Each copy is written to the sink in a single
+// Write once the response is done, so concurrent responses never interleave. A copy is framed
+// as its length in decimal, a newline, the copied bytes and a closing newline, so a reader can
+// split the sink into bodies even when they contain newlines themselves.
+type Tee struct {
+	// MaxSize is the largest part of a body that is copied; the rest only goes to the client.
+	MaxSize int
+	// ContentTypes limits copying to responses whose Content-Type starts with one of these
+	// prefixes. An empty list copies every response.
+	ContentTypes []string
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewTee returns a new Tee instance copying response bodies to w.
+func NewTee(w io.Writer) *Tee {
+	return &Tee{
+		MaxSize: DefaultTeeMaxSize,
+		w:       w,
+	}
+}
+
+func (t *Tee) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	tw := &teeResponseWriter{ResponseWriter: wrapResponseWriter(rw), tee: t}
+	next(tw, r)
+
+	if tw.buf.Len() > 0 {
+		record := strconv.AppendInt(nil, int64(tw.buf.Len()), 10)
+		record = append(record, '\n')
+		record = append(record, tw.buf.Bytes()...)
+		record = append(record, '\n')
+
+		t.mu.Lock()
+		t.w.Write(record)
+		t.mu.Unlock()
+	}
+}
+
+func (t *Tee) matches(contentType string) bool {
+	if len(t.ContentTypes) == 0 {
+		return true
+	}
+	for _, prefix := range t.ContentTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+type teeResponseWriter struct {
+	ResponseWriter
+	tee *Tee
+	buf bytes.Buffer
+	// decided and copying record whether the response's content type is copied.
+	decided bool
+	copying bool
+}
+
+func (w *teeResponseWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		w.decided = true
+		w.copying = w.tee.matches(w.Header().Get("Content-Type"))
+	}
+
+	n, err := w.ResponseWriter.Write(p)
+	if w.copying {
+		if room := w.tee.MaxSize - w.buf.Len(); room > 0 {
+			w.buf.Write(p[:min(n, room)])
+		}
+	}
+	return n, err
+}
+
+// Unwrap returns the wrapped http.ResponseWriter so http.ResponseController can reach it.
+func (w *teeResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,107 @@
+package y_middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func serveTee(tee *Tee, contentType, body string) *httptest.ResponseRecorder {
+	k := New(tee)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", contentType)
+		io.WriteString(rw, body[:len(body)/2])
+		io.WriteString(rw, body[len(body)/2:])
+	})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	return rec
+}
+
+func TestTeeCopiesBody(t *testing.T) {
+	var sink bytes.Buffer
+	rec := serveTee(NewTee(&sink), "application/json", `{"ok":true}`)
+	if rec.Body.String() != `{"ok":true}` {
+		t.Errorf("client body = %q", rec.Body)
+	}
+	if sink.String() != "11\n{\"ok\":true}\n" {
+		t.Errorf("sink = %q, want the response body", sink.String())
+	}
+}
+
+func TestTeeMaxSize(t *testing.T) {
+	var sink bytes.Buffer
+	tee := NewTee(&sink)
+	tee.MaxSize = 5
+	rec := serveTee(tee, "text/plain", "hello world")
+	if rec.Body.String() != "hello world" {
+		t.Errorf("client body = %q, want it complete", rec.Body)
+	}
+	if sink.String() != "5\nhello\n" {
+		t.Errorf("sink = %q, want the first 5 bytes", sink.String())
+	}
+}
+
+func TestTeeContentTypes(t *testing.T) {
+	var sink bytes.Buffer
+	tee := NewTee(&sink)
+	tee.ContentTypes = []string{"application/json"}
+
+	serveTee(tee, "text/html", "<p>skipped</p>")
+	if sink.Len() != 0 {
+		t.Errorf("sink = %q, want nothing for text/html", sink.String())
+	}
+	serveTee(tee, "application/json; charset=utf-8", "[1]")
+	if sink.String() != "3\n[1]\n" {
+		t.Errorf("sink = %q, want the JSON body", sink.String())
+	}
+}
+
+func TestTeeFramesEachBody(t *testing.T) {
+	var sink bytes.Buffer
+	tee := NewTee(&sink)
+	bodies := []string{"line one\nline two\n", "5\nfake", "last"}
+	for _, body := range bodies {
+		serveTee(tee, "text/plain", body)
+	}
+
+	rest := sink.Bytes()
+	for i, want := range bodies {
+		header, body, ok := bytes.Cut(rest, []byte("\n"))
+		n, err := strconv.Atoi(string(header))
+		if !ok || err != nil || len(body) < n+1 || body[n] != '\n' {
+			t.Fatalf("record %d is malformed: %q", i, rest)
+		}
+		if got := string(body[:n]); got != want {
+			t.Errorf("record %d = %q, want %q", i, got, want)
+		}
+		rest = body[n+1:]
+	}
+	if len(rest) != 0 {
+		t.Errorf("trailing sink data %q", rest)
+	}
+}
+
+func TestTeeKeepsResponseWriter(t *testing.T) {
+	var sink bytes.Buffer
+	tee := NewTee(&sink)
+	var status int
+	tee.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusCreated)
+		io.WriteString(rw, "made")
+		res, ok := rw.(ResponseWriter)
+		if !ok {
+			t.Fatal("Tee hid the ResponseWriter")
+		}
+		status = res.Status()
+	})
+	if status != http.StatusCreated {
+		t.Errorf("Status() = %d, want %d", status, http.StatusCreated)
+	}
+	if sink.String() != "4\nmade\n" {
+		t.Errorf("sink = %q", sink.String())
+	}
+}
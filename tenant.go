@@ -0,0 +1,73 @@
+package y_middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type tenantKey struct{}
+
+// Tenant is a middleware handler that resolves which tenant a request belongs to and stores it in
+// the request context, where handlers read it with TenantFrom. Requests for unknown tenants get a
+// 404 and the rest of the chain is skipped. SubdomainTenant, HeaderTenant and PathTenant build
+// resolvers for the common sources.
+type Tenant struct {
+	resolver func(r *http.Request) (string, bool)
+}
+
+// NewTenant returns a new Tenant instance using resolver, which returns the tenant of a
+// request and whether it is known.
+func NewTenant(resolver func(r *http.Request) (string, bool)) *Tenant {
+	return &Tenant{resolver: resolver}
+}
+
+func (t *Tenant) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	tenant, ok := t.resolver(r)
+	if !ok || tenant == "" {
+		http.NotFound(rw, r)
+		return
+	}
+	next(rw, r.WithContext(context.WithValue(r.Context(), tenantKey{}, tenant)))
+}
+
+// TenantFrom returns the tenant resolved by Tenant, or an empty string if there is none.
+func TenantFrom(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// SubdomainTenant returns a resolver taking the tenant from the leftmost label of the host,
+// e.g. "acme" for acme.example.com. Hosts with fewer than three labels have no tenant.
+func SubdomainTenant(known func(tenant string) bool) func(r *http.Request) (string, bool) {
+	return func(r *http.Request) (string, bool) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		labels := strings.Split(host, ".")
+		if len(labels) < 3 {
+			return "", false
+		}
+		tenant := strings.ToLower(labels[0])
+		return tenant, known(tenant)
+	}
+}
+
+// HeaderTenant returns a resolver taking the tenant from the request header name.
+func HeaderTenant(name string, known func(tenant string) bool) func(r *http.Request) (string, bool) {
+	return func(r *http.Request) (string, bool) {
+		tenant := r.Header.Get(name)
+		return tenant, tenant != "" && known(tenant)
+	}
+}
+
+// PathTenant returns a resolver taking the tenant from the first path segment,
+// e.g. "acme" for /acme/orders.
+func PathTenant(known func(tenant string) bool) func(r *http.Request) (string, bool) {
+	return func(r *http.Request) (string, bool) {
+		tenant, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		return tenant, tenant != "" && known(tenant)
+	}
+}
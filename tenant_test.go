@@ -0,0 +1,73 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func knownTenant(tenant string) bool {
+	return tenant == "acme" || tenant == "globex"
+}
+
+func resolveTenant(resolver func(r *http.Request) (string, bool), req *http.Request) (int, string) {
+	var tenant string
+	k := New(NewTenant(resolver))
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		tenant = TenantFrom(r.Context())
+	})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, req)
+	return rec.Code, tenant
+}
+
+func TestSubdomainTenant(t *testing.T) {
+	tests := []struct {
+		host   string
+		status int
+		tenant string
+	}{
+		{"acme.example.com", http.StatusOK, "acme"},
+		{"GLOBEX.example.com:8080", http.StatusOK, "globex"},
+		{"initech.example.com", http.StatusNotFound, ""},
+		{"example.com", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Host = tt.host
+		status, tenant := resolveTenant(SubdomainTenant(knownTenant), req)
+		if status != tt.status || tenant != tt.tenant {
+			t.Errorf("host %s: status, tenant = %d, %q, want %d, %q", tt.host, status, tenant, tt.status, tt.tenant)
+		}
+	}
+}
+
+func TestHeaderTenant(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Tenant", "acme")
+	if status, tenant := resolveTenant(HeaderTenant("X-Tenant", knownTenant), req); status != http.StatusOK || tenant != "acme" {
+		t.Errorf("status, tenant = %d, %q, want 200, acme", status, tenant)
+	}
+	if status, _ := resolveTenant(HeaderTenant("X-Tenant", knownTenant), httptest.NewRequest(http.MethodGet, "/", nil)); status != http.StatusNotFound {
+		t.Errorf("status = %d without the header, want %d", status, http.StatusNotFound)
+	}
+}
+
+func TestPathTenant(t *testing.T) {
+	tests := []struct {
+		path   string
+		status int
+		tenant string
+	}{
+		{"/acme/orders", http.StatusOK, "acme"},
+		{"/globex", http.StatusOK, "globex"},
+		{"/initech/orders", http.StatusNotFound, ""},
+		{"/", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		status, tenant := resolveTenant(PathTenant(knownTenant), httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if status != tt.status || tenant != tt.tenant {
+			t.Errorf("path %s: status, tenant = %d, %q, want %d, %q", tt.path, status, tenant, tt.status, tt.tenant)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TimelineTrailer is the response trailer Timeline reports the timeline in.
+const TimelineTrailer = "X-Timeline"
+
+type timelineKey struct{}
+
+// TimelineEntry records when a Named handler was entered and exited, relative to the start of
+// the request.
+type TimelineEntry struct {
+	Name  string
+	Enter time.Duration
+	Exit  time.Duration
+}
+
+type timeline struct {
+	start   time.Time
+	clock   Clock
+	mu      sync.Mutex
+	entries []TimelineEntry
+}
+
+// Timeline is a development middleware handler that records when each Named handler in the
+// stack is entered and exited, showing where time is spent. The timeline is sent in the
+// X-Timeline response trailer as "name;enter=...;exit=..." entries in the order the handlers
+// were entered. It only runs while Enabled is set or when the request carries the Header.
+type Timeline struct {
+	Enabled bool
+	// Header is the request header that turns the timeline on for a single request.
+	Header string
+
+	clock Clock
+}
+
+// NewTimeline returns a new Timeline instance recording requests that carry an X-Timeline header.
+func NewTimeline() *Timeline {
+	return &Timeline{Header: "X-Timeline"}
+}
+
+// WithClock makes the Timeline read the time from c instead of the system clock.
+func (t *Timeline) WithClock(c Clock) *Timeline {
+	t.clock = c
+	return t
+}
+
+func (t *Timeline) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !t.Enabled && (t.Header == "" || r.Header.Get(t.Header) == "") {
+		next(rw, r)
+		return
+	}
+
+	clock := clockOrDefault(t.clock)
+	tl := &timeline{start: clock.Now(), clock: clock}
+	rw.Header().Add("Trailer", TimelineTrailer)
+	next(rw, r.WithContext(context.WithValue(r.Context(), timelineKey{}, tl)))
+
+	rw.Header().Set(TimelineTrailer, tl.String())
+}
+
+// TimelineFrom returns the entries Timeline has recorded for the request so far.
+func TimelineFrom(ctx context.Context) []TimelineEntry {
+	tl, ok := ctx.Value(timelineKey{}).(*timeline)
+	if !ok {
+		return nil
+	}
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	return append([]TimelineEntry(nil), tl.entries...)
+}
+
+// Named wraps h so that it appears under name in a request Timeline. Without a Timeline
+// running for the request it just calls h.
+func Named(name string, h Handler) Handler {
+	return HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		tl, ok := r.Context().Value(timelineKey{}).(*timeline)
+		if !ok {
+			h.ServeHTTP(rw, r, next)
+			return
+		}
+		i := tl.enter(name)
+		defer tl.exit(i)
+		h.ServeHTTP(rw, r, next)
+	})
+}
+
+func (tl *timeline) enter(name string) int {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	tl.entries = append(tl.entries, TimelineEntry{Name: name, Enter: tl.clock.Now().Sub(tl.start)})
+	return len(tl.entries) - 1
+}
+
+func (tl *timeline) exit(i int) {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	tl.entries[i].Exit = tl.clock.Now().Sub(tl.start)
+}
+
+func (tl *timeline) String() string {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+
+	parts := make([]string, len(tl.entries))
+	for i, e := range tl.entries {
+		parts[i] = e.Name + ";enter=" + e.Enter.String() + ";exit=" + e.Exit.String()
+	}
+	return strings.Join(parts, ", ")
+}
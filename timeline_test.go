@@ -0,0 +1,71 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func timelineStack(tl *Timeline, clock *fakeClock) *Kudret {
+	step := func(d time.Duration) HandlerFunc {
+		return func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+			clock.Advance(d)
+			next(rw, r)
+			clock.Advance(d)
+		}
+	}
+	k := New(tl, Named("auth", step(time.Millisecond)), Named("app", step(5*time.Millisecond)))
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte("ok"))
+	})
+	return k
+}
+
+func TestTimelineTrailer(t *testing.T) {
+	clock := newFakeClock()
+	k := timelineStack(NewTimeline().WithClock(clock), clock)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Timeline", "1")
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, r)
+
+	want := "auth;enter=0s;exit=12ms, app;enter=1ms;exit=11ms"
+	if got := rec.Result().Trailer.Get(TimelineTrailer); got != want {
+		t.Errorf("trailer = %q, want %q", got, want)
+	}
+}
+
+func TestTimelineOff(t *testing.T) {
+	clock := newFakeClock()
+	k := timelineStack(NewTimeline().WithClock(clock), clock)
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := rec.Header().Get("Trailer"); got != "" {
+		t.Errorf("Trailer = %q without the header, want none", got)
+	}
+	if rec.Body.String() != "ok" {
+		t.Errorf("body = %q, want the handler to run", rec.Body)
+	}
+}
+
+func TestTimelineFrom(t *testing.T) {
+	clock := newFakeClock()
+	tl := NewTimeline().WithClock(clock)
+	tl.Enabled = true
+
+	var entries []TimelineEntry
+	k := New(tl, Named("outer", HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		clock.Advance(2 * time.Millisecond)
+		next(rw, r)
+	})))
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		entries = TimelineFrom(r.Context())
+	})
+	k.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if len(entries) != 1 || entries[0].Name != "outer" || entries[0].Enter != 0 || entries[0].Exit != 0 {
+		t.Errorf("entries = %+v, want outer entered and not yet exited", entries)
+	}
+}
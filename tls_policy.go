@@ -0,0 +1,128 @@
+package y_middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// TLSPolicy is a middleware handler that rejects requests made over a TLS connection older
+// than MinVersion or using a cipher suite outside CipherSuites, skipping the rest of the chain.
+// Requests without TLS are rejected too.
+//
+// By default the negotiated parameters are read from r.TLS. When TLS is terminated upstream,
+// set VersionHeader and CipherSuiteHeader to the headers the proxy forwards them in. Only do so
+// behind a proxy that overwrites those headers, since clients can set them.
+type TLSPolicy struct {
+	// MinVersion is the oldest accepted TLS version, e.g. tls.VersionTLS12.
+	MinVersion uint16
+	// CipherSuites are the accepted cipher suite IDs. An empty list accepts any suite. When
+	// VersionHeader is set, CipherSuiteHeader must be set too, or the suite is unknown and
+	// every request is rejected.
+	CipherSuites []uint16
+	// Status is the response status for rejected requests. Defaults to 426 Upgrade Required.
+	Status int
+	// VersionHeader names the header carrying the TLS version negotiated upstream,
+	// e.g. "TLSv1.2" or "1.3".
+	VersionHeader string
+	// CipherSuiteHeader names the header carrying the cipher suite negotiated upstream,
+	// either as its IANA name or as a hex ID like "0x1301".
+	CipherSuiteHeader string
+}
+
+// NewTLSPolicy returns a new TLSPolicy instance that reads r.TLS and requires at least minVersion.
+func NewTLSPolicy(minVersion uint16, cipherSuites ...uint16) *TLSPolicy {
+	return &TLSPolicy{
+		MinVersion:   minVersion,
+		CipherSuites: cipherSuites,
+		Status:       http.StatusUpgradeRequired,
+	}
+}
+
+func (p *TLSPolicy) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	version, suite, ok := p.negotiated(r)
+	if !ok || version < p.MinVersion || !p.allowedSuite(suite) {
+		status := p.Status
+		if status == 0 {
+			status = http.StatusUpgradeRequired
+		}
+		if status == http.StatusUpgradeRequired {
+			rw.Header().Set("Upgrade", strings.Replace(tls.VersionName(p.MinVersion), " ", "/", 1))
+			rw.Header().Set("Connection", "Upgrade")
+		}
+		http.Error(rw, http.StatusText(status), status)
+		return
+	}
+	next(rw, r)
+}
+
+// negotiated returns the TLS version and cipher suite the request was made with.
+func (p *TLSPolicy) negotiated(r *http.Request) (version, suite uint16, ok bool) {
+	if p.VersionHeader == "" {
+		if r.TLS == nil {
+			return 0, 0, false
+		}
+		return r.TLS.Version, r.TLS.CipherSuite, true
+	}
+
+	version, ok = parseTLSVersion(r.Header.Get(p.VersionHeader))
+	if !ok {
+		return 0, 0, false
+	}
+	if p.CipherSuiteHeader != "" {
+		if suite, ok = parseCipherSuite(r.Header.Get(p.CipherSuiteHeader)); !ok {
+			return 0, 0, false
+		}
+	}
+	return version, suite, true
+}
+
+func (p *TLSPolicy) allowedSuite(suite uint16) bool {
+	if len(p.CipherSuites) == 0 {
+		return true
+	}
+	// Behind a proxy that forwards only the version the suite is unknown, and a policy that
+	// lists suites must not skip its check.
+	if p.VersionHeader != "" && p.CipherSuiteHeader == "" {
+		return false
+	}
+	for _, s := range p.CipherSuites {
+		if s == suite {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTLSVersion accepts the common spellings proxies use, such as "TLSv1.2", "TLS 1.2" and "1.2".
+func parseTLSVersion(v string) (uint16, bool) {
+	v = strings.TrimSpace(strings.ToUpper(v))
+	v = strings.TrimPrefix(v, "TLS")
+	v = strings.TrimLeft(v, "V/ ")
+	switch v {
+	case "1.0", "1":
+		return tls.VersionTLS10, true
+	case "1.1":
+		return tls.VersionTLS11, true
+	case "1.2":
+		return tls.VersionTLS12, true
+	case "1.3":
+		return tls.VersionTLS13, true
+	}
+	return 0, false
+}
+
+func parseCipherSuite(v string) (uint16, bool) {
+	v = strings.TrimSpace(v)
+	if strings.HasPrefix(v, "0x") || strings.HasPrefix(v, "0X") {
+		id, err := strconv.ParseUint(v[2:], 16, 16)
+		return uint16(id), err == nil
+	}
+	for _, s := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		if s.Name == v {
+			return s.ID, true
+		}
+	}
+	return 0, false
+}
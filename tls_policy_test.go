@@ -0,0 +1,103 @@
+package y_middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func tlsPolicyStatus(p *TLSPolicy, r *http.Request) (int, http.Header) {
+	k := New(p)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, r)
+	return rec.Code, rec.Header()
+}
+
+func tlsRequest(version, suite uint16) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "https://example.com/", nil)
+	req.TLS = &tls.ConnectionState{Version: version, CipherSuite: suite}
+	return req
+}
+
+func TestTLSPolicyConnection(t *testing.T) {
+	p := NewTLSPolicy(tls.VersionTLS12, tls.TLS_AES_128_GCM_SHA256)
+	tests := []struct {
+		name string
+		req  *http.Request
+		want int
+	}{
+		{"allowed", tlsRequest(tls.VersionTLS13, tls.TLS_AES_128_GCM_SHA256), http.StatusOK},
+		{"old version", tlsRequest(tls.VersionTLS11, tls.TLS_AES_128_GCM_SHA256), http.StatusUpgradeRequired},
+		{"other suite", tlsRequest(tls.VersionTLS13, tls.TLS_AES_256_GCM_SHA384), http.StatusUpgradeRequired},
+		{"no TLS", httptest.NewRequest(http.MethodGet, "/", nil), http.StatusUpgradeRequired},
+	}
+	for _, tt := range tests {
+		if got, _ := tlsPolicyStatus(p, tt.req); got != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+
+	_, h := tlsPolicyStatus(p, tlsRequest(tls.VersionTLS10, 0))
+	if got := h.Get("Upgrade"); got != "TLS/1.2" {
+		t.Errorf("Upgrade = %q, want TLS/1.2", got)
+	}
+}
+
+func TestTLSPolicyForbiddenStatus(t *testing.T) {
+	p := NewTLSPolicy(tls.VersionTLS12)
+	p.Status = http.StatusForbidden
+	got, h := tlsPolicyStatus(p, tlsRequest(tls.VersionTLS10, 0))
+	if got != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", got, http.StatusForbidden)
+	}
+	if h.Get("Upgrade") != "" {
+		t.Error("Upgrade header set on a 403")
+	}
+}
+
+func TestTLSPolicyHeaders(t *testing.T) {
+	p := NewTLSPolicy(tls.VersionTLS12, tls.TLS_AES_128_GCM_SHA256)
+	p.VersionHeader = "X-TLS-Version"
+	p.CipherSuiteHeader = "X-TLS-Cipher"
+	tests := []struct {
+		version, suite string
+		want           int
+	}{
+		{"TLSv1.3", "TLS_AES_128_GCM_SHA256", http.StatusOK},
+		{"1.2", "0x1301", http.StatusOK},
+		{"TLSv1.1", "TLS_AES_128_GCM_SHA256", http.StatusUpgradeRequired},
+		{"TLSv1.3", "TLS_AES_256_GCM_SHA384", http.StatusUpgradeRequired},
+		{"TLSv1.3", "", http.StatusUpgradeRequired},
+		{"", "TLS_AES_128_GCM_SHA256", http.StatusUpgradeRequired},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-TLS-Version", tt.version)
+		req.Header.Set("X-TLS-Cipher", tt.suite)
+		if got, _ := tlsPolicyStatus(p, req); got != tt.want {
+			t.Errorf("version %q, suite %q: status = %d, want %d", tt.version, tt.suite, got, tt.want)
+		}
+	}
+}
+
+func TestTLSPolicyVersionHeaderOnly(t *testing.T) {
+	tests := []struct {
+		suites []uint16
+		want   int
+	}{
+		{nil, http.StatusOK},
+		{[]uint16{tls.TLS_AES_128_GCM_SHA256}, http.StatusUpgradeRequired},
+	}
+	for _, tt := range tests {
+		p := NewTLSPolicy(tls.VersionTLS12, tt.suites...)
+		p.VersionHeader = "X-TLS-Version"
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-TLS-Version", "TLSv1.3")
+		if got, _ := tlsPolicyStatus(p, req); got != tt.want {
+			t.Errorf("suites %v without a cipher suite header: status = %d, want %d", tt.suites, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package y_middleware
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+type traceParentKey struct{}
+
+// TraceParent is the W3C Trace Context of an inbound request, parsed from its traceparent and
+// tracestate headers.
+type TraceParent struct {
+	TraceID    [16]byte
+	ParentID   [8]byte
+	Flags      byte
+	TraceState string
+}
+
+// Sampled reports whether the caller recorded its side of the trace.
+func (tp TraceParent) Sampled() bool {
+	return tp.Flags&0x01 != 0
+}
+
+// TraceContext is a middleware handler that parses the W3C Trace Context headers of inbound
+// requests and stores the result in the request context, where tracing middleware read it with
+// TraceParentFrom. Malformed traceparent headers are ignored, so the request starts a new trace.
+type TraceContext struct{}
+
+// NewTraceContext returns a new TraceContext instance.
+func NewTraceContext() *TraceContext {
+	return &TraceContext{}
+}
+
+func (t *TraceContext) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if tp, ok := parseTraceParent(r.Header.Get("traceparent")); ok {
+		tp.TraceState = strings.Join(r.Header.Values("tracestate"), ",")
+		r = r.WithContext(context.WithValue(r.Context(), traceParentKey{}, tp))
+	}
+	next(rw, r)
+}
+
+// parseTraceParent parses a traceparent header of the form version-traceid-parentid-flags.
+func parseTraceParent(v string) (TraceParent, bool) {
+	var tp TraceParent
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return tp, false
+	}
+	if len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return tp, false
+	}
+	if strings.ToLower(v) != v {
+		return tp, false
+	}
+
+	var flags [1]byte
+	if _, err := hex.Decode(tp.TraceID[:], []byte(parts[1])); err != nil {
+		return tp, false
+	}
+	if _, err := hex.Decode(tp.ParentID[:], []byte(parts[2])); err != nil {
+		return tp, false
+	}
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return tp, false
+	}
+	if tp.TraceID == [16]byte{} || tp.ParentID == [8]byte{} {
+		return tp, false
+	}
+	tp.Flags = flags[0]
+	return tp, true
+}
+
+// TraceParentFrom returns the trace context TraceContext parsed from the request headers. It
+// returns false if there was none or it was malformed.
+func TraceParentFrom(ctx context.Context) (TraceParent, bool) {
+	tp, ok := ctx.Value(traceParentKey{}).(TraceParent)
+	return tp, ok
+}
@@ -0,0 +1,58 @@
+package y_middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	tests := []struct {
+		header string
+		ok     bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-zz", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if _, ok := parseTraceParent(tt.header); ok != tt.ok {
+			t.Errorf("parseTraceParent(%q) ok = %v, want %v", tt.header, ok, tt.ok)
+		}
+	}
+}
+
+func TestTraceContext(t *testing.T) {
+	var tp TraceParent
+	var ok bool
+	k := New(NewTraceContext())
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		tp, ok = TraceParentFrom(r.Context())
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.Header.Add("tracestate", "a=1")
+	r.Header.Add("tracestate", "b=2")
+	k.ServeHTTP(httptest.NewRecorder(), r)
+	if !ok || tp.TraceID[0] != 0x4b || tp.ParentID[7] != 0xb7 || !tp.Sampled() || tp.TraceState != "a=1,b=2" {
+		t.Errorf("TraceParentFrom = %+v, %v", tp, ok)
+	}
+
+	r.Header.Set("traceparent", "garbage")
+	k.ServeHTTP(httptest.NewRecorder(), r)
+	if ok {
+		t.Error("malformed traceparent was stored")
+	}
+	if _, ok := TraceParentFrom(context.Background()); ok {
+		t.Error("TraceParentFrom reported a trace without TraceContext in the chain")
+	}
+}
@@ -0,0 +1,73 @@
+package y_middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Upgrade is a middleware handler that hands protocol upgrade requests, such as WebSocket or
+// h2c handshakes, straight to a registered upgrader. The upgrader gets the server's own
+// http.ResponseWriter, with every wrapper added by the stack removed, and the rest of the chain
+// is skipped, so buffering, compression or logging wrappers cannot corrupt the handshake. It
+// should be added to the stack before any middleware that wraps the response. Requests without
+// a matching upgrader pass through.
+type Upgrade struct {
+	upgraders map[string]http.Handler
+}
+
+// NewUpgrade returns a new Upgrade instance with no upgraders registered.
+func NewUpgrade() *Upgrade {
+	return &Upgrade{upgraders: make(map[string]http.Handler)}
+}
+
+// Handle registers h as the upgrader for protocol, e.g. "websocket". It must not be called
+// while requests are being served.
+func (u *Upgrade) Handle(protocol string, h http.Handler) {
+	u.upgraders[strings.ToLower(protocol)] = h
+}
+
+func (u *Upgrade) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if h := u.upgrader(r); h != nil {
+		h.ServeHTTP(unwrapResponseWriter(rw), r)
+		return
+	}
+	next(rw, r)
+}
+
+func (u *Upgrade) upgrader(r *http.Request) http.Handler {
+	if !headerHasToken(r.Header, "Connection", "upgrade") {
+		return nil
+	}
+	for _, v := range r.Header.Values("Upgrade") {
+		for _, protocol := range strings.Split(v, ",") {
+			protocol, _, _ = strings.Cut(strings.TrimSpace(protocol), "/")
+			if h, ok := u.upgraders[strings.ToLower(protocol)]; ok {
+				return h
+			}
+		}
+	}
+	return nil
+}
+
+// headerHasToken reports whether the comma separated header name contains token.
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// unwrapResponseWriter removes every wrapper around rw that exposes an Unwrap method.
+func unwrapResponseWriter(rw http.ResponseWriter) http.ResponseWriter {
+	for {
+		unwrapper, ok := rw.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return rw
+		}
+		rw = unwrapper.Unwrap()
+	}
+}
@@ -0,0 +1,90 @@
+package y_middleware
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUpgradeUnwrapsResponseWriter(t *testing.T) {
+	var got http.ResponseWriter
+	u := NewUpgrade()
+	u.Handle("WebSocket", http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		got = rw
+		rw.WriteHeader(http.StatusSwitchingProtocols)
+	}))
+	nextCalled := false
+	k := New(NewBufferResponse(DefaultBufferResponseMaxSize), u)
+	k.UseHandlerFunc(func(http.ResponseWriter, *http.Request) { nextCalled = true })
+
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	r.Header.Set("Connection", "keep-alive, Upgrade")
+	r.Header.Set("Upgrade", "websocket/13")
+	k.ServeHTTP(rec, r)
+
+	if got != rec {
+		t.Errorf("upgrader got %T, want the server's own ResponseWriter", got)
+	}
+	if nextCalled || rec.Code != http.StatusSwitchingProtocols {
+		t.Errorf("next called = %v, status = %d, want the upgrader to answer alone", nextCalled, rec.Code)
+	}
+}
+
+func TestUpgradePassesThrough(t *testing.T) {
+	u := NewUpgrade()
+	u.Handle("websocket", http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Error("upgrader called")
+	}))
+	k := New(u)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) { rw.WriteHeader(http.StatusTeapot) })
+
+	for _, headers := range []map[string]string{
+		{},
+		{"Upgrade": "websocket"},
+		{"Connection": "upgrade", "Upgrade": "h2c"},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		for name, value := range headers {
+			r.Header.Set(name, value)
+		}
+		rec := httptest.NewRecorder()
+		k.ServeHTTP(rec, r)
+		if rec.Code != http.StatusTeapot {
+			t.Errorf("%v: status = %d, want it passed on", headers, rec.Code)
+		}
+	}
+}
+
+func TestUpgradeHijacksThroughStack(t *testing.T) {
+	u := NewUpgrade()
+	u.Handle("echo", http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		conn, buf, err := http.NewResponseController(rw).Hijack()
+		if err != nil {
+			t.Errorf("Hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+		buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\nhello")
+		buf.Flush()
+	}))
+	srv := httptest.NewServer(New(NewGzip(-1), u))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "echo")
+	res, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %d, want %d", res.StatusCode, http.StatusSwitchingProtocols)
+	}
+	line, _ := bufio.NewReader(res.Body).ReadString('o')
+	if line != "hello" {
+		t.Errorf("read %q after the handshake, want hello", line)
+	}
+}
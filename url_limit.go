@@ -0,0 +1,26 @@
+package y_middleware
+
+import "net/http"
+
+// URLLimit is a middleware handler that rejects requests whose URL, path and query together,
+// is longer than a limit with a 414 URI Too Long, skipping the rest of the chain.
+type URLLimit struct {
+	max int
+}
+
+// NewURLLimit returns a new URLLimit instance allowing URLs of up to max bytes.
+func NewURLLimit(max int) *URLLimit {
+	return &URLLimit{max: max}
+}
+
+func (u *URLLimit) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	uri := r.RequestURI
+	if uri == "" {
+		uri = r.URL.RequestURI()
+	}
+	if len(uri) > u.max {
+		http.Error(rw, http.StatusText(http.StatusRequestURITooLong), http.StatusRequestURITooLong)
+		return
+	}
+	next(rw, r)
+}
@@ -0,0 +1,43 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestURLLimit(t *testing.T) {
+	k := New(NewURLLimit(16))
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		target string
+		want   int
+	}{
+		{"/short?q=1", http.StatusOK},
+		{"/exactly16?q=123", http.StatusOK},
+		{"/exactly16?q=1234", http.StatusRequestURITooLong},
+		{"/" + strings.Repeat("a", 20), http.StatusRequestURITooLong},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.target, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestURLLimitWithoutRequestURI(t *testing.T) {
+	k := New(NewURLLimit(8))
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/a?query=long", nil)
+	req.RequestURI = ""
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestURITooLong {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusRequestURITooLong)
+	}
+}
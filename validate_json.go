@@ -0,0 +1,83 @@
+package y_middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// DefaultValidateJSONMaxBodySize is the largest request body ValidateJSON reads by default.
+const DefaultValidateJSONMaxBodySize = 1 << 20
+
+// FieldError describes a single validation failure.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// JSONValidator validates a JSON request body. It lets ValidateJSON work with any JSON Schema
+// library, or hand-written checks, without depending on one.
+type JSONValidator interface {
+	// Validate returns the problems found in body, or none if it is valid.
+	Validate(body []byte) []FieldError
+}
+
+// JSONContextValidator is a JSONValidator that also gets the request context, e.g. to decode
+// the body with DecodeJSON. ValidateJSON calls ValidateContext instead of Validate when the
+// Validator implements it.
+type JSONContextValidator interface {
+	JSONValidator
+	ValidateContext(ctx context.Context, body []byte) []FieldError
+}
+
+// JSONValidatorFunc is an adapter to allow the use of ordinary functions as JSONValidator.
+type JSONValidatorFunc func(body []byte) []FieldError
+
+func (f JSONValidatorFunc) Validate(body []byte) []FieldError {
+	return f(body)
+}
+
+// ValidateJSON is a middleware handler that validates JSON request bodies before calling next.
+// Malformed JSON gets a 400, and bodies the Validator rejects get a 422 with the field errors
+// as a JSON document. The body is buffered so handlers can still read it.
+type ValidateJSON struct {
+	Validator   JSONValidator
+	MaxBodySize int64
+}
+
+// NewValidateJSON returns a new ValidateJSON instance checking bodies with v.
+func NewValidateJSON(v JSONValidator) *ValidateJSON {
+	return &ValidateJSON{
+		Validator:   v,
+		MaxBodySize: DefaultValidateJSONMaxBodySize,
+	}
+}
+
+func (v *ValidateJSON) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	body, err := bufferBody(r, v.MaxBodySize)
+	if errors.Is(err, errBodyTooLarge) {
+		http.Error(rw, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil || !json.Valid(body) {
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	var errs []FieldError
+	if cv, ok := v.Validator.(JSONContextValidator); ok {
+		errs = cv.ValidateContext(r.Context(), body)
+	} else {
+		errs = v.Validator.Validate(body)
+	}
+	if len(errs) > 0 {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(rw).Encode(struct {
+			Errors []FieldError `json:"errors"`
+		}{errs})
+		return
+	}
+	next(rw, r)
+}
@@ -0,0 +1,119 @@
+package y_middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// requireName is a JSONValidator requiring a non-empty "name" string.
+var requireName = JSONValidatorFunc(func(body []byte) []FieldError {
+	var v struct {
+		Name string `json:"name"`
+	}
+	json.Unmarshal(body, &v)
+	if v.Name == "" {
+		return []FieldError{{Field: "name", Message: "is required"}}
+	}
+	return nil
+})
+
+func serveValidateJSON(v *ValidateJSON, body string) (*httptest.ResponseRecorder, string) {
+	read := "<not served>"
+	k := New(v)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		read = string(b)
+	})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	return rec, read
+}
+
+func TestValidateJSONValidBody(t *testing.T) {
+	rec, read := serveValidateJSON(NewValidateJSON(requireName), `{"name":"widget"}`)
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if read != `{"name":"widget"}` {
+		t.Errorf("handler read %q, want the original body", read)
+	}
+}
+
+func TestValidateJSONFieldErrors(t *testing.T) {
+	rec, read := serveValidateJSON(NewValidateJSON(requireName), `{"name":""}`)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+	if read != "<not served>" {
+		t.Error("handler was served")
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	var res struct {
+		Errors []FieldError `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Errors) != 1 || res.Errors[0] != (FieldError{Field: "name", Message: "is required"}) {
+		t.Errorf("errors = %+v, want the name error", res.Errors)
+	}
+}
+
+func TestValidateJSONRejectsBadBodies(t *testing.T) {
+	small := NewValidateJSON(requireName)
+	small.MaxBodySize = 8
+
+	if rec, _ := serveValidateJSON(NewValidateJSON(requireName), `{"name":`); rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d for malformed JSON, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if rec, _ := serveValidateJSON(small, `{"name":"widget"}`); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d for a large body, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+// exactID is a JSONContextValidator requiring an "id" that decodes to an exact int64, which
+// only works for large ids when JSONNumberMode has the body decoded with UseNumber.
+type exactID struct{}
+
+func (exactID) Validate(body []byte) []FieldError {
+	return []FieldError{{Field: "id", Message: "Validate called instead of ValidateContext"}}
+}
+
+func (exactID) ValidateContext(ctx context.Context, body []byte) []FieldError {
+	var v struct {
+		ID any `json:"id"`
+	}
+	if err := DecodeJSON(ctx, bytes.NewReader(body), &v); err != nil {
+		return []FieldError{{Field: "id", Message: err.Error()}}
+	}
+	if n, ok := v.ID.(json.Number); ok {
+		if _, err := n.Int64(); err == nil {
+			return nil
+		}
+	}
+	return []FieldError{{Field: "id", Message: "must be an exact integer"}}
+}
+
+func TestValidateJSONContextValidator(t *testing.T) {
+	body := `{"id":9007199254740993}`
+	withNumbers := New(NewJSONNumberMode(true), NewValidateJSON(exactID{}))
+	withNumbers.UseHandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	rec := httptest.NewRecorder()
+	withNumbers.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Errorf("with JSONNumberMode: status = %d, body %s, want %d", rec.Code, rec.Body, http.StatusOK)
+	}
+
+	rec, _ = serveValidateJSON(NewValidateJSON(exactID{}), body)
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), "must be an exact integer") {
+		t.Errorf("without JSONNumberMode: got %d %s, want the float64 decode rejected", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,116 @@
+package y_middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// QueryType is the type a query parameter must parse as.
+type QueryType int
+
+const (
+	// QueryString accepts any value and stores it as a string.
+	QueryString QueryType = iota
+	// QueryInt accepts base 10 integers and stores them as an int.
+	QueryInt
+	// QueryBool accepts the values strconv.ParseBool does and stores them as a bool.
+	QueryBool
+	// QueryEnum accepts one of the rule's Enum values and stores it as a string.
+	QueryEnum
+)
+
+// QueryRule describes one query parameter checked by ValidateQuery.
+type QueryRule struct {
+	Name     string
+	Required bool
+	Type     QueryType
+	Enum     []string
+	// Pattern, if set, must match the raw value.
+	Pattern *regexp.Regexp
+}
+
+type queryValuesKey struct{}
+
+// ValidateQuery is a middleware handler that validates the request query parameters against a
+// set of rules before calling next. Requests breaking any rule get a 400 with the field errors
+// as a JSON document. The values of valid requests are stored, converted to their type, in the
+// request context, where handlers read them with QueryValues. Parameters without a rule are
+// left alone.
+type ValidateQuery struct {
+	Rules []QueryRule
+}
+
+// NewValidateQuery returns a new ValidateQuery instance checking rules.
+func NewValidateQuery(rules ...QueryRule) *ValidateQuery {
+	return &ValidateQuery{Rules: rules}
+}
+
+func (v *ValidateQuery) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	query := r.URL.Query()
+	values := make(map[string]any, len(v.Rules))
+	var errs []FieldError
+	for _, rule := range v.Rules {
+		raw, present := query[rule.Name]
+		if !present || len(raw) == 0 || raw[0] == "" {
+			if rule.Required {
+				errs = append(errs, FieldError{Field: rule.Name, Message: "is required"})
+			}
+			continue
+		}
+		value, msg := rule.parse(raw[0])
+		if msg != "" {
+			errs = append(errs, FieldError{Field: rule.Name, Message: msg})
+			continue
+		}
+		values[rule.Name] = value
+	}
+
+	if len(errs) > 0 {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(struct {
+			Errors []FieldError `json:"errors"`
+		}{errs})
+		return
+	}
+	next(rw, r.WithContext(context.WithValue(r.Context(), queryValuesKey{}, values)))
+}
+
+func (rule QueryRule) parse(raw string) (any, string) {
+	if rule.Pattern != nil && !rule.Pattern.MatchString(raw) {
+		return nil, "does not match " + rule.Pattern.String()
+	}
+	switch rule.Type {
+	case QueryInt:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, "must be an integer"
+		}
+		return n, ""
+	case QueryBool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, "must be a boolean"
+		}
+		return b, ""
+	case QueryEnum:
+		for _, e := range rule.Enum {
+			if raw == e {
+				return raw, ""
+			}
+		}
+		return nil, "must be one of " + strings.Join(rule.Enum, ", ")
+	}
+	return raw, ""
+}
+
+// QueryValues returns the query parameters ValidateQuery checked, converted to the type of their
+// rule: string, int or bool. Optional parameters missing from the request are left out.
+func QueryValues(ctx context.Context) map[string]any {
+	values, _ := ctx.Value(queryValuesKey{}).(map[string]any)
+	return values
+}
@@ -0,0 +1,64 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var searchRules = []QueryRule{
+	{Name: "q", Required: true, Pattern: regexp.MustCompile(`^\w+$`)},
+	{Name: "limit", Type: QueryInt},
+	{Name: "exact", Type: QueryBool},
+	{Name: "sort", Type: QueryEnum, Enum: []string{"asc", "desc"}},
+}
+
+func serveValidateQuery(query string) (*httptest.ResponseRecorder, map[string]any) {
+	var values map[string]any
+	k := New(NewValidateQuery(searchRules...))
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		values = QueryValues(r.Context())
+	})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search?"+query, nil))
+	return rec, values
+}
+
+func TestValidateQueryConvertsValues(t *testing.T) {
+	rec, values := serveValidateQuery("q=shoes&limit=20&exact=true&sort=desc&other=x")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	want := map[string]any{"q": "shoes", "limit": 20, "exact": true, "sort": "desc"}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("QueryValues = %v, want %v", values, want)
+	}
+
+	_, values = serveValidateQuery("q=shoes&limit=")
+	if want := map[string]any{"q": "shoes"}; !reflect.DeepEqual(values, want) {
+		t.Errorf("QueryValues with optional parameters missing = %v, want %v", values, want)
+	}
+}
+
+func TestValidateQueryRejects(t *testing.T) {
+	rec, values := serveValidateQuery("limit=ten&exact=maybe&sort=up")
+	if rec.Code != http.StatusBadRequest || values != nil {
+		t.Fatalf("status = %d, handler ran = %v, want 400 without calling next", rec.Code, values != nil)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	want := `{"errors":[{"field":"q","message":"is required"},{"field":"limit","message":"must be an integer"},` +
+		`{"field":"exact","message":"must be a boolean"},{"field":"sort","message":"must be one of asc, desc"}]}`
+	if got := strings.TrimSpace(rec.Body.String()); got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+
+	rec, _ = serveValidateQuery("q=two+words")
+	if !strings.Contains(rec.Body.String(), `"message":"does not match ^\\w+$"`) {
+		t.Errorf("pattern mismatch body = %s", rec.Body)
+	}
+}
@@ -0,0 +1,64 @@
+package y_middleware
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// WellKnownResponse is the canned response WellKnown serves for a path.
+type WellKnownResponse struct {
+	// Status defaults to 200, or to 204 when Body is empty.
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// WellKnown is a middleware handler that answers requests for a fixed set of paths, such as
+// /favicon.ico and /robots.txt, with canned responses instead of calling next. This keeps those
+// requests out of the application handlers and their logs. Other paths pass through.
+type WellKnown struct {
+	Responses map[string]WellKnownResponse
+}
+
+// NewWellKnown returns a new WellKnown instance answering /favicon.ico with a 204.
+func NewWellKnown() *WellKnown {
+	return &WellKnown{
+		Responses: map[string]WellKnownResponse{
+			"/favicon.ico": {Status: http.StatusNoContent},
+		},
+	}
+}
+
+// Set registers the response served for path.
+func (w *WellKnown) Set(path string, res WellKnownResponse) {
+	if w.Responses == nil {
+		w.Responses = make(map[string]WellKnownResponse)
+	}
+	w.Responses[path] = res
+}
+
+func (w *WellKnown) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	res, ok := w.Responses[r.URL.Path]
+	if !ok || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		next(rw, r)
+		return
+	}
+
+	status := res.Status
+	if status == 0 {
+		status = http.StatusOK
+		if len(res.Body) == 0 {
+			status = http.StatusNoContent
+		}
+	}
+	if res.ContentType != "" {
+		rw.Header().Set("Content-Type", res.ContentType)
+	}
+	if bodyAllowedForStatus(status) {
+		rw.Header().Set("Content-Length", strconv.Itoa(len(res.Body)))
+	}
+	rw.WriteHeader(status)
+	if r.Method != http.MethodHead && bodyAllowedForStatus(status) {
+		rw.Write(res.Body)
+	}
+}
@@ -0,0 +1,53 @@
+package y_middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWellKnown(t *testing.T) {
+	w := NewWellKnown()
+	w.Set("/robots.txt", WellKnownResponse{ContentType: "text/plain", Body: []byte("User-agent: *\n")})
+	w.Set("/gone", WellKnownResponse{Status: http.StatusGone})
+
+	k := New(w)
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusTeapot)
+	})
+
+	tests := []struct {
+		method, path string
+		status       int
+		body, length string
+		contentType  string
+	}{
+		{http.MethodGet, "/favicon.ico", http.StatusNoContent, "", "", ""},
+		{http.MethodGet, "/robots.txt", http.StatusOK, "User-agent: *\n", "14", "text/plain"},
+		{http.MethodHead, "/robots.txt", http.StatusOK, "", "14", "text/plain"},
+		{http.MethodGet, "/gone", http.StatusGone, "", "0", ""},
+		{http.MethodPost, "/robots.txt", http.StatusTeapot, "", "", ""},
+		{http.MethodGet, "/other", http.StatusTeapot, "", "", ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		k.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.status || rec.Body.String() != tt.body {
+			t.Errorf("%s %s: got %d %q, want %d %q", tt.method, tt.path, rec.Code, rec.Body, tt.status, tt.body)
+		}
+		if got := rec.Header().Get("Content-Length"); got != tt.length {
+			t.Errorf("%s %s: Content-Length = %q, want %q", tt.method, tt.path, got, tt.length)
+		}
+		if got := rec.Header().Get("Content-Type"); got != tt.contentType {
+			t.Errorf("%s %s: Content-Type = %q, want %q", tt.method, tt.path, got, tt.contentType)
+		}
+	}
+}
+
+func TestWellKnownSetOnZeroValue(t *testing.T) {
+	var w WellKnown
+	w.Set("/a", WellKnownResponse{Body: []byte("a")})
+	if _, ok := w.Responses["/a"]; !ok {
+		t.Error("Set on a zero WellKnown did not register the response")
+	}
+}
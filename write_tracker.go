@@ -0,0 +1,57 @@
+package y_middleware
+
+import (
+	"net/http"
+	"runtime/debug"
+)
+
+// WriteTracker is a development middleware handler that pinpoints the handler behind a
+// "superfluous WriteHeader call". It records a stack trace the first time the response header is
+// written, explicitly or by a Write, and logs every later WriteHeader call along with both stack
+// traces.
+type WriteTracker struct {
+	// Logger receives the warnings. When nil, the request's LoggerFrom logger is used.
+	Logger ALogger
+}
+
+// NewWriteTracker returns a new WriteTracker instance.
+func NewWriteTracker() *WriteTracker {
+	return &WriteTracker{}
+}
+
+func (t *WriteTracker) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	logger := t.Logger
+	if logger == nil {
+		logger = LoggerFrom(r.Context())
+	}
+	next(&trackingWriter{ResponseWriter: wrapResponseWriter(rw), logger: logger, r: r}, r)
+}
+
+// trackingWriter remembers where the response header was first written.
+type trackingWriter struct {
+	ResponseWriter
+	logger ALogger
+	r      *http.Request
+	first  []byte
+}
+
+func (w *trackingWriter) WriteHeader(code int) {
+	if w.first != nil {
+		w.logger.Printf("%s %s: superfluous WriteHeader(%d), status %d already written at:\n%s\nsuperfluous call at:\n%s",
+			w.r.Method, w.r.URL.Path, code, w.Status(), w.first, debug.Stack())
+		return
+	}
+	w.first = debug.Stack()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *trackingWriter) Write(p []byte) (int, error) {
+	if w.first == nil {
+		w.first = debug.Stack()
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *trackingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,60 @@
+package y_middleware
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func writeTwiceWithWriteHeader(rw http.ResponseWriter) {
+	rw.WriteHeader(http.StatusCreated)
+}
+
+func writeTwiceWithWrite(rw http.ResponseWriter) {
+	io.WriteString(rw, "body")
+}
+
+func TestWriteTrackerLogsSuperfluousWriteHeader(t *testing.T) {
+	for name, first := range map[string]func(http.ResponseWriter){
+		"WriteHeader": writeTwiceWithWriteHeader,
+		"Write":       writeTwiceWithWrite,
+	} {
+		var logged bytes.Buffer
+		k := New(&WriteTracker{Logger: log.New(&logged, "", 0)})
+		k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			first(rw)
+			rw.WriteHeader(http.StatusInternalServerError)
+		})
+		rec := httptest.NewRecorder()
+		k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+		if rec.Code == http.StatusInternalServerError {
+			t.Errorf("%s: the superfluous status reached the client", name)
+		}
+		out := logged.String()
+		if !strings.HasPrefix(out, "GET /orders: superfluous WriteHeader(500), status ") {
+			t.Errorf("%s: log = %q, want a superfluous WriteHeader warning", name, out)
+		}
+		if !strings.Contains(out, "writeTwiceWith"+name) || !strings.Contains(out, "superfluous call at:") {
+			t.Errorf("%s: log does not show both call sites:\n%s", name, out)
+		}
+	}
+}
+
+func TestWriteTrackerQuiet(t *testing.T) {
+	var logged bytes.Buffer
+	k := New(&WriteTracker{Logger: log.New(&logged, "", 0)})
+	k.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusAccepted)
+		io.WriteString(rw, "ok")
+	})
+	rec := httptest.NewRecorder()
+	k.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusAccepted || logged.Len() != 0 {
+		t.Errorf("got %d with log %q, want 202 and nothing logged", rec.Code, logged.String())
+	}
+}